@@ -2,17 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
 	"fullcycle-auction_go/internal/infra/api/web/controller/bid_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/event_controller"
 	"fullcycle-auction_go/internal/infra/api/web/controller/user_controller"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/infra/auth"
 	"fullcycle-auction_go/internal/infra/database/auction"
 	"fullcycle-auction_go/internal/infra/database/bid"
+	"fullcycle-auction_go/internal/infra/database/event"
+	"fullcycle-auction_go/internal/infra/database/migration"
 	"fullcycle-auction_go/internal/infra/database/user"
+	"fullcycle-auction_go/internal/infra/pubsub"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"fullcycle-auction_go/internal/usecase/user_usecase"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -20,7 +34,8 @@ import (
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	if err := godotenv.Load("cmd/auction/.env"); err != nil {
 		log.Fatal("Error trying to load env variables")
@@ -33,36 +48,177 @@ func main() {
 		return
 	}
 
+	if err := migration.NewMigrator(databaseConnection, migration.Migrations).Run(ctx); err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	hub, err := newEventHub(ctx, databaseConnection)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	go func() {
+		if err := hub.Run(ctx); err != nil {
+			logger.Error("Error receiving auction events", err)
+		}
+	}()
+
 	router := gin.Default()
 
-	userController, bidController, auctionsController := initDependencies(databaseConnection)
+	tokenService, err := newTokenService()
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	userController, bidController, auctionsController, eventController, auctionCloser, bidUseCase, userUseCase :=
+		initDependencies(databaseConnection, hub, tokenService)
+	authenticated := middleware.Authenticate(userUseCase)
+
+	closerDone := make(chan struct{})
+	go func() {
+		defer close(closerDone)
+		auctionCloser.Run(ctx)
+	}()
 
 	router.GET("/auction", auctionsController.FindAuctions)
 	router.GET("/auction/:auctionId", auctionsController.FindAuctionById)
-	router.POST("/auction", auctionsController.CreateAuction)
+	router.POST("/auction", authenticated, auctionsController.CreateAuction)
+	router.PUT("/auction/:auctionId", authenticated, auctionsController.UpdateAuction)
+	router.POST("/auction/:auctionId/cancel", authenticated, auctionsController.CancelAuction)
+	router.POST("/auction/:auctionId/close", authenticated, auctionsController.CloseAuction)
+	router.POST("/auction/:auctionId/relist", authenticated, auctionsController.RelistAuction)
 	router.GET("/auction/winner/:auctionId", auctionsController.FindWinningBidByAuctionId)
-	router.POST("/bid", bidController.CreateBid)
+	router.GET("/auction/:auctionId/events", eventController.StreamAuctionEvents)
+	router.POST("/bid", authenticated, bidController.CreateBid)
+	router.POST("/bid/proxy", authenticated, bidController.CreateProxyBid)
 	router.GET("/bid/:auctionId", bidController.FindBidByAuctionId)
 	router.GET("/user", userController.FindAllUsers)
 	router.GET("/user/:userId", userController.FindUserById)
+	router.POST("/user", userController.CreateUser)
+	router.PUT("/user/:userId", authenticated, userController.UpdateUser)
+	router.DELETE("/user/:userId", authenticated, userController.DeleteUser)
+	router.POST("/login", userController.Login)
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+	// Event streams never finish on their own; ending them lets Shutdown
+	// wait only for the regular requests.
+	server.RegisterOnShutdown(hub.Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error running http server", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	shutdown(server, bidUseCase, closerDone, databaseConnection.Client())
+}
 
-	router.Run(":8080")
+// shutdown stops the service in dependency order: in-flight requests finish
+// first, then the pending bid batch is stored, and only then is the database
+// connection closed. All steps share the SHUTDOWN_TIMEOUT deadline.
+func shutdown(
+	server *http.Server,
+	bidUseCase bid_usecase.BidUseCaseInterface,
+	closerDone <-chan struct{},
+	client *mongo.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), getShutdownTimeout())
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Error trying to shut down http server", err)
+	}
+
+	if err := bidUseCase.Close(ctx); err != nil {
+		logger.Error("Error trying to flush pending bids", err)
+	}
+
+	select {
+	case <-closerDone:
+	case <-ctx.Done():
+	}
+
+	if err := client.Disconnect(ctx); err != nil {
+		logger.Error("Error trying to disconnect from mongodb", err)
+	}
+
+	logger.Info("Server stopped")
+}
+
+// newEventHub creates the hub that feeds the auction event streams. With
+// EVENT_BACKEND=mongo events are shared with the other replicas through the
+// database; by default they stay in this process.
+func newEventHub(ctx context.Context, database *mongo.Database) (*pubsub.Hub, error) {
+	switch backend := os.Getenv("EVENT_BACKEND"); backend {
+	case "", "memory":
+		return pubsub.NewHub(nil), nil
+	case "mongo":
+		eventBackend, err := event.NewEventBackend(ctx, database)
+		if err != nil {
+			return nil, err
+		}
+		return pubsub.NewHub(eventBackend), nil
+	default:
+		return nil, fmt.Errorf("invalid EVENT_BACKEND %q: must be memory or mongo", backend)
+	}
+}
+
+func getShutdownTimeout() time.Duration {
+	duration, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || duration <= 0 {
+		return 10 * time.Second
+	}
+
+	return duration
 }
 
-func initDependencies(database *mongo.Database) (
+// newTokenService creates the service that signs access tokens with
+// JWT_SECRET; they expire after JWT_EXPIRATION.
+func newTokenService() (*auth.JWTService, error) {
+	secret, err := auth.GetJWTSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewJWTService(secret, auth.GetJWTExpiration(), time.Now), nil
+}
+
+func initDependencies(database *mongo.Database, hub *pubsub.Hub, tokenService *auth.JWTService) (
 	userController *user_controller.UserController,
 	bidController *bid_controller.BidController,
-	auctionController *auction_controller.AuctionController) {
+	auctionController *auction_controller.AuctionController,
+	eventController *event_controller.EventController,
+	auctionCloser *auction_usecase.AuctionCloser,
+	bidUseCase bid_usecase.BidUseCaseInterface,
+	userUseCase user_usecase.UserUseCaseInterface) {
 
 	auctionRepository := auction.NewAuctionRepository(database)
-	bidRepository := bid.NewBidRepository(database, auctionRepository)
+	bidRepository := bid.NewBidRepository(
+		database, auctionRepository, bid.NewProxyBidRepository(database))
 	userRepository := user.NewUserRepository(database)
 
-	userController = user_controller.NewUserController(
-		user_usecase.NewUserUseCase(userRepository))
-	auctionController = auction_controller.NewAuctionController(
-		auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository))
-	bidController = bid_controller.NewBidController(bid_usecase.NewBidUseCase(bidRepository))
+	bidUseCase = bid_usecase.NewBidUseCase(bidRepository, auctionRepository, hub)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, hub)
+
+	userUseCase = user_usecase.NewUserUseCase(userRepository, tokenService)
+	userController = user_controller.NewUserController(userUseCase)
+	auctionController = auction_controller.NewAuctionController(auctionUseCase)
+	eventController = event_controller.NewEventController(auctionUseCase, hub)
+	bidController = bid_controller.NewBidController(bidUseCase)
+	auctionCloser = auction_usecase.NewAuctionCloser(
+		auctionRepository, hub, auction_usecase.GetAuctionCloserInterval(), time.Now)
 
 	return
 }
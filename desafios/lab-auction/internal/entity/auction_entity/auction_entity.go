@@ -4,20 +4,42 @@ import (
 	"context"
 	"fullcycle-auction_go/internal/internal_error"
 	"github.com/google/uuid"
+	"os"
 	"time"
 )
 
+// CreateAuction creates an active auction sold by sellerId and ending at
+// endTime. A zero endTime falls back to the default duration configured by
+// AUCTION_INTERVAL and an empty auctionType to an English auction.
 func CreateAuction(
+	sellerId string,
 	productName, category, description string,
-	condition ProductCondition) (*Auction, *internal_error.InternalError) {
+	condition ProductCondition,
+	auctionType AuctionType,
+	endTime time.Time,
+	bidRules BidRules,
+	softClose SoftClose) (*Auction, *internal_error.InternalError) {
+	now := time.Now()
+	if endTime.IsZero() {
+		endTime = now.Add(GetAuctionInterval())
+	}
+	if auctionType == "" {
+		auctionType = English
+	}
+
 	auction := &Auction{
 		Id:          uuid.New().String(),
+		SellerId:    sellerId,
 		ProductName: productName,
 		Category:    category,
 		Description: description,
 		Condition:   condition,
+		Type:        auctionType,
 		Status:      Active,
-		Timestamp:   time.Now(),
+		Timestamp:   now,
+		EndTime:     endTime,
+		BidRules:    bidRules,
+		SoftClose:   softClose,
 	}
 
 	if err := auction.Validate(); err != nil {
@@ -28,6 +50,10 @@ func CreateAuction(
 }
 
 func (au *Auction) Validate() *internal_error.InternalError {
+	if err := uuid.Validate(au.SellerId); err != nil {
+		return internal_error.NewBadRequestError("SellerId is not a valid id")
+	}
+
 	if len(au.ProductName) <= 1 ||
 		len(au.Category) <= 2 ||
 		len(au.Description) <= 10 && (au.Condition != New &&
@@ -36,17 +62,69 @@ func (au *Auction) Validate() *internal_error.InternalError {
 		return internal_error.NewBadRequestError("invalid auction object")
 	}
 
+	if !au.Type.valid() {
+		return internal_error.NewBadRequestError("invalid auction type")
+	}
+
+	if !au.EndTime.After(au.Timestamp) {
+		return internal_error.NewBadRequestError("EndTime must be after the auction start")
+	}
+
+	if err := au.BidRules.Validate(); err != nil {
+		return err
+	}
+
+	if err := au.SoftClose.Validate(); err != nil {
+		return err
+	}
+
+	if err := au.Strategy().Validate(au); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// GetAuctionInterval returns the default auction duration, used when no end
+// time is given at creation.
+func GetAuctionInterval() time.Duration {
+	auctionInterval := os.Getenv("AUCTION_INTERVAL")
+	duration, err := time.ParseDuration(auctionInterval)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return duration
+}
+
 type Auction struct {
 	Id          string
+	SellerId    string
 	ProductName string
 	Category    string
 	Description string
 	Condition   ProductCondition
+	Type        AuctionType
 	Status      AuctionStatus
 	Timestamp   time.Time
+	EndTime     time.Time
+	BidRules    BidRules
+	SoftClose   SoftClose
+
+	// ExtendedBy is how much the soft-close rule has already extended
+	// EndTime.
+	ExtendedBy time.Duration
+
+	HighestBidId     string
+	HighestBidUserId string
+	HighestBidAmount float64
+
+	// BidCount is the number of accepted bids, sealed ones included.
+	BidCount int64
+	// Revision counts the seller's edits; a bid is only accepted against
+	// the revision it was validated with.
+	Revision int64
+	// RelistedFrom is the id of the unsold auction this one relists.
+	RelistedFrom string
 }
 
 type ProductCondition int
@@ -55,6 +133,7 @@ type AuctionStatus int
 const (
 	Active AuctionStatus = iota
 	Completed
+	Cancelled
 )
 
 const (
@@ -68,11 +147,25 @@ type AuctionRepositoryInterface interface {
 		ctx context.Context,
 		auctionEntity *Auction) *internal_error.InternalError
 
+	// FindAuctions returns the requested page of the auctions matching
+	// query and the total number of matches.
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]Auction, *internal_error.InternalError)
+		query AuctionQuery) ([]Auction, int64, *internal_error.InternalError)
 
 	FindAuctionById(
 		ctx context.Context, id string) (*Auction, *internal_error.InternalError)
+
+	CloseExpiredAuctions(
+		ctx context.Context, now time.Time) ([]string, *internal_error.InternalError)
+
+	// UpdateAuction stores the seller's edit of an auction, provided it is
+	// still active, has no bids and was not edited since it was loaded.
+	UpdateAuction(
+		ctx context.Context, auctionEntity *Auction) *internal_error.InternalError
+
+	// EndAuction stores the status and end time of an auction the seller
+	// cancelled or closed, provided it is still active.
+	EndAuction(
+		ctx context.Context, auctionEntity *Auction) *internal_error.InternalError
 }
@@ -0,0 +1,116 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+)
+
+// CheckSeller returns a forbidden error unless userId sold the auction.
+// Auctions created before sellers were recorded have no seller and cannot
+// be managed by anyone.
+func (au *Auction) CheckSeller(userId string) *internal_error.InternalError {
+	if au.SellerId == "" || au.SellerId != userId {
+		return internal_error.NewForbiddenError("Only the seller can manage this auction")
+	}
+
+	return nil
+}
+
+// CheckBidder rejects bids by the seller on their own auction.
+func (au *Auction) CheckBidder(userId string) *internal_error.InternalError {
+	if au.SellerId != "" && au.SellerId == userId {
+		return internal_error.NewBadRequestError("Sellers cannot bid on their own auctions")
+	}
+
+	return nil
+}
+
+// Editable reports whether the seller may still change the auction: it is
+// active and nobody has bid yet.
+func (au *Auction) Editable() bool {
+	return au.Status == Active && au.BidCount == 0 && !au.HasBids()
+}
+
+// Update replaces the listing and its rules. It is only allowed before the
+// first bid, and the new end time must be after at.
+func (au *Auction) Update(
+	productName, category, description string,
+	condition ProductCondition,
+	endTime time.Time,
+	bidRules BidRules,
+	softClose SoftClose,
+	at time.Time) *internal_error.InternalError {
+	if !au.Editable() {
+		return internal_error.NewBadRequestError("Auction can only be updated while active and before the first bid")
+	}
+
+	if endTime.IsZero() {
+		endTime = au.EndTime
+	}
+	if !endTime.After(at) {
+		return internal_error.NewBadRequestError("EndTime must be in the future")
+	}
+
+	updated := *au
+	updated.ProductName = productName
+	updated.Category = category
+	updated.Description = description
+	updated.Condition = condition
+	updated.EndTime = endTime
+	updated.BidRules = bidRules
+	updated.SoftClose = softClose
+	updated.Revision++
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+
+	*au = updated
+	return nil
+}
+
+// Cancel withdraws an active auction. Its bids are kept but none of them
+// wins.
+func (au *Auction) Cancel(at time.Time) *internal_error.InternalError {
+	if au.Status != Active {
+		return internal_error.NewBadRequestError("Only active auctions can be cancelled")
+	}
+
+	au.Status = Cancelled
+	au.EndTime = at
+	return nil
+}
+
+// CloseEarly ends an active auction at the given time; the current bids
+// decide the winner as if it had ended on schedule.
+func (au *Auction) CloseEarly(at time.Time) *internal_error.InternalError {
+	if au.Status != Active {
+		return internal_error.NewBadRequestError("Only active auctions can be closed")
+	}
+
+	au.Status = Completed
+	au.EndTime = at
+	return nil
+}
+
+// Relist creates a new active auction for the same product and rules,
+// ending at endTime. Only ended auctions that did not sell can be relisted.
+func (au *Auction) Relist(endTime time.Time, sold bool) (*Auction, *internal_error.InternalError) {
+	if au.Status == Active {
+		return nil, internal_error.NewBadRequestError("Active auctions cannot be relisted")
+	}
+	if sold {
+		return nil, internal_error.NewBadRequestError("Sold auctions cannot be relisted")
+	}
+
+	relisted, err := CreateAuction(
+		au.SellerId,
+		au.ProductName, au.Category, au.Description,
+		au.Condition, au.Type, endTime, au.BidRules, au.SoftClose)
+	if err != nil {
+		return nil, err
+	}
+
+	relisted.RelistedFrom = au.Id
+	return relisted, nil
+}
@@ -0,0 +1,107 @@
+package auction_entity
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func newSellerAuction(t *testing.T) *Auction {
+	t.Helper()
+
+	auction, err := CreateAuction(
+		uuid.New().String(), "Produto Teste", "Categoria Teste", "Descrição do produto de teste",
+		New, English, time.Now().Add(time.Hour), BidRules{StartingPrice: 100}, SoftClose{})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	return auction
+}
+
+func TestAuction_CheckSellerAndBidder(t *testing.T) {
+	auction := newSellerAuction(t)
+	other := uuid.New().String()
+
+	if err := auction.CheckSeller(auction.SellerId); err != nil {
+		t.Errorf("O vendedor deveria poder gerenciar o leilão: %v", err)
+	}
+	if err := auction.CheckSeller(other); err == nil || err.Err != "forbidden" {
+		t.Errorf("Esperado forbidden para outro usuário, mas obteve %v", err)
+	}
+	if err := auction.CheckBidder(auction.SellerId); err == nil {
+		t.Error("O vendedor não deveria poder dar lances no próprio leilão")
+	}
+	if err := auction.CheckBidder(other); err != nil {
+		t.Errorf("Outro usuário deveria poder dar lances: %v", err)
+	}
+}
+
+func TestAuction_Update(t *testing.T) {
+	auction := newSellerAuction(t)
+	now := time.Now()
+
+	err := auction.Update("Produto Novo", "Categoria Nova", "Nova descrição do produto",
+		Used, time.Time{}, BidRules{StartingPrice: 200}, SoftClose{}, now)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if auction.ProductName != "Produto Novo" || auction.BidRules.StartingPrice != 200 || auction.Revision != 1 {
+		t.Errorf("Leilão não atualizado como esperado: %+v", auction)
+	}
+
+	err = auction.Update("Produto Novo", "Categoria Nova", "Nova descrição do produto",
+		Used, time.Time{}, BidRules{MinIncrement: -1}, SoftClose{}, now)
+	if err == nil || auction.Revision != 1 || auction.BidRules.StartingPrice != 200 {
+		t.Errorf("Atualização inválida não deveria alterar o leilão, erro %v", err)
+	}
+
+	auction.BidCount = 1
+	err = auction.Update("Produto Novo", "Categoria Nova", "Nova descrição do produto",
+		Used, time.Time{}, BidRules{}, SoftClose{}, now)
+	if err == nil {
+		t.Error("Não deveria ser possível atualizar um leilão com lances")
+	}
+}
+
+func TestAuction_CancelAndCloseEarly(t *testing.T) {
+	now := time.Now()
+
+	cancelled := newSellerAuction(t)
+	if err := cancelled.Cancel(now); err != nil || cancelled.Status != Cancelled || !cancelled.EndTime.Equal(now) {
+		t.Errorf("Esperado leilão cancelado em %v, mas obteve %+v (erro %v)", now, cancelled, err)
+	}
+	if err := cancelled.CloseEarly(now); err == nil {
+		t.Error("Não deveria ser possível fechar um leilão cancelado")
+	}
+
+	closed := newSellerAuction(t)
+	if err := closed.CloseEarly(now); err != nil || closed.Status != Completed || !closed.EndTime.Equal(now) {
+		t.Errorf("Esperado leilão fechado em %v, mas obteve %+v (erro %v)", now, closed, err)
+	}
+	if err := closed.Cancel(now); err == nil {
+		t.Error("Não deveria ser possível cancelar um leilão encerrado")
+	}
+}
+
+func TestAuction_Relist(t *testing.T) {
+	auction := newSellerAuction(t)
+	endTime := time.Now().Add(2 * time.Hour)
+
+	if _, err := auction.Relist(endTime, false); err == nil {
+		t.Error("Não deveria ser possível relistar um leilão ativo")
+	}
+
+	auction.Status = Completed
+	if _, err := auction.Relist(endTime, true); err == nil {
+		t.Error("Não deveria ser possível relistar um leilão vendido")
+	}
+
+	relisted, err := auction.Relist(endTime, false)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if relisted.Id == auction.Id || relisted.RelistedFrom != auction.Id ||
+		relisted.SellerId != auction.SellerId || relisted.Status != Active || !relisted.EndTime.Equal(endTime) {
+		t.Errorf("Leilão relistado inesperado: %+v", relisted)
+	}
+}
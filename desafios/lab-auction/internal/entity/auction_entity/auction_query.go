@@ -0,0 +1,54 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/entity/page_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+type AuctionSort string
+
+const (
+	SortNewest        AuctionSort = "newest"
+	SortEndingSoonest AuctionSort = "ending_soonest"
+	SortHighestBid    AuctionSort = "highest_bid"
+	// SortRelevance orders by how well the auctions match Search.
+	SortRelevance AuctionSort = "relevance"
+)
+
+// AuctionQuery selects a page of auctions. Empty fields do not filter;
+// Search matches whole words of the product name and description.
+type AuctionQuery struct {
+	Status      *AuctionStatus
+	Category    string
+	ProductName string
+	Search      string
+	Sort        AuctionSort
+	Page        page_entity.Page
+}
+
+func (aq AuctionQuery) Validate() *internal_error.InternalError {
+	switch aq.Sort {
+	case "", SortNewest, SortEndingSoonest, SortHighestBid:
+	case SortRelevance:
+		if aq.Search == "" {
+			return internal_error.NewBadRequestError("Sorting by relevance requires a search")
+		}
+	default:
+		return internal_error.NewBadRequestError("Invalid auction sort")
+	}
+
+	return nil
+}
+
+// SortOrDefault returns the requested sort, or relevance when searching and
+// newest otherwise.
+func (aq AuctionQuery) SortOrDefault() AuctionSort {
+	if aq.Sort != "" {
+		return aq.Sort
+	}
+	if aq.Search != "" {
+		return SortRelevance
+	}
+
+	return SortNewest
+}
@@ -0,0 +1,30 @@
+package auction_entity
+
+import "testing"
+
+func TestAuctionQuery_Sort(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    AuctionQuery
+		expected AuctionSort
+		valid    bool
+	}{
+		{name: "padrão sem busca", query: AuctionQuery{}, expected: SortNewest, valid: true},
+		{name: "padrão com busca", query: AuctionQuery{Search: "câmera"}, expected: SortRelevance, valid: true},
+		{name: "explícita com busca", query: AuctionQuery{Search: "câmera", Sort: SortHighestBid}, expected: SortHighestBid, valid: true},
+		{name: "terminando antes", query: AuctionQuery{Sort: SortEndingSoonest}, expected: SortEndingSoonest, valid: true},
+		{name: "relevância sem busca", query: AuctionQuery{Sort: SortRelevance}},
+		{name: "desconhecida", query: AuctionQuery{Sort: "cheapest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Validate(); (err == nil) != tt.valid {
+				t.Fatalf("Esperado válido=%v, mas obteve erro %v", tt.valid, err)
+			}
+			if sort := tt.query.SortOrDefault(); tt.valid && sort != tt.expected {
+				t.Errorf("Esperado ordenação %q, mas obteve %q", tt.expected, sort)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package auction_entity
+
+import (
+	"fmt"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"math"
+	"time"
+)
+
+type AuctionType string
+
+const (
+	// English is the open ascending auction: bids are public and the
+	// highest bid wins, paying its own amount.
+	English AuctionType = "english"
+	// Dutch is the descending-price auction: the price falls over time and
+	// the first bid at the current price wins and ends the auction.
+	Dutch AuctionType = "dutch"
+	// SealedFirstPrice hides bids until the auction closes; the highest bid
+	// wins, paying its own amount.
+	SealedFirstPrice AuctionType = "sealed_first_price"
+	// Vickrey hides bids until the auction closes; the highest bid wins,
+	// paying the second highest amount.
+	Vickrey AuctionType = "vickrey"
+)
+
+// AuctionStrategy holds the rules that differ between auction types.
+type AuctionStrategy interface {
+	// Validate checks that the auction is configured for this type.
+	Validate(au *Auction) *internal_error.InternalError
+
+	// MinimumBid returns the lowest amount a bid placed at the given time
+	// may have.
+	MinimumBid(au *Auction, at time.Time) float64
+
+	// ValidateBid checks a bid placed at the given time on an auction that
+	// is accepting bids.
+	ValidateBid(au *Auction, amount float64, at time.Time) *internal_error.InternalError
+
+	// EndTimeAfterBid returns the end time and total extension of the
+	// auction once a bid placed at the given time is accepted.
+	EndTimeAfterBid(au *Auction, at time.Time) (time.Time, time.Duration)
+
+	// Sealed reports whether bids stay hidden until the auction closes.
+	// Sealed bids do not compete with each other when placed, so they do
+	// not become the auction's high bid.
+	Sealed() bool
+
+	// Winner returns the winning bid and the price it pays, or nil when no
+	// bid wins. ranked holds the best bid of each bidder, highest first and
+	// earliest first among equal amounts.
+	Winner(au *Auction, ranked []bid_entity.Bid) (*bid_entity.Bid, float64)
+}
+
+// Strategy returns the rules of the auction type. Auctions created before
+// types existed are English auctions.
+func (au *Auction) Strategy() AuctionStrategy {
+	switch au.Type {
+	case Dutch:
+		return dutchStrategy{}
+	case SealedFirstPrice:
+		return sealedFirstPriceStrategy{}
+	case Vickrey:
+		return vickreyStrategy{}
+	default:
+		return englishStrategy{}
+	}
+}
+
+// EndTimeAfterBid returns the end time and total extension of the auction
+// once a bid placed at the given time is accepted.
+func (au *Auction) EndTimeAfterBid(at time.Time) (time.Time, time.Duration) {
+	return au.Strategy().EndTimeAfterBid(au, at)
+}
+
+// BidsHidden reports whether the bids of the auction must not be shown. The
+// bids of a sealed auction are only revealed once it completes; a cancelled
+// sealed auction is never settled, so its bids are never revealed.
+func (au *Auction) BidsHidden() bool {
+	return au.Strategy().Sealed() && au.Status != Completed
+}
+
+// Winner returns the winning bid among ranked and the price it pays.
+func (au *Auction) Winner(ranked []bid_entity.Bid) (*bid_entity.Bid, float64) {
+	return au.Strategy().Winner(au, ranked)
+}
+
+// CurrentPrice returns the price of a Dutch auction at the given time.
+func (au *Auction) CurrentPrice(at time.Time) float64 {
+	rules := au.BidRules
+	if rules.PriceDropInterval <= 0 || !at.After(au.Timestamp) {
+		return rules.StartingPrice
+	}
+
+	drops := float64(at.Sub(au.Timestamp) / rules.PriceDropInterval)
+	price := math.Round((rules.StartingPrice-drops*rules.PriceDrop)*100) / 100
+
+	return math.Max(price, rules.ReservePrice)
+}
+
+func (t AuctionType) valid() bool {
+	switch t {
+	case English, Dutch, SealedFirstPrice, Vickrey:
+		return true
+	default:
+		return false
+	}
+}
+
+type englishStrategy struct{}
+
+func (englishStrategy) Validate(au *Auction) *internal_error.InternalError {
+	if au.BidRules.PriceDrop > 0 || au.BidRules.PriceDropInterval > 0 {
+		return internal_error.NewBadRequestError("Price drops are only available in dutch auctions")
+	}
+
+	return nil
+}
+
+// MinimumBid is the starting price for the first bid, then the current high
+// bid plus the increment.
+func (englishStrategy) MinimumBid(au *Auction, at time.Time) float64 {
+	if !au.HasBids() {
+		return au.BidRules.StartingPrice
+	}
+
+	return au.MinimumBidAbove(au.HighestBidAmount)
+}
+
+// ValidateBid requires a bid to beat the current high bid, even when no
+// increment is configured.
+func (s englishStrategy) ValidateBid(au *Auction, amount float64, at time.Time) *internal_error.InternalError {
+	minimum := s.MinimumBid(au, at)
+	if amount < minimum || (au.HasBids() && amount <= au.HighestBidAmount) {
+		return internal_error.NewBadRequestError(
+			fmt.Sprintf("Bid amount must be greater than the current high bid and at least %.2f", minimum))
+	}
+
+	return nil
+}
+
+func (englishStrategy) EndTimeAfterBid(au *Auction, at time.Time) (time.Time, time.Duration) {
+	return au.ExtendFor(at)
+}
+
+func (englishStrategy) Sealed() bool {
+	return false
+}
+
+func (englishStrategy) Winner(au *Auction, ranked []bid_entity.Bid) (*bid_entity.Bid, float64) {
+	if len(ranked) == 0 {
+		return nil, 0
+	}
+
+	return &ranked[0], ranked[0].Amount
+}
+
+type dutchStrategy struct{}
+
+func (dutchStrategy) Validate(au *Auction) *internal_error.InternalError {
+	rules := au.BidRules
+	if rules.StartingPrice <= 0 || rules.PriceDrop <= 0 || rules.PriceDropInterval <= 0 {
+		return internal_error.NewBadRequestError(
+			"Dutch auctions require a starting price, a price drop and a price drop interval")
+	}
+
+	return validateNoIncrementsOrSoftClose(au)
+}
+
+// MinimumBid is the current price.
+func (dutchStrategy) MinimumBid(au *Auction, at time.Time) float64 {
+	return au.CurrentPrice(at)
+}
+
+// ValidateBid accepts only the first bid, at or above the current price.
+func (dutchStrategy) ValidateBid(au *Auction, amount float64, at time.Time) *internal_error.InternalError {
+	if au.HasBids() {
+		return internal_error.NewBadRequestError("Auction already has a winning bid")
+	}
+
+	if price := au.CurrentPrice(at); amount < price {
+		return internal_error.NewBadRequestError(
+			fmt.Sprintf("Bid amount must be at least the current price of %.2f", price))
+	}
+
+	return nil
+}
+
+// EndTimeAfterBid ends the auction at the winning bid.
+func (dutchStrategy) EndTimeAfterBid(au *Auction, at time.Time) (time.Time, time.Duration) {
+	return at, au.ExtendedBy
+}
+
+func (dutchStrategy) Sealed() bool {
+	return false
+}
+
+func (dutchStrategy) Winner(au *Auction, ranked []bid_entity.Bid) (*bid_entity.Bid, float64) {
+	if len(ranked) == 0 {
+		return nil, 0
+	}
+
+	return &ranked[0], ranked[0].Amount
+}
+
+type sealedFirstPriceStrategy struct{}
+
+func (sealedFirstPriceStrategy) Validate(au *Auction) *internal_error.InternalError {
+	if au.BidRules.PriceDrop > 0 || au.BidRules.PriceDropInterval > 0 {
+		return internal_error.NewBadRequestError("Price drops are only available in dutch auctions")
+	}
+
+	return validateNoIncrementsOrSoftClose(au)
+}
+
+// MinimumBid is the starting price: sealed bids never see each other.
+func (sealedFirstPriceStrategy) MinimumBid(au *Auction, at time.Time) float64 {
+	return au.BidRules.StartingPrice
+}
+
+func (sealedFirstPriceStrategy) ValidateBid(au *Auction, amount float64, at time.Time) *internal_error.InternalError {
+	if amount < au.BidRules.StartingPrice {
+		return internal_error.NewBadRequestError(
+			fmt.Sprintf("Bid amount must be at least %.2f", au.BidRules.StartingPrice))
+	}
+
+	return nil
+}
+
+func (sealedFirstPriceStrategy) EndTimeAfterBid(au *Auction, at time.Time) (time.Time, time.Duration) {
+	return au.EndTime, au.ExtendedBy
+}
+
+func (sealedFirstPriceStrategy) Sealed() bool {
+	return true
+}
+
+func (sealedFirstPriceStrategy) Winner(au *Auction, ranked []bid_entity.Bid) (*bid_entity.Bid, float64) {
+	if len(ranked) == 0 {
+		return nil, 0
+	}
+
+	return &ranked[0], ranked[0].Amount
+}
+
+// vickreyStrategy takes bids like a sealed first-price auction and differs
+// only in the price the winner pays.
+type vickreyStrategy struct {
+	sealedFirstPriceStrategy
+}
+
+// Winner makes the highest bid pay the second highest bid, or the starting
+// price when it is the only bid, but never less than the reserve price.
+func (vickreyStrategy) Winner(au *Auction, ranked []bid_entity.Bid) (*bid_entity.Bid, float64) {
+	if len(ranked) == 0 {
+		return nil, 0
+	}
+
+	price := math.Max(au.BidRules.StartingPrice, au.BidRules.ReservePrice)
+	if len(ranked) > 1 {
+		price = math.Max(price, ranked[1].Amount)
+	}
+
+	return &ranked[0], math.Min(price, ranked[0].Amount)
+}
+
+func validateNoIncrementsOrSoftClose(au *Auction) *internal_error.InternalError {
+	if au.BidRules.MinIncrement > 0 || au.BidRules.MinIncrementPercent > 0 {
+		return internal_error.NewBadRequestError("Bid increments are only available in english auctions")
+	}
+
+	if au.SoftClose.Enabled() {
+		return internal_error.NewBadRequestError("Soft close is only available in english auctions")
+	}
+
+	return nil
+}
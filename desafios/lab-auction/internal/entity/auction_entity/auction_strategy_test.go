@@ -0,0 +1,147 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"testing"
+	"time"
+)
+
+func TestAuction_ValidateByType(t *testing.T) {
+	dutchRules := BidRules{StartingPrice: 1000, ReservePrice: 400, PriceDrop: 50, PriceDropInterval: time.Minute}
+	softClose := SoftClose{Window: 30 * time.Second, Extension: time.Minute, MaxExtension: time.Minute}
+
+	tests := []struct {
+		name    string
+		auction Auction
+		valid   bool
+	}{
+		{name: "inglês com incremento", auction: Auction{Type: English, BidRules: BidRules{MinIncrement: 10}}, valid: true},
+		{name: "inglês com queda de preço", auction: Auction{Type: English, BidRules: dutchRules}},
+		{name: "holandês completo", auction: Auction{Type: Dutch, BidRules: dutchRules}, valid: true},
+		{name: "holandês sem queda de preço", auction: Auction{Type: Dutch, BidRules: BidRules{StartingPrice: 1000}}},
+		{name: "holandês com soft close", auction: Auction{Type: Dutch, BidRules: dutchRules, SoftClose: softClose}},
+		{name: "selado simples", auction: Auction{Type: SealedFirstPrice, BidRules: BidRules{StartingPrice: 100}}, valid: true},
+		{name: "selado com incremento", auction: Auction{Type: SealedFirstPrice, BidRules: BidRules{MinIncrement: 10}}},
+		{name: "vickrey com soft close", auction: Auction{Type: Vickrey, SoftClose: softClose}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.auction.Strategy().Validate(&tt.auction); (err == nil) != tt.valid {
+				t.Errorf("Esperado válido=%v, mas obteve erro %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestAuction_CurrentPrice(t *testing.T) {
+	auction := newActiveAuction(BidRules{
+		StartingPrice: 1000, ReservePrice: 400, PriceDrop: 50, PriceDropInterval: time.Minute})
+	auction.Type = Dutch
+
+	tests := []struct {
+		elapsed time.Duration
+		price   float64
+	}{
+		{elapsed: 0, price: 1000},
+		{elapsed: 59 * time.Second, price: 1000},
+		{elapsed: 3 * time.Minute, price: 850},
+		{elapsed: time.Hour, price: 400},
+	}
+
+	for _, tt := range tests {
+		if price := auction.CurrentPrice(auction.Timestamp.Add(tt.elapsed)); price != tt.price {
+			t.Errorf("Esperado preço %.2f após %v, mas obteve %.2f", tt.price, tt.elapsed, price)
+		}
+	}
+}
+
+func TestDutch_FirstBidAtCurrentPriceWins(t *testing.T) {
+	auction := newActiveAuction(BidRules{
+		StartingPrice: 1000, PriceDrop: 50, PriceDropInterval: time.Minute})
+	auction.Type = Dutch
+	at := auction.Timestamp.Add(2 * time.Minute)
+
+	if err := auction.ValidateBid(899.99, at); err == nil {
+		t.Error("Esperado erro para lance abaixo do preço atual")
+	}
+	if err := auction.ValidateBid(900, at); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if endTime, _ := auction.EndTimeAfterBid(at); !endTime.Equal(at) {
+		t.Errorf("Esperado término no lance vencedor %v, mas obteve %v", at, endTime)
+	}
+
+	auction.HighestBidId = "winner"
+	auction.HighestBidAmount = 900
+	if err := auction.ValidateBid(1000, at); err == nil {
+		t.Error("Esperado erro para lance após o lance vencedor")
+	}
+}
+
+func TestSealed_BidsIgnoreEachOther(t *testing.T) {
+	auction := newActiveAuction(BidRules{StartingPrice: 100})
+	auction.Type = SealedFirstPrice
+	auction.HighestBidId = "legacy"
+	auction.HighestBidAmount = 500
+
+	if err := auction.ValidateBid(99, time.Now()); err == nil {
+		t.Error("Esperado erro para lance abaixo do preço inicial")
+	}
+	if err := auction.ValidateBid(100, time.Now()); err != nil {
+		t.Errorf("Erro inesperado: %v", err)
+	}
+	if !auction.BidsHidden() {
+		t.Error("Lances de leilão selado ativo deveriam ficar ocultos")
+	}
+
+	auction.Status = Cancelled
+	if !auction.BidsHidden() {
+		t.Error("Lances de leilão selado cancelado deveriam continuar ocultos")
+	}
+
+	auction.Status = Completed
+	if auction.BidsHidden() {
+		t.Error("Lances de leilão selado encerrado deveriam ficar visíveis")
+	}
+}
+
+func TestAuction_Winner(t *testing.T) {
+	ranked := []bid_entity.Bid{
+		{Id: "primeiro", Amount: 300},
+		{Id: "segundo", Amount: 250},
+	}
+
+	tests := []struct {
+		name        string
+		auctionType AuctionType
+		rules       BidRules
+		ranked      []bid_entity.Bid
+		winner      string
+		price       float64
+	}{
+		{name: "inglês paga o próprio lance", auctionType: English, ranked: ranked, winner: "primeiro", price: 300},
+		{name: "selado paga o próprio lance", auctionType: SealedFirstPrice, ranked: ranked, winner: "primeiro", price: 300},
+		{name: "vickrey paga o segundo lance", auctionType: Vickrey, ranked: ranked, winner: "primeiro", price: 250},
+		{name: "vickrey com lance único paga o preço inicial", auctionType: Vickrey, rules: BidRules{StartingPrice: 100}, ranked: ranked[:1], winner: "primeiro", price: 100},
+		{name: "vickrey não paga menos que a reserva", auctionType: Vickrey, rules: BidRules{ReservePrice: 280}, ranked: ranked, winner: "primeiro", price: 280},
+		{name: "sem lances", auctionType: Vickrey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := &Auction{Type: tt.auctionType, BidRules: tt.rules}
+
+			winner, price := auction.Winner(tt.ranked)
+			if tt.winner == "" {
+				if winner != nil {
+					t.Errorf("Esperado nenhum vencedor, mas obteve %+v", winner)
+				}
+				return
+			}
+			if winner == nil || winner.Id != tt.winner || price != tt.price {
+				t.Errorf("Esperado %s pagando %.2f, mas obteve %+v pagando %.2f", tt.winner, tt.price, winner, price)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/internal_error"
+	"math"
+	"time"
+)
+
+// BidRules are the pricing rules a bid must satisfy. MinIncrement and
+// MinIncrementPercent are alternatives: at most one of them may be set.
+type BidRules struct {
+	StartingPrice       float64
+	ReservePrice        float64
+	MinIncrement        float64
+	MinIncrementPercent float64
+
+	// PriceDrop and PriceDropInterval describe how the price of a Dutch
+	// auction falls: by PriceDrop every PriceDropInterval, from
+	// StartingPrice down to ReservePrice.
+	PriceDrop         float64
+	PriceDropInterval time.Duration
+}
+
+func (br BidRules) Validate() *internal_error.InternalError {
+	if br.StartingPrice < 0 || br.ReservePrice < 0 ||
+		br.MinIncrement < 0 || br.MinIncrementPercent < 0 ||
+		br.PriceDrop < 0 || br.PriceDropInterval < 0 {
+		return internal_error.NewBadRequestError("Bid rules must not be negative")
+	}
+
+	if br.MinIncrement > 0 && br.MinIncrementPercent > 0 {
+		return internal_error.NewBadRequestError(
+			"MinIncrement and MinIncrementPercent cannot be used together")
+	}
+
+	if br.ReservePrice > 0 && br.ReservePrice < br.StartingPrice {
+		return internal_error.NewBadRequestError("ReservePrice must not be lower than StartingPrice")
+	}
+
+	return nil
+}
+
+// HasBids reports whether the auction already has an accepted bid.
+func (au *Auction) HasBids() bool {
+	return au.HighestBidId != ""
+}
+
+// MinimumBid returns the lowest amount a bid placed at the given time may
+// have under the rules of the auction type.
+func (au *Auction) MinimumBid(at time.Time) float64 {
+	return au.Strategy().MinimumBid(au, at)
+}
+
+// MinimumBidAbove returns the lowest amount that beats amount by the
+// minimum increment, rounded to cents.
+func (au *Auction) MinimumBidAbove(amount float64) float64 {
+	increment := au.BidRules.MinIncrement
+	if au.BidRules.MinIncrementPercent > 0 {
+		increment = amount * au.BidRules.MinIncrementPercent / 100
+	}
+
+	return math.Round((amount+increment)*100) / 100
+}
+
+// AcceptsBids reports whether a bid placed at the given time can still be
+// accepted.
+func (au *Auction) AcceptsBids(at time.Time) bool {
+	return au.Status == Active && at.Before(au.EndTime)
+}
+
+// ValidateBid checks a bid placed at the given time against the auction
+// state and the bid rules of its type.
+func (au *Auction) ValidateBid(amount float64, at time.Time) *internal_error.InternalError {
+	if !au.AcceptsBids(at) {
+		return internal_error.NewBadRequestError("Auction is not accepting bids")
+	}
+
+	return au.Strategy().ValidateBid(au, amount, at)
+}
+
+// ReserveMet reports whether amount reaches the reserve price.
+func (au *Auction) ReserveMet(amount float64) bool {
+	return amount >= au.BidRules.ReservePrice
+}
+
+// SoftClose is the optional anti-sniping rule: a bid placed within Window of
+// the end extends the auction by Extension, up to MaxExtension in total.
+type SoftClose struct {
+	Window       time.Duration
+	Extension    time.Duration
+	MaxExtension time.Duration
+}
+
+func (sc SoftClose) Enabled() bool {
+	return sc.Window > 0
+}
+
+func (sc SoftClose) Validate() *internal_error.InternalError {
+	if sc.Window < 0 || sc.Extension < 0 || sc.MaxExtension < 0 {
+		return internal_error.NewBadRequestError("Soft close durations must not be negative")
+	}
+
+	if !sc.Enabled() {
+		if sc.Extension > 0 || sc.MaxExtension > 0 {
+			return internal_error.NewBadRequestError("Soft close requires a window")
+		}
+		return nil
+	}
+
+	if sc.Extension <= 0 || sc.MaxExtension < sc.Extension {
+		return internal_error.NewBadRequestError(
+			"Soft close requires an extension no greater than the max extension")
+	}
+
+	return nil
+}
+
+// ExtendFor returns the end time and total extension of the auction after
+// a bid placed at the given time, applying the soft-close rule.
+func (au *Auction) ExtendFor(at time.Time) (time.Time, time.Duration) {
+	if !au.SoftClose.Enabled() || au.EndTime.Sub(at) > au.SoftClose.Window {
+		return au.EndTime, au.ExtendedBy
+	}
+
+	extension := au.SoftClose.Extension
+	if left := au.SoftClose.MaxExtension - au.ExtendedBy; left < extension {
+		extension = left
+	}
+	if extension <= 0 {
+		return au.EndTime, au.ExtendedBy
+	}
+
+	return au.EndTime.Add(extension), au.ExtendedBy + extension
+}
@@ -0,0 +1,150 @@
+package auction_entity
+
+import (
+	"testing"
+	"time"
+)
+
+func newActiveAuction(rules BidRules) *Auction {
+	now := time.Now()
+	return &Auction{
+		Status:    Active,
+		Timestamp: now,
+		EndTime:   now.Add(time.Hour),
+		BidRules:  rules,
+	}
+}
+
+func TestBidRules_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules BidRules
+		valid bool
+	}{
+		{name: "sem regras", rules: BidRules{}, valid: true},
+		{name: "incremento absoluto", rules: BidRules{StartingPrice: 100, ReservePrice: 500, MinIncrement: 10}, valid: true},
+		{name: "incremento percentual", rules: BidRules{MinIncrementPercent: 5}, valid: true},
+		{name: "incrementos juntos", rules: BidRules{MinIncrement: 10, MinIncrementPercent: 5}},
+		{name: "reserva abaixo do inicial", rules: BidRules{StartingPrice: 100, ReservePrice: 50}},
+		{name: "valor negativo", rules: BidRules{StartingPrice: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Esperado válido=%v, mas obteve erro %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestAuction_ValidateBid(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   BidRules
+		highest float64
+		amount  float64
+		valid   bool
+	}{
+		{name: "primeiro lance no preço inicial", rules: BidRules{StartingPrice: 100}, amount: 100, valid: true},
+		{name: "primeiro lance abaixo do preço inicial", rules: BidRules{StartingPrice: 100}, amount: 99.99},
+		{name: "lance igual ao maior lance", highest: 100, amount: 100},
+		{name: "lance acima sem incremento", highest: 100, amount: 100.01, valid: true},
+		{name: "incremento absoluto atingido", rules: BidRules{MinIncrement: 10}, highest: 100, amount: 110, valid: true},
+		{name: "incremento absoluto não atingido", rules: BidRules{MinIncrement: 10}, highest: 100, amount: 109.99},
+		{name: "incremento percentual atingido", rules: BidRules{MinIncrementPercent: 10}, highest: 100, amount: 110, valid: true},
+		{name: "incremento percentual não atingido", rules: BidRules{MinIncrementPercent: 10}, highest: 100, amount: 109.99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := newActiveAuction(tt.rules)
+			if tt.highest > 0 {
+				auction.HighestBidId = "current"
+				auction.HighestBidAmount = tt.highest
+			}
+
+			if err := auction.ValidateBid(tt.amount, time.Now()); (err == nil) != tt.valid {
+				t.Errorf("Esperado válido=%v para o lance %.2f (mínimo %.2f), mas obteve erro %v",
+					tt.valid, tt.amount, auction.MinimumBid(time.Now()), err)
+			}
+		})
+	}
+}
+
+func TestAuction_ValidateBidRejectsClosedAuction(t *testing.T) {
+	auction := newActiveAuction(BidRules{})
+
+	if err := auction.ValidateBid(10, auction.EndTime); err == nil {
+		t.Error("Esperado erro para lance no horário de término")
+	}
+
+	auction.Status = Completed
+	if err := auction.ValidateBid(10, time.Now()); err == nil {
+		t.Error("Esperado erro para lance em leilão encerrado")
+	}
+}
+
+func TestAuction_ReserveMet(t *testing.T) {
+	auction := newActiveAuction(BidRules{ReservePrice: 500})
+
+	if auction.ReserveMet(499.99) {
+		t.Error("Reserva não deveria ser atingida abaixo do preço de reserva")
+	}
+	if !auction.ReserveMet(500) {
+		t.Error("Reserva deveria ser atingida no preço de reserva")
+	}
+}
+
+func TestSoftClose_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		softClose SoftClose
+		valid     bool
+	}{
+		{name: "desativado", softClose: SoftClose{}, valid: true},
+		{name: "ativo", softClose: SoftClose{Window: 30 * time.Second, Extension: time.Minute, MaxExtension: 5 * time.Minute}, valid: true},
+		{name: "sem janela", softClose: SoftClose{Extension: time.Minute, MaxExtension: time.Minute}},
+		{name: "sem extensão", softClose: SoftClose{Window: 30 * time.Second, MaxExtension: time.Minute}},
+		{name: "máximo menor que a extensão", softClose: SoftClose{Window: 30 * time.Second, Extension: time.Minute, MaxExtension: 30 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.softClose.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Esperado válido=%v, mas obteve erro %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestAuction_ExtendFor(t *testing.T) {
+	end := time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)
+	softClose := SoftClose{Window: 30 * time.Second, Extension: time.Minute, MaxExtension: 90 * time.Second}
+
+	tests := []struct {
+		name       string
+		softClose  SoftClose
+		extendedBy time.Duration
+		bidAt      time.Time
+		endTime    time.Time
+		total      time.Duration
+	}{
+		{name: "sem regra", bidAt: end.Add(-time.Second), endTime: end},
+		{name: "fora da janela", softClose: softClose, bidAt: end.Add(-31 * time.Second), endTime: end},
+		{name: "dentro da janela", softClose: softClose, bidAt: end.Add(-10 * time.Second), endTime: end.Add(time.Minute), total: time.Minute},
+		{name: "limitado pelo máximo", softClose: softClose, extendedBy: time.Minute, bidAt: end.Add(-time.Second), endTime: end.Add(30 * time.Second), total: 90 * time.Second},
+		{name: "máximo atingido", softClose: softClose, extendedBy: 90 * time.Second, bidAt: end.Add(-time.Second), endTime: end, total: 90 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := &Auction{Status: Active, EndTime: end, SoftClose: tt.softClose, ExtendedBy: tt.extendedBy}
+
+			endTime, total := auction.ExtendFor(tt.bidAt)
+			if !endTime.Equal(tt.endTime) || total != tt.total {
+				t.Errorf("Esperado término %v e extensão %v, mas obteve %v e %v", tt.endTime, tt.total, endTime, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"math"
+	"time"
+)
+
+// NextProxyBid returns the automatic bid to place next given the proxy bids
+// of the auction, or ok false when no proxy bid can act. Called repeatedly,
+// each returned bid being placed before the next call, it settles the
+// auction the eBay way: the highest maximum wins, paying one increment over
+// the second highest, capped at its own maximum. Equal maximums favour the
+// proxy bid registered first.
+func (au *Auction) NextProxyBid(
+	proxies []bid_entity.ProxyBid, at time.Time) (userId string, amount float64, ok bool) {
+	minimum := au.MinimumBid(at)
+
+	leaderMax := au.HighestBidAmount
+	var leaderProxy, challenger *bid_entity.ProxyBid
+
+	for i := range proxies {
+		proxy := &proxies[i]
+
+		if au.HasBids() && proxy.UserId == au.HighestBidUserId {
+			if proxy.MaxAmount > leaderMax {
+				leaderMax = proxy.MaxAmount
+				leaderProxy = proxy
+			}
+			continue
+		}
+
+		if proxy.MaxAmount < minimum || (au.HasBids() && proxy.MaxAmount <= au.HighestBidAmount) {
+			continue
+		}
+
+		if challenger == nil || proxy.MaxAmount > challenger.MaxAmount ||
+			(proxy.MaxAmount == challenger.MaxAmount && proxy.Timestamp.Before(challenger.Timestamp)) {
+			challenger = proxy
+		}
+	}
+
+	if challenger == nil {
+		return "", 0, false
+	}
+
+	if !au.HasBids() {
+		return challenger.UserId, minimum, true
+	}
+
+	challengerWins := challenger.MaxAmount > leaderMax ||
+		(challenger.MaxAmount == leaderMax && leaderProxy != nil && challenger.Timestamp.Before(leaderProxy.Timestamp))
+	if challengerWins {
+		return challenger.UserId,
+			math.Min(challenger.MaxAmount, math.Max(minimum, au.MinimumBidAbove(leaderMax))), true
+	}
+
+	if leaderProxy != nil {
+		return au.HighestBidUserId,
+			math.Min(leaderMax, au.MinimumBidAbove(challenger.MaxAmount)), true
+	}
+
+	return "", 0, false
+}
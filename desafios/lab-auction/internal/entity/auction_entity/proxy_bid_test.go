@@ -0,0 +1,110 @@
+package auction_entity
+
+import (
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"testing"
+	"time"
+)
+
+// resolve aplica os lances automáticos até que nenhum lance máximo possa
+// cobrir o líder, como faz o repositório de lances.
+func resolve(t *testing.T, auction *Auction, proxies []bid_entity.ProxyBid) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		userId, amount, ok := auction.NextProxyBid(proxies, time.Now())
+		if !ok {
+			return
+		}
+		if err := auction.ValidateBid(amount, time.Now()); err != nil {
+			t.Fatalf("Lance automático inválido de %.2f: %v", amount, err)
+		}
+		auction.HighestBidId = userId
+		auction.HighestBidUserId = userId
+		auction.HighestBidAmount = amount
+	}
+	t.Fatal("A resolução dos lances máximos não terminou")
+}
+
+func TestNextProxyBid(t *testing.T) {
+	now := time.Now()
+	proxy := func(userId string, maxAmount float64, order int) bid_entity.ProxyBid {
+		return bid_entity.ProxyBid{
+			UserId:    userId,
+			MaxAmount: maxAmount,
+			Timestamp: now.Add(time.Duration(order) * time.Second),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		leader     string
+		highestBid float64
+		proxies    []bid_entity.ProxyBid
+		winner     string
+		amount     float64
+	}{
+		{
+			name:    "primeiro lance máximo abre pelo preço inicial",
+			proxies: []bid_entity.ProxyBid{proxy("ana", 500, 0)},
+			winner:  "ana", amount: 100,
+		},
+		{
+			name:    "maior máximo vence pagando um incremento acima do segundo",
+			proxies: []bid_entity.ProxyBid{proxy("ana", 300, 0), proxy("bia", 500, 1)},
+			winner:  "bia", amount: 310,
+		},
+		{
+			name:    "empate favorece o lance máximo mais antigo",
+			proxies: []bid_entity.ProxyBid{proxy("bia", 300, 1), proxy("ana", 300, 0)},
+			winner:  "ana", amount: 300,
+		},
+		{
+			name:   "lance máximo defende contra lance manual",
+			leader: "carlos", highestBid: 150,
+			proxies: []bid_entity.ProxyBid{proxy("ana", 400, 0)},
+			winner:  "ana", amount: 160,
+		},
+		{
+			name:   "lance manual acima do máximo mantém a liderança",
+			leader: "carlos", highestBid: 450,
+			proxies: []bid_entity.ProxyBid{proxy("ana", 400, 0)},
+			winner:  "carlos", amount: 450,
+		},
+		{
+			name:   "líder com máximo cobre o desafiante até o próprio limite",
+			leader: "ana", highestBid: 120,
+			proxies: []bid_entity.ProxyBid{proxy("ana", 205, 0), proxy("bia", 200, 1)},
+			winner:  "ana", amount: 205,
+		},
+		{
+			name:   "empate com o líder favorece o desafiante registrado antes",
+			leader: "bia", highestBid: 120,
+			proxies: []bid_entity.ProxyBid{proxy("bia", 300, 1), proxy("ana", 300, 0)},
+			winner:  "ana", amount: 300,
+		},
+		{
+			name:   "empate com o líder registrado antes mantém a liderança",
+			leader: "ana", highestBid: 120,
+			proxies: []bid_entity.ProxyBid{proxy("ana", 300, 0), proxy("bia", 300, 1)},
+			winner:  "ana", amount: 300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := newActiveAuction(BidRules{StartingPrice: 100, MinIncrement: 10})
+			if tt.leader != "" {
+				auction.HighestBidId = tt.leader
+				auction.HighestBidUserId = tt.leader
+				auction.HighestBidAmount = tt.highestBid
+			}
+
+			resolve(t, auction, tt.proxies)
+
+			if auction.HighestBidUserId != tt.winner || auction.HighestBidAmount != tt.amount {
+				t.Errorf("Esperado %s liderando com %.2f, mas obteve %s com %.2f",
+					tt.winner, tt.amount, auction.HighestBidUserId, auction.HighestBidAmount)
+			}
+		})
+	}
+}
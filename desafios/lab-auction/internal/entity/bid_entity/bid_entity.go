@@ -2,6 +2,7 @@ package bid_entity
 
 import (
 	"context"
+	"fullcycle-auction_go/internal/entity/page_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"github.com/google/uuid"
 	"time"
@@ -13,6 +14,9 @@ type Bid struct {
 	AuctionId string
 	Amount    float64
 	Timestamp time.Time
+
+	// Automatic marks a bid placed on behalf of a proxy bid.
+	Automatic bool
 }
 
 func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.InternalError) {
@@ -43,14 +47,60 @@ func (b *Bid) Validate() *internal_error.InternalError {
 	return nil
 }
 
+type BidStatus string
+
+const (
+	BidAccepted      BidStatus = "accepted"
+	BidOutbid        BidStatus = "outbid"
+	BidAuctionClosed BidStatus = "auction_closed"
+	BidInvalid       BidStatus = "invalid"
+)
+
+// BidResult is the outcome of placing a bid. EndTime is the auction end
+// time after an accepted bid, which a late bid may have extended. Err is set
+// instead of Status when the bid could not be processed at all.
+type BidResult struct {
+	Status  BidStatus
+	Reason  string
+	EndTime time.Time
+	Err     *internal_error.InternalError
+
+	// AutoBids are the proxy bids placed in response to this bid.
+	AutoBids []PlacedBid
+
+	// Sealed marks a bid that stays hidden until its auction closes.
+	Sealed bool
+}
+
+// PlacedBid is an accepted bid and the auction end time after it.
+type PlacedBid struct {
+	Bid     Bid
+	EndTime time.Time
+}
+
 type BidEntityRepository interface {
+	// CreateBid places the bids and returns their results in the same order.
 	CreateBid(
 		ctx context.Context,
-		bidEntities []Bid) *internal_error.InternalError
+		bidEntities []Bid) ([]BidResult, *internal_error.InternalError)
 
+	// FindBidByAuctionId returns the requested page of the bids of an
+	// auction, newest first, and the total number of bids.
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]Bid, *internal_error.InternalError)
+		ctx context.Context,
+		auctionId string,
+		page page_entity.Page) ([]Bid, int64, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*Bid, *internal_error.InternalError)
+
+	// FindRankedBidsByAuctionId returns the best bid of each bidder, highest
+	// first and earliest first among equal amounts, up to limit bids.
+	FindRankedBidsByAuctionId(
+		ctx context.Context, auctionId string, limit int) ([]Bid, *internal_error.InternalError)
+
+	// CreateProxyBid stores the proxy bid and places the automatic bids it
+	// triggers.
+	CreateProxyBid(
+		ctx context.Context, proxyBid *ProxyBid) ProxyBidResult
 }
@@ -0,0 +1,67 @@
+package bid_entity
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/internal_error"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ProxyBid is the maximum a user is willing to pay in an auction. The
+// system bids on the user's behalf, in minimum increments, up to MaxAmount.
+// MaxAmount is never shown to other users.
+type ProxyBid struct {
+	Id        string
+	UserId    string
+	AuctionId string
+	MaxAmount float64
+	Timestamp time.Time
+}
+
+func CreateProxyBid(userId, auctionId string, maxAmount float64) (*ProxyBid, *internal_error.InternalError) {
+	proxyBid := &ProxyBid{
+		Id:        uuid.New().String(),
+		UserId:    userId,
+		AuctionId: auctionId,
+		MaxAmount: maxAmount,
+		Timestamp: time.Now(),
+	}
+
+	if err := proxyBid.Validate(); err != nil {
+		return nil, err
+	}
+
+	return proxyBid, nil
+}
+
+func (pb *ProxyBid) Validate() *internal_error.InternalError {
+	if err := uuid.Validate(pb.UserId); err != nil {
+		return internal_error.NewBadRequestError("UserId is not a valid id")
+	} else if err := uuid.Validate(pb.AuctionId); err != nil {
+		return internal_error.NewBadRequestError("AuctionId is not a valid id")
+	} else if pb.MaxAmount <= 0 {
+		return internal_error.NewBadRequestError("MaxAmount is not a valid value")
+	}
+
+	return nil
+}
+
+// ProxyBidResult is the outcome of registering a proxy bid. HighestBid
+// describes the auction after the automatic bids were placed.
+type ProxyBidResult struct {
+	Status     BidStatus
+	Reason     string
+	HighestBid float64
+	AutoBids   []PlacedBid
+	Err        *internal_error.InternalError
+}
+
+type ProxyBidRepositoryInterface interface {
+	// UpsertProxyBid stores the proxy bid, replacing the maximum of an
+	// earlier proxy bid of the same user in the same auction.
+	UpsertProxyBid(
+		ctx context.Context, proxyBid *ProxyBid) *internal_error.InternalError
+
+	FindProxyBidsByAuctionId(
+		ctx context.Context, auctionId string) ([]ProxyBid, *internal_error.InternalError)
+}
@@ -0,0 +1,37 @@
+package event_entity
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	HighBid       EventType = "high_bid"
+	AuctionClosed EventType = "auction_closed"
+	// AuctionCancelled ends an auction withdrawn by its seller.
+	AuctionCancelled EventType = "auction_cancelled"
+)
+
+// AuctionEvent is a change in an auction pushed to its subscribers. EndTime
+// is set on high bids, since a late bid may extend the auction.
+type AuctionEvent struct {
+	Type      EventType
+	AuctionId string
+	BidId     string
+	UserId    string
+	Amount    float64
+	EndTime   time.Time
+	Timestamp time.Time
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event AuctionEvent)
+}
+
+type EventSubscriber interface {
+	// Subscribe returns the events of one auction and a function that ends
+	// the subscription. The channel is closed when the subscription ends.
+	Subscribe(auctionId string) (<-chan AuctionEvent, func())
+}
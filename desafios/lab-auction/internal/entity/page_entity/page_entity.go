@@ -0,0 +1,57 @@
+package page_entity
+
+import (
+	"fmt"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+const (
+	DefaultSize int64 = 20
+	MaxSize     int64 = 100
+
+	// MaxNumber bounds the page number, so Skip cannot overflow and the
+	// database is never asked to skip past millions of documents.
+	MaxNumber int64 = 10000
+)
+
+// Page selects a slice of a sorted listing: page Number, counting from 1,
+// with Size items per page.
+type Page struct {
+	Number int64
+	Size   int64
+}
+
+// NewPage validates the requested page, using the first page and
+// DefaultSize for zero values.
+func NewPage(number, size int64) (Page, *internal_error.InternalError) {
+	if number < 0 || size < 0 {
+		return Page{}, internal_error.NewBadRequestError("Page and page size must not be negative")
+	}
+	if size > MaxSize {
+		return Page{}, internal_error.NewBadRequestError(
+			fmt.Sprintf("Page size must not exceed %d", MaxSize))
+	}
+	if number > MaxNumber {
+		return Page{}, internal_error.NewBadRequestError(
+			fmt.Sprintf("Page must not exceed %d", MaxNumber))
+	}
+
+	if number == 0 {
+		number = 1
+	}
+	if size == 0 {
+		size = DefaultSize
+	}
+
+	return Page{Number: number, Size: size}, nil
+}
+
+// Skip is the number of items before the page.
+func (p Page) Skip() int64 {
+	return (p.Number - 1) * p.Size
+}
+
+// TotalPages is the number of pages needed to list total items.
+func (p Page) TotalPages(total int64) int64 {
+	return (total + p.Size - 1) / p.Size
+}
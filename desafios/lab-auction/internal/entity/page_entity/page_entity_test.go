@@ -0,0 +1,51 @@
+package page_entity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		number, size int64
+		expected     Page
+		valid        bool
+	}{
+		{name: "padrão", expected: Page{Number: 1, Size: DefaultSize}, valid: true},
+		{name: "explícita", number: 3, size: 10, expected: Page{Number: 3, Size: 10}, valid: true},
+		{name: "tamanho máximo", number: 1, size: MaxSize, expected: Page{Number: 1, Size: MaxSize}, valid: true},
+		{name: "tamanho acima do máximo", number: 1, size: MaxSize + 1},
+		{name: "página máxima", number: MaxNumber, size: MaxSize, expected: Page{Number: MaxNumber, Size: MaxSize}, valid: true},
+		{name: "página acima do máximo", number: MaxNumber + 1, size: 10},
+		{name: "página que estouraria o deslocamento", number: math.MaxInt64, size: MaxSize},
+		{name: "página negativa", number: -1, size: 10},
+		{name: "tamanho negativo", number: 1, size: -10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := NewPage(tt.number, tt.size)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Esperado válido=%v, mas obteve erro %v", tt.valid, err)
+			}
+			if tt.valid && page != tt.expected {
+				t.Errorf("Esperado %+v, mas obteve %+v", tt.expected, page)
+			}
+		})
+	}
+}
+
+func TestPage_SkipAndTotalPages(t *testing.T) {
+	page := Page{Number: 3, Size: 20}
+
+	if skip := page.Skip(); skip != 40 {
+		t.Errorf("Esperado pular 40 itens, mas obteve %d", skip)
+	}
+
+	for total, expected := range map[int64]int64{0: 0, 1: 1, 20: 1, 21: 2, 60: 3} {
+		if pages := page.TotalPages(total); pages != expected {
+			t.Errorf("Esperado %d páginas para %d itens, mas obteve %d", expected, total, pages)
+		}
+	}
+}
@@ -2,17 +2,142 @@ package user_entity
 
 import (
 	"context"
+	"errors"
 	"fullcycle-auction_go/internal/internal_error"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const minPasswordLength = 8
+
 type User struct {
-	Id   string
-	Name string
+	Id           string
+	Name         string
+	Email        string
+	PasswordHash string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	// DeletedAt is set when the user is deleted. Deleted users are kept so
+	// their bids and auctions stay consistent, but can no longer sign in.
+	DeletedAt time.Time
+}
+
+// CreateUser creates a user with a hashed password.
+func CreateUser(name, email, password string) (*User, *internal_error.InternalError) {
+	now := time.Now()
+	user := &User{
+		Id:        uuid.New().String(),
+		Name:      strings.TrimSpace(name),
+		Email:     normalizeEmail(email),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := user.SetPassword(password); err != nil {
+		return nil, err
+	}
+
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
+func (u *User) Validate() *internal_error.InternalError {
+	if len(u.Name) < 2 {
+		return internal_error.NewBadRequestError("Name must have at least 2 characters")
+	}
+
+	if address, err := mail.ParseAddress(u.Email); err != nil || address.Address != u.Email {
+		return internal_error.NewBadRequestError("Email is not a valid address")
+	}
+
+	if u.PasswordHash == "" {
+		return internal_error.NewBadRequestError("Password is required")
+	}
+
+	return nil
+}
+
+// Update changes the profile fields that are not empty.
+func (u *User) Update(name, email, password string) *internal_error.InternalError {
+	if name != "" {
+		u.Name = strings.TrimSpace(name)
+	}
+	if email != "" {
+		u.Email = normalizeEmail(email)
+	}
+	if password != "" {
+		if err := u.SetPassword(password); err != nil {
+			return err
+		}
+	}
+	u.UpdatedAt = time.Now()
+
+	return u.Validate()
+}
+
+// SetPassword replaces the password hash. The password itself is never
+// stored.
+func (u *User) SetPassword(password string) *internal_error.InternalError {
+	if len(password) < minPasswordLength {
+		return internal_error.NewBadRequestError("Password must have at least 8 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return internal_error.NewBadRequestError("Password must have at most 72 bytes")
+		}
+		return internal_error.NewInternalServerError("Error trying to hash password")
+	}
+
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether password matches the stored hash.
+func (u *User) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+func (u *User) IsDeleted() bool {
+	return !u.DeletedAt.IsZero()
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// UserRepositoryInterface never returns deleted users.
 type UserRepositoryInterface interface {
+	CreateUser(
+		ctx context.Context, userEntity *User) *internal_error.InternalError
+	UpdateUser(
+		ctx context.Context, userEntity *User) *internal_error.InternalError
+	DeleteUser(
+		ctx context.Context, userId string, at time.Time) *internal_error.InternalError
 	FindUserById(
 		ctx context.Context, userId string) (*User, *internal_error.InternalError)
+	FindUserByEmail(
+		ctx context.Context, email string) (*User, *internal_error.InternalError)
 	FindAllUsers(
 		ctx context.Context) ([]User, *internal_error.InternalError)
 }
+
+// TokenService issues and verifies the access tokens that identify an
+// authenticated user.
+type TokenService interface {
+	IssueToken(userId string) (token string, expiresAt time.Time, err *internal_error.InternalError)
+	VerifyToken(token string) (userId string, err *internal_error.InternalError)
+}
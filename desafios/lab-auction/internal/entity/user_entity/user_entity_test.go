@@ -0,0 +1,65 @@
+package user_entity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateUser_HashesPassword(t *testing.T) {
+	user, err := CreateUser(" Maria ", "Maria@Example.com", "senha-segura")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if user.Name != "Maria" || user.Email != "maria@example.com" {
+		t.Errorf("Esperado nome e email normalizados, mas obteve %q e %q", user.Name, user.Email)
+	}
+	if user.PasswordHash == "" || strings.Contains(user.PasswordHash, "senha-segura") {
+		t.Error("A senha deveria ser gravada apenas como hash")
+	}
+	if !user.CheckPassword("senha-segura") || user.CheckPassword("senha-errada") {
+		t.Error("CheckPassword deveria aceitar apenas a senha cadastrada")
+	}
+}
+
+func TestCreateUser_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		userName string
+		email    string
+		password string
+	}{
+		{name: "nome curto", userName: "M", email: "maria@example.com", password: "senha-segura"},
+		{name: "email inválido", userName: "Maria", email: "maria", password: "senha-segura"},
+		{name: "email com nome", userName: "Maria", email: "Maria <maria@example.com>", password: "senha-segura"},
+		{name: "senha curta", userName: "Maria", email: "maria@example.com", password: "curta"},
+		{name: "senha longa demais", userName: "Maria", email: "maria@example.com", password: strings.Repeat("a", 73)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateUser(tt.userName, tt.email, tt.password); err == nil || err.Err != "bad_request" {
+				t.Errorf("Esperado bad request, mas obteve %v", err)
+			}
+		})
+	}
+}
+
+func TestUser_UpdateKeepsEmptyFields(t *testing.T) {
+	user, _ := CreateUser("Maria", "maria@example.com", "senha-segura")
+	hash := user.PasswordHash
+
+	if err := user.Update("Maria Silva", "", ""); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if user.Name != "Maria Silva" || user.Email != "maria@example.com" || user.PasswordHash != hash {
+		t.Errorf("Apenas o nome deveria mudar, mas obteve %+v", user)
+	}
+
+	if err := user.Update("", "", "nova-senha-segura"); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !user.CheckPassword("nova-senha-segura") {
+		t.Error("A nova senha deveria ser aceita")
+	}
+}
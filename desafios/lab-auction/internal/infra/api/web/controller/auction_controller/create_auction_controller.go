@@ -3,6 +3,7 @@ package auction_controller
 import (
 	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
 	"fullcycle-auction_go/internal/infra/api/web/validation"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"github.com/gin-gonic/gin"
@@ -28,6 +29,7 @@ func (u *AuctionController) CreateAuction(c *gin.Context) {
 		c.JSON(restErr.Code, restErr)
 		return
 	}
+	auctionInputDTO.SellerId = middleware.UserId(c)
 
 	err := u.auctionUseCase.CreateAuction(context.Background(), auctionInputDTO)
 	if err != nil {
@@ -3,11 +3,11 @@ package auction_controller
 import (
 	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
-	"strconv"
 )
 
 func (u *AuctionController) FindAuctionById(c *gin.Context) {
@@ -34,19 +34,14 @@ func (u *AuctionController) FindAuctionById(c *gin.Context) {
 }
 
 func (u *AuctionController) FindAuctions(c *gin.Context) {
-	status := c.Query("status")
-	category := c.Query("category")
-	productName := c.Query("productName")
-
-	statusNumber, errConv := strconv.Atoi(status)
-	if errConv != nil {
-		errRest := rest_err.NewBadRequestError("Error trying to validate auction status param")
+	var findInputDTO auction_usecase.FindAuctionsInputDTO
+	if err := c.ShouldBindQuery(&findInputDTO); err != nil {
+		errRest := validation.ValidateErr(err)
 		c.JSON(errRest.Code, errRest)
 		return
 	}
 
-	auctions, err := u.auctionUseCase.FindAuctions(context.Background(),
-		auction_usecase.AuctionStatus(statusNumber), category, productName)
+	auctions, err := u.auctionUseCase.FindAuctions(context.Background(), findInputDTO)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
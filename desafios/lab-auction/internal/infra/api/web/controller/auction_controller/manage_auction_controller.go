@@ -0,0 +1,117 @@
+package auction_controller
+
+import (
+	"errors"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (u *AuctionController) UpdateAuction(c *gin.Context) {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return
+	}
+
+	var auctionInputDTO auction_usecase.AuctionInputDTO
+	if err := c.ShouldBindJSON(&auctionInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+	auctionInputDTO.SellerId = middleware.UserId(c)
+
+	auctionData, err := u.auctionUseCase.UpdateAuction(c.Request.Context(), auctionId, auctionInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, auctionData)
+}
+
+func (u *AuctionController) CancelAuction(c *gin.Context) {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return
+	}
+
+	if err := u.auctionUseCase.CancelAuction(c.Request.Context(), middleware.UserId(c), auctionId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (u *AuctionController) CloseAuction(c *gin.Context) {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return
+	}
+
+	if err := u.auctionUseCase.CloseAuction(c.Request.Context(), middleware.UserId(c), auctionId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RelistAuction accepts an optional body with the new end time.
+func (u *AuctionController) RelistAuction(c *gin.Context) {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return
+	}
+
+	var relistInputDTO auction_usecase.RelistInputDTO
+	if err := c.ShouldBindJSON(&relistInputDTO); err != nil && !errors.Is(err, io.EOF) {
+		restErr := validation.ValidateErr(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	auctionData, err := u.auctionUseCase.RelistAuction(
+		c.Request.Context(), middleware.UserId(c), auctionId, relistInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	c.JSON(http.StatusCreated, auctionData)
+}
+
+// auctionIdParam returns the auctionId path parameter, answering the
+// request with a bad request when it is not a valid id.
+func auctionIdParam(c *gin.Context) (string, bool) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return "", false
+	}
+
+	return auctionId, true
+}
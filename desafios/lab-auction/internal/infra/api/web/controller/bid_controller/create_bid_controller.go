@@ -1,8 +1,9 @@
 package bid_controller
 
 import (
-	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
 	"fullcycle-auction_go/internal/infra/api/web/validation"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"github.com/gin-gonic/gin"
@@ -28,8 +29,9 @@ func (u *BidController) CreateBid(c *gin.Context) {
 		c.JSON(restErr.Code, restErr)
 		return
 	}
+	bidInputDTO.UserId = middleware.UserId(c)
 
-	err := u.bidUseCase.CreateBid(context.Background(), bidInputDTO)
+	bidResult, err := u.bidUseCase.CreateBid(c.Request.Context(), bidInputDTO)
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
@@ -37,5 +39,16 @@ func (u *BidController) CreateBid(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	c.JSON(bidResultStatusCode(bidResult.Status), bidResult)
+}
+
+func bidResultStatusCode(status bid_entity.BidStatus) int {
+	switch status {
+	case bid_entity.BidAccepted:
+		return http.StatusCreated
+	case bid_entity.BidOutbid, bid_entity.BidAuctionClosed:
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
 }
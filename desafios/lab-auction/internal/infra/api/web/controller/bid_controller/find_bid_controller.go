@@ -3,6 +3,8 @@ package bid_controller
 import (
 	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -21,7 +23,14 @@ func (u *BidController) FindBidByAuctionId(c *gin.Context) {
 		return
 	}
 
-	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(context.Background(), auctionId)
+	var findInputDTO bid_usecase.FindBidsInputDTO
+	if err := c.ShouldBindQuery(&findInputDTO); err != nil {
+		errRest := validation.ValidateErr(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(context.Background(), auctionId, findInputDTO)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
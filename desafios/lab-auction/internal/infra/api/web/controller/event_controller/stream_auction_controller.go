@@ -0,0 +1,145 @@
+package event_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const timeRemainingInterval = time.Second
+
+type EventController struct {
+	auctionUseCase  auction_usecase.AuctionUseCaseInterface
+	eventSubscriber event_entity.EventSubscriber
+}
+
+func NewEventController(
+	auctionUseCase auction_usecase.AuctionUseCaseInterface,
+	eventSubscriber event_entity.EventSubscriber) *EventController {
+	return &EventController{
+		auctionUseCase:  auctionUseCase,
+		eventSubscriber: eventSubscriber,
+	}
+}
+
+type AuctionEventOutputDTO struct {
+	Type      event_entity.EventType `json:"type"`
+	AuctionId string                 `json:"auction_id"`
+	BidId     string                 `json:"bid_id,omitempty"`
+	UserId    string                 `json:"user_id,omitempty"`
+	Amount    float64                `json:"amount,omitempty"`
+	EndTime   *time.Time             `json:"end_time,omitempty" time_format:"2006-01-02 15:04:05"`
+	Timestamp time.Time              `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+}
+
+type TimeRemainingOutputDTO struct {
+	AuctionId        string    `json:"auction_id"`
+	EndTime          time.Time `json:"end_time" time_format:"2006-01-02 15:04:05"`
+	RemainingSeconds int64     `json:"remaining_seconds"`
+}
+
+// StreamAuctionEvents streams an auction as server-sent events: a snapshot
+// of the auction, then each new high bid, the time remaining every second
+// and finally the closing or cancellation event, after which the stream ends.
+func (ec *EventController) StreamAuctionEvents(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	// Subscribing before reading the snapshot means no bid placed in
+	// between is missed.
+	events, unsubscribe := ec.eventSubscriber.Subscribe(auctionId)
+	defer unsubscribe()
+
+	auction, err := ec.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.SSEvent("snapshot", auction)
+	c.Writer.Flush()
+
+	if endEvent, ended := endEventType(auction.Status); ended {
+		c.SSEvent(string(endEvent), AuctionEventOutputDTO{
+			Type:      endEvent,
+			AuctionId: auction.Id,
+			Timestamp: auction.EndTime,
+		})
+		return
+	}
+
+	ticker := time.NewTicker(timeRemainingInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+
+			eventOutput := AuctionEventOutputDTO{
+				Type:      event.Type,
+				AuctionId: event.AuctionId,
+				BidId:     event.BidId,
+				UserId:    event.UserId,
+				Amount:    event.Amount,
+				Timestamp: event.Timestamp,
+			}
+			if !event.EndTime.IsZero() {
+				auction.EndTime = event.EndTime
+				eventOutput.EndTime = &event.EndTime
+			}
+
+			c.SSEvent(string(event.Type), eventOutput)
+			return event.Type != event_entity.AuctionClosed && event.Type != event_entity.AuctionCancelled
+		case now := <-ticker.C:
+			remaining := auction.EndTime.Sub(now)
+			if remaining < 0 {
+				remaining = 0
+			}
+
+			c.SSEvent("time_remaining", TimeRemainingOutputDTO{
+				AuctionId:        auction.Id,
+				EndTime:          auction.EndTime,
+				RemainingSeconds: int64(remaining.Round(time.Second) / time.Second),
+			})
+			return true
+		}
+	})
+}
+
+// endEventType returns the event that ends the stream of an auction that
+// has already ended with the given status.
+func endEventType(status auction_usecase.AuctionStatus) (event_entity.EventType, bool) {
+	switch auction_entity.AuctionStatus(status) {
+	case auction_entity.Completed:
+		return event_entity.AuctionClosed, true
+	case auction_entity.Cancelled:
+		return event_entity.AuctionCancelled, true
+	default:
+		return "", false
+	}
+}
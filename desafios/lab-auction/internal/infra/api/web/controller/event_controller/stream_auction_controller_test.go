@@ -0,0 +1,128 @@
+package event_controller
+
+import (
+	"bufio"
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/infra/pubsub"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type auctionUseCaseFake struct {
+	auction_usecase.AuctionUseCaseInterface
+	auction *auction_usecase.AuctionOutputDTO
+}
+
+func (f *auctionUseCaseFake) FindAuctionById(
+	ctx context.Context, id string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if f.auction == nil || f.auction.Id != id {
+		return nil, internal_error.NewNotFoundError("auction not found")
+	}
+	return f.auction, nil
+}
+
+func newStreamServer(t *testing.T, auction *auction_usecase.AuctionOutputDTO) (*httptest.Server, *pubsub.Hub) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	hub := pubsub.NewHub(nil)
+	router := gin.New()
+	router.GET("/auction/:auctionId/events",
+		NewEventController(&auctionUseCaseFake{auction: auction}, hub).StreamAuctionEvents)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, hub
+}
+
+// readEvents lê os nomes dos eventos SSE até o fim do stream.
+func readEvents(t *testing.T, resp *http.Response, onEvent func(name string)) []string {
+	t.Helper()
+
+	var names []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event:"); ok {
+			names = append(names, name)
+			if onEvent != nil {
+				onEvent(name)
+			}
+		}
+	}
+	return names
+}
+
+func TestStreamAuctionEvents_PushesBidsUntilClosed(t *testing.T) {
+	auction := &auction_usecase.AuctionOutputDTO{
+		Id:      uuid.New().String(),
+		EndTime: time.Now().Add(time.Hour),
+	}
+	server, hub := newStreamServer(t, auction)
+
+	resp, err := http.Get(server.URL + "/auction/" + auction.Id + "/events")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Esperado Content-Type text/event-stream, mas obteve %q", contentType)
+	}
+
+	names := readEvents(t, resp, func(name string) {
+		if name == "snapshot" {
+			hub.Publish(context.Background(), event_entity.AuctionEvent{
+				Type: event_entity.HighBid, AuctionId: auction.Id, Amount: 150})
+			hub.Publish(context.Background(), event_entity.AuctionEvent{
+				Type: event_entity.AuctionClosed, AuctionId: auction.Id})
+		}
+	})
+
+	expected := []string{"snapshot", "high_bid", "auction_closed"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Esperado eventos %v, mas obteve %v", expected, names)
+	}
+}
+
+func TestStreamAuctionEvents_ClosedAuctionEndsImmediately(t *testing.T) {
+	auction := &auction_usecase.AuctionOutputDTO{
+		Id:     uuid.New().String(),
+		Status: auction_usecase.AuctionStatus(auction_entity.Completed),
+	}
+	server, _ := newStreamServer(t, auction)
+
+	resp, err := http.Get(server.URL + "/auction/" + auction.Id + "/events")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	defer resp.Body.Close()
+
+	names := readEvents(t, resp, nil)
+	if strings.Join(names, ",") != "snapshot,auction_closed" {
+		t.Errorf("Esperado snapshot seguido de auction_closed, mas obteve %v", names)
+	}
+}
+
+func TestStreamAuctionEvents_UnknownAuction(t *testing.T) {
+	server, _ := newStreamServer(t, nil)
+
+	resp, err := http.Get(server.URL + "/auction/" + uuid.New().String() + "/events")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Esperado status 404, mas obteve %d", resp.StatusCode)
+	}
+}
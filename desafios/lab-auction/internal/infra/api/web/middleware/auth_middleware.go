@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userIdKey = "userId"
+
+// Authenticate rejects requests without a valid "Authorization: Bearer"
+// token and stores the authenticated user id for the handlers, which read
+// it with UserId.
+func Authenticate(userUseCase user_usecase.UserUseCaseInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || token == "" {
+			errRest := rest_err.NewUnauthorizedError("Missing bearer token")
+			c.AbortWithStatusJSON(errRest.Code, errRest)
+			return
+		}
+
+		userId, err := userUseCase.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			errRest := rest_err.ConvertError(err)
+			c.AbortWithStatusJSON(errRest.Code, errRest)
+			return
+		}
+
+		c.Set(userIdKey, userId)
+		c.Next()
+	}
+}
+
+// UserId returns the id of the authenticated user, or an empty string on
+// routes without the Authenticate middleware.
+func UserId(c *gin.Context) string {
+	return c.GetString(userIdKey)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fullcycle-auction_go/internal/internal_error"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtHeader is the only header this service issues and accepts: HS256
+// tokens, so a token claiming another algorithm is rejected outright.
+const jwtHeader = `{"alg":"HS256","typ":"JWT"}`
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// JWTService issues and verifies HS256 JSON Web Tokens whose subject is the
+// user id.
+type JWTService struct {
+	secret     []byte
+	expiration time.Duration
+	now        func() time.Time
+}
+
+func NewJWTService(secret []byte, expiration time.Duration, now func() time.Time) *JWTService {
+	return &JWTService{
+		secret:     secret,
+		expiration: expiration,
+		now:        now,
+	}
+}
+
+func (js *JWTService) IssueToken(userId string) (string, time.Time, *internal_error.InternalError) {
+	issuedAt := js.now()
+	expiresAt := issuedAt.Add(js.expiration)
+
+	claims, err := json.Marshal(jwtClaims{
+		Subject:   userId,
+		IssuedAt:  issuedAt.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, internal_error.NewInternalServerError("Error trying to issue token")
+	}
+
+	unsigned := encodeSegment([]byte(jwtHeader)) + "." + encodeSegment(claims)
+	return unsigned + "." + js.sign(unsigned), expiresAt, nil
+}
+
+func (js *JWTService) VerifyToken(token string) (string, *internal_error.InternalError) {
+	claims, err := js.parse(token)
+	if err != nil {
+		return "", internal_error.NewUnauthorizedError("Invalid or expired token")
+	}
+
+	return claims.Subject, nil
+}
+
+func (js *JWTService) parse(token string) (*jwtClaims, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("token must have three segments")
+	}
+
+	unsigned := segments[0] + "." + segments[1]
+	if !hmac.Equal([]byte(segments[2]), []byte(js.sign(unsigned))) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(segments[0])
+	if err != nil || string(header) != jwtHeader {
+		return nil, errors.New("unsupported token header")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" || js.now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+
+	return &claims, nil
+}
+
+func (js *JWTService) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, js.secret)
+	mac.Write([]byte(unsigned))
+	return encodeSegment(mac.Sum(nil))
+}
+
+func encodeSegment(segment []byte) string {
+	return base64.RawURLEncoding.EncodeToString(segment)
+}
+
+// GetJWTSecret returns the key tokens are signed with. There is no default:
+// a guessable key would let anyone sign in as any user.
+func GetJWTSecret() ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < 32 {
+		return nil, errors.New("JWT_SECRET must have at least 32 characters")
+	}
+
+	return []byte(secret), nil
+}
+
+func GetJWTExpiration() time.Duration {
+	duration, err := time.ParseDuration(os.Getenv("JWT_EXPIRATION"))
+	if err != nil || duration <= 0 {
+		return 24 * time.Hour
+	}
+
+	return duration
+}
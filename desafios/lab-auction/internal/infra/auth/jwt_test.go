@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("segredo-de-teste-com-32-caracteres!")
+
+func TestJWTService_IssueAndVerify(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	service := NewJWTService(testSecret, time.Hour, func() time.Time { return now })
+
+	token, expiresAt, err := service.IssueToken("user-1")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Esperado expiração em %v, mas obteve %v", now.Add(time.Hour), expiresAt)
+	}
+
+	userId, err := service.VerifyToken(token)
+	if err != nil || userId != "user-1" {
+		t.Errorf("Esperado user-1, mas obteve %q com erro %v", userId, err)
+	}
+}
+
+func TestJWTService_RejectsInvalidTokens(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	service := NewJWTService(testSecret, time.Hour, func() time.Time { return now })
+	token, _, _ := service.IssueToken("user-1")
+
+	otherKey := NewJWTService([]byte("outro-segredo-com-mais-de-32-caracteres"), time.Hour, func() time.Time { return now })
+	forged, _, _ := otherKey.IssueToken("user-1")
+
+	segments := strings.Split(token, ".")
+	noneHeader := encodeSegment([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + segments[1] + "."
+
+	tests := map[string]string{
+		"vazio":             "",
+		"malformado":        "abc.def",
+		"assinatura alheia": forged,
+		"algoritmo none":    noneHeader,
+		"payload alterado":  segments[0] + "." + encodeSegment([]byte(`{"sub":"admin","exp":9999999999}`)) + "." + segments[2],
+	}
+	for name, candidate := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := service.VerifyToken(candidate); err == nil || err.Err != "unauthorized" {
+				t.Errorf("Esperado erro unauthorized, mas obteve %v", err)
+			}
+		})
+	}
+
+	expired := NewJWTService(testSecret, time.Hour, func() time.Time { return now.Add(time.Hour) })
+	if _, err := expired.VerifyToken(token); err == nil {
+		t.Error("Esperado erro para token expirado")
+	}
+}
@@ -0,0 +1,67 @@
+package auction
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CloseExpiredAuctions completes every active auction whose end time is not
+// after now and returns the ids of the auctions it closed. Each auction is
+// completed with its own conditional update that checks the end time again,
+// so an auction extended by a late bid in the meantime stays open, and when
+// several replicas run the closer an auction is reported by only one of them.
+func (ar *AuctionRepository) CloseExpiredAuctions(
+	ctx context.Context, now time.Time) ([]string, *internal_error.InternalError) {
+	cursor, err := ar.Collection.Find(ctx, expiredFilter(now), options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		logger.Error("Error trying to find expired auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find expired auctions")
+	}
+	defer cursor.Close(ctx)
+
+	var expired []AuctionEntityMongo
+	if err := cursor.All(ctx, &expired); err != nil {
+		logger.Error("Error decoding expired auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding expired auctions")
+	}
+
+	var closed []string
+	update := bson.M{"$set": bson.M{"status": auction_entity.Completed}}
+	for _, auction := range expired {
+		filter := expiredFilter(now)
+		filter["_id"] = auction.Id
+
+		result, err := ar.Collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			logger.Error("Error trying to close expired auction", err)
+			return closed, internal_error.NewInternalServerError("Error trying to close expired auctions")
+		}
+		if result.ModifiedCount == 1 {
+			closed = append(closed, auction.Id)
+		}
+	}
+
+	return closed, nil
+}
+
+// expiredFilter matches active auctions whose end time is not after now.
+// Auctions created before end_time was persisted end AUCTION_INTERVAL after
+// their creation.
+func expiredFilter(now time.Time) bson.M {
+	return bson.M{
+		"status": auction_entity.Active,
+		"$or": bson.A{
+			bson.M{"end_time": bson.M{"$lte": now.Unix()}},
+			bson.M{
+				"end_time":  bson.M{"$exists": false},
+				"timestamp": bson.M{"$lte": now.Add(-auction_entity.GetAuctionInterval()).Unix()},
+			},
+		},
+	}
+}
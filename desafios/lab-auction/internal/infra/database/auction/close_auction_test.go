@@ -0,0 +1,82 @@
+package auction
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCloseExpiredAuctions(t *testing.T) {
+	t.Setenv("AUCTION_INTERVAL", "10m")
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+
+	now := time.Now()
+	expired := insertAuction(t, repository, bson.M{"end_time": now.Add(-time.Minute).Unix()})
+	endingNow := insertAuction(t, repository, bson.M{"end_time": now.Unix()})
+	legacyExpired := insertAuction(t, repository, bson.M{
+		"end_time":  nil,
+		"timestamp": now.Add(-11 * time.Minute).Unix(),
+	})
+	open := insertAuction(t, repository, nil)
+	legacyOpen := insertAuction(t, repository, bson.M{
+		"end_time":  nil,
+		"timestamp": now.Add(-9 * time.Minute).Unix(),
+	})
+	cancelled := insertAuction(t, repository, bson.M{
+		"status":   auction_entity.Cancelled,
+		"end_time": now.Add(-time.Minute).Unix(),
+	})
+
+	// A late bid extends the auction after the closer loaded it.
+	extended := insertAuction(t, repository, bson.M{"end_time": now.Add(-time.Second).Unix()})
+	updated, err := repository.UpdateHighestBid(ctx, extended, newBid(extended.Id, 100), now.Add(time.Minute), time.Minute)
+	if err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	closed, err := repository.CloseExpiredAuctions(ctx, now)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	expected := []string{expired.Id, endingNow.Id, legacyExpired.Id}
+	sort.Strings(expected)
+	sort.Strings(closed)
+	if len(closed) != len(expected) {
+		t.Fatalf("Esperado %d leilões encerrados, obteve %d: %v", len(expected), len(closed), closed)
+	}
+	for i := range expected {
+		if closed[i] != expected[i] {
+			t.Fatalf("Esperado leilões %v encerrados, obteve %v", expected, closed)
+		}
+	}
+
+	for _, id := range expected {
+		if stored := findAuction(t, repository, id); stored.Status != auction_entity.Completed {
+			t.Errorf("Esperado leilão %s completo, obteve status %d", id, stored.Status)
+		}
+	}
+	for _, id := range []string{open.Id, legacyOpen.Id, extended.Id} {
+		if stored := findAuction(t, repository, id); stored.Status != auction_entity.Active {
+			t.Errorf("Esperado leilão %s ativo, obteve status %d", id, stored.Status)
+		}
+	}
+	if stored := findAuction(t, repository, cancelled.Id); stored.Status != auction_entity.Cancelled {
+		t.Errorf("Esperado leilão cancelado mantido, obteve status %d", stored.Status)
+	}
+
+	// A second run, as from another replica, reports nothing.
+	closed, err = repository.CloseExpiredAuctions(ctx, now)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(closed) != 0 {
+		t.Errorf("Esperado nenhum leilão encerrado novamente, obteve %v", closed)
+	}
+}
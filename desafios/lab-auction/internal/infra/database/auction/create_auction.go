@@ -5,21 +5,42 @@ import (
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/internal_error"
-	"os"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AuctionEntityMongo struct {
 	Id          string                          `bson:"_id"`
+	SellerId    string                          `bson:"seller_id,omitempty"`
 	ProductName string                          `bson:"product_name"`
 	Category    string                          `bson:"category"`
 	Description string                          `bson:"description"`
 	Condition   auction_entity.ProductCondition `bson:"condition"`
+	Type        auction_entity.AuctionType      `bson:"type,omitempty"`
 	Status      auction_entity.AuctionStatus    `bson:"status"`
 	Timestamp   int64                           `bson:"timestamp"`
+	EndTime     int64                           `bson:"end_time,omitempty"`
+
+	StartingPrice       float64 `bson:"starting_price"`
+	ReservePrice        float64 `bson:"reserve_price"`
+	MinIncrement        float64 `bson:"min_increment"`
+	MinIncrementPercent float64 `bson:"min_increment_percent"`
+	PriceDrop           float64 `bson:"price_drop,omitempty"`
+	PriceDropInterval   int64   `bson:"price_drop_interval,omitempty"`
+
+	SoftCloseWindow       int64 `bson:"soft_close_window,omitempty"`
+	SoftCloseExtension    int64 `bson:"soft_close_extension,omitempty"`
+	SoftCloseMaxExtension int64 `bson:"soft_close_max_extension,omitempty"`
+	ExtendedBy            int64 `bson:"extended_by,omitempty"`
+
+	HighestBidId     string  `bson:"highest_bid_id,omitempty"`
+	HighestBidUserId string  `bson:"highest_bid_user_id,omitempty"`
+	HighestBidAmount float64 `bson:"highest_bid_amount,omitempty"`
+
+	BidCount     int64  `bson:"bid_count,omitempty"`
+	Revision     int64  `bson:"revision,omitempty"`
+	RelistedFrom string `bson:"relisted_from,omitempty"`
 }
 type AuctionRepository struct {
 	Collection *mongo.Collection
@@ -36,42 +57,91 @@ func (ar *AuctionRepository) CreateAuction(
 	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
 	auctionEntityMongo := &AuctionEntityMongo{
 		Id:          auctionEntity.Id,
+		SellerId:    auctionEntity.SellerId,
 		ProductName: auctionEntity.ProductName,
 		Category:    auctionEntity.Category,
 		Description: auctionEntity.Description,
 		Condition:   auctionEntity.Condition,
+		Type:        auctionEntity.Type,
 		Status:      auctionEntity.Status,
 		Timestamp:   auctionEntity.Timestamp.Unix(),
+		EndTime:     auctionEntity.EndTime.Unix(),
+
+		StartingPrice:       auctionEntity.BidRules.StartingPrice,
+		ReservePrice:        auctionEntity.BidRules.ReservePrice,
+		MinIncrement:        auctionEntity.BidRules.MinIncrement,
+		MinIncrementPercent: auctionEntity.BidRules.MinIncrementPercent,
+		PriceDrop:           auctionEntity.BidRules.PriceDrop,
+		PriceDropInterval:   int64(auctionEntity.BidRules.PriceDropInterval / time.Second),
+
+		SoftCloseWindow:       int64(auctionEntity.SoftClose.Window / time.Second),
+		SoftCloseExtension:    int64(auctionEntity.SoftClose.Extension / time.Second),
+		SoftCloseMaxExtension: int64(auctionEntity.SoftClose.MaxExtension / time.Second),
+
+		RelistedFrom: auctionEntity.RelistedFrom,
 	}
 	_, err := ar.Collection.InsertOne(ctx, auctionEntityMongo)
 	if err != nil {
+		// The unique index on relisted_from allows one relist per auction.
+		if auctionEntity.RelistedFrom != "" && mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewBadRequestError("Auction was already relisted")
+		}
 		logger.Error("Error trying to insert auction", err)
 		return internal_error.NewInternalServerError("Error trying to insert auction")
 	}
 
-	go func() {
-		select {
-		case <-time.After(getAutctionInterval()):
-			update := bson.M{"$set": bson.M{"status": auction_entity.Completed}}
-			filter := bson.M{"_id": auctionEntityMongo.Id}
-
-			_, err := ar.Collection.UpdateOne(ctx, filter, update)
-			if err != nil {
-				logger.Error("Error trying to update auction", err)
-				return
-			}
-		}
-	}()
-
 	return nil
 }
 
-func getAutctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
-	if err != nil {
-		return 5 * time.Minute
+// endTime returns the stored end time. Auctions created before end_time was
+// persisted end AUCTION_INTERVAL after their creation.
+func (am *AuctionEntityMongo) endTime() time.Time {
+	if am.EndTime == 0 {
+		return time.Unix(am.Timestamp, 0).Add(auction_entity.GetAuctionInterval())
+	}
+	return time.Unix(am.EndTime, 0)
+}
+
+// auctionType returns the stored type. Auctions created before types were
+// persisted are English auctions.
+func (am *AuctionEntityMongo) auctionType() auction_entity.AuctionType {
+	if am.Type == "" {
+		return auction_entity.English
 	}
-	return duration
+	return am.Type
+}
 
+func (am *AuctionEntityMongo) toEntity() *auction_entity.Auction {
+	return &auction_entity.Auction{
+		Id:          am.Id,
+		SellerId:    am.SellerId,
+		ProductName: am.ProductName,
+		Category:    am.Category,
+		Description: am.Description,
+		Condition:   am.Condition,
+		Type:        am.auctionType(),
+		Status:      am.Status,
+		Timestamp:   time.Unix(am.Timestamp, 0),
+		EndTime:     am.endTime(),
+		BidRules: auction_entity.BidRules{
+			StartingPrice:       am.StartingPrice,
+			ReservePrice:        am.ReservePrice,
+			MinIncrement:        am.MinIncrement,
+			MinIncrementPercent: am.MinIncrementPercent,
+			PriceDrop:           am.PriceDrop,
+			PriceDropInterval:   time.Duration(am.PriceDropInterval) * time.Second,
+		},
+		SoftClose: auction_entity.SoftClose{
+			Window:       time.Duration(am.SoftCloseWindow) * time.Second,
+			Extension:    time.Duration(am.SoftCloseExtension) * time.Second,
+			MaxExtension: time.Duration(am.SoftCloseMaxExtension) * time.Second,
+		},
+		ExtendedBy:       time.Duration(am.ExtendedBy) * time.Second,
+		HighestBidId:     am.HighestBidId,
+		HighestBidUserId: am.HighestBidUserId,
+		HighestBidAmount: am.HighestBidAmount,
+		BidCount:         am.BidCount,
+		Revision:         am.Revision,
+		RelistedFrom:     am.RelistedFrom,
+	}
 }
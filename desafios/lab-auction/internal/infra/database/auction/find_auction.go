@@ -2,13 +2,16 @@ package auction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"time"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 )
 
 func (ar *AuctionRepository) FindAuctionById(
@@ -17,65 +20,86 @@ func (ar *AuctionRepository) FindAuctionById(
 
 	var auctionEntityMongo AuctionEntityMongo
 	if err := ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			logger.Error(fmt.Sprintf("Auction not found with this id = %s", id), err)
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Auction not found with this id = %s", id))
+		}
+
 		logger.Error(fmt.Sprintf("Error trying to find auction by id = %s", id), err)
 		return nil, internal_error.NewInternalServerError("Error trying to find auction by id")
 	}
 
-	return &auction_entity.Auction{
-		Id:          auctionEntityMongo.Id,
-		ProductName: auctionEntityMongo.ProductName,
-		Category:    auctionEntityMongo.Category,
-		Description: auctionEntityMongo.Description,
-		Condition:   auctionEntityMongo.Condition,
-		Status:      auctionEntityMongo.Status,
-		Timestamp:   time.Unix(auctionEntityMongo.Timestamp, 0),
-	}, nil
+	return auctionEntityMongo.toEntity(), nil
 }
 
 func (repo *AuctionRepository) FindAuctions(
 	ctx context.Context,
-	status auction_entity.AuctionStatus,
-	category string,
-	productName string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	query auction_entity.AuctionQuery) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
 	filter := bson.M{}
 
-	if status != 0 {
-		filter["status"] = status
+	if query.Status != nil {
+		filter["status"] = *query.Status
+	}
+
+	if query.Category != "" {
+		filter["category"] = query.Category
 	}
 
-	if category != "" {
-		filter["category"] = category
+	if query.ProductName != "" {
+		filter["product_name"] = primitive.Regex{Pattern: regexp.QuoteMeta(query.ProductName), Options: "i"}
 	}
 
-	if productName != "" {
-		filter["productName"] = primitive.Regex{Pattern: productName, Options: "i"}
+	if query.Search != "" {
+		filter["$text"] = bson.M{"$search": query.Search}
 	}
 
-	cursor, err := repo.Collection.Find(ctx, filter)
+	total, err := repo.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.Error("Error counting auctions", err)
+		return nil, 0, internal_error.NewInternalServerError("Error finding auctions")
+	}
+
+	opts := options.Find().
+		SetSort(auctionSort(query.SortOrDefault())).
+		SetSkip(query.Page.Skip()).
+		SetLimit(query.Page.Size)
+
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
 	if err != nil {
 		logger.Error("Error finding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error finding auctions")
+		return nil, 0, internal_error.NewInternalServerError("Error finding auctions")
 	}
 	defer cursor.Close(ctx)
 
 	var auctionsMongo []AuctionEntityMongo
 	if err := cursor.All(ctx, &auctionsMongo); err != nil {
 		logger.Error("Error decoding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error decoding auctions")
+		return nil, 0, internal_error.NewInternalServerError("Error decoding auctions")
+	}
+
+	auctionsEntity := make([]auction_entity.Auction, len(auctionsMongo))
+	for i, auction := range auctionsMongo {
+		auctionsEntity[i] = *auction.toEntity()
 	}
 
-	var auctionsEntity []auction_entity.Auction
-	for _, auction := range auctionsMongo {
-		auctionsEntity = append(auctionsEntity, auction_entity.Auction{
-			Id:          auction.Id,
-			ProductName: auction.ProductName,
-			Category:    auction.Category,
-			Status:      auction.Status,
-			Description: auction.Description,
-			Condition:   auction.Condition,
-			Timestamp:   time.Unix(auction.Timestamp, 0),
-		})
+	return auctionsEntity, total, nil
+}
+
+// auctionSort returns the sort document of an auction sort. The id breaks
+// ties so that pages never overlap.
+func auctionSort(sort auction_entity.AuctionSort) bson.D {
+	var order bson.D
+	switch sort {
+	case auction_entity.SortEndingSoonest:
+		order = bson.D{{Key: "end_time", Value: 1}}
+	case auction_entity.SortHighestBid:
+		order = bson.D{{Key: "highest_bid_amount", Value: -1}}
+	case auction_entity.SortRelevance:
+		order = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}
+	default:
+		order = bson.D{{Key: "timestamp", Value: -1}}
 	}
 
-	return auctionsEntity, nil
+	return append(order, bson.E{Key: "_id", Value: 1})
 }
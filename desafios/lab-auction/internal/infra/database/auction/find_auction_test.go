@@ -0,0 +1,160 @@
+package auction
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/page_entity"
+	"fullcycle-auction_go/internal/infra/database/migration"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newAuctionRepositoryWithAuctions(t *testing.T) *AuctionRepository {
+	t.Helper()
+	ctx := context.Background()
+
+	database := mongotest.NewDatabase(t)
+	if err := migration.NewMigrator(database, migration.Migrations).Run(ctx); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+
+	now := time.Now()
+	auctions := []AuctionEntityMongo{
+		{
+			ProductName: "Notebook Dell", Category: "Eletrônicos",
+			Description: "Notebook usado em bom estado", Status: auction_entity.Active,
+			Timestamp: now.Add(-4 * time.Hour).Unix(), EndTime: now.Add(3 * time.Hour).Unix(),
+			HighestBidAmount: 1500,
+		},
+		{
+			ProductName: "Bicicleta Caloi", Category: "Esportes",
+			Description: "Bicicleta aro 29", Status: auction_entity.Active,
+			Timestamp: now.Add(-3 * time.Hour).Unix(), EndTime: now.Add(time.Hour).Unix(),
+			HighestBidAmount: 800,
+		},
+		{
+			ProductName: "Capa para notebook", Category: "Acessórios",
+			Description: "Capa de neoprene", Status: auction_entity.Active,
+			Timestamp: now.Add(-2 * time.Hour).Unix(), EndTime: now.Add(2 * time.Hour).Unix(),
+		},
+		{
+			ProductName: "Mouse sem fio", Category: "Eletrônicos",
+			Description: "Mouse ideal para notebook", Status: auction_entity.Completed,
+			Timestamp: now.Add(-time.Hour).Unix(), EndTime: now.Add(-30 * time.Minute).Unix(),
+			HighestBidAmount: 100,
+		},
+	}
+
+	repository := NewAuctionRepository(database)
+	for _, auction := range auctions {
+		auction.Id = uuid.New().String()
+		auction.SellerId = uuid.New().String()
+		auction.Condition = auction_entity.New
+		auction.Type = auction_entity.English
+		if _, err := repository.Collection.InsertOne(ctx, auction); err != nil {
+			t.Fatalf("Erro ao criar leilão no banco: %v", err)
+		}
+	}
+
+	return repository
+}
+
+func TestFindAuctions(t *testing.T) {
+	repository := newAuctionRepositoryWithAuctions(t)
+
+	active := auction_entity.Active
+	firstPage := page_entity.Page{Number: 1, Size: page_entity.DefaultSize}
+
+	tests := []struct {
+		name     string
+		query    auction_entity.AuctionQuery
+		total    int64
+		expected []string
+	}{
+		{
+			name:     "mais recentes",
+			query:    auction_entity.AuctionQuery{Page: page_entity.Page{Number: 1, Size: 2}},
+			total:    4,
+			expected: []string{"Mouse sem fio", "Capa para notebook"},
+		},
+		{
+			name:     "segunda página",
+			query:    auction_entity.AuctionQuery{Page: page_entity.Page{Number: 2, Size: 2}},
+			total:    4,
+			expected: []string{"Bicicleta Caloi", "Notebook Dell"},
+		},
+		{
+			name: "ativos terminando antes",
+			query: auction_entity.AuctionQuery{
+				Status: &active, Sort: auction_entity.SortEndingSoonest, Page: firstPage},
+			total:    3,
+			expected: []string{"Bicicleta Caloi", "Capa para notebook", "Notebook Dell"},
+		},
+		{
+			name:     "maior lance",
+			query:    auction_entity.AuctionQuery{Sort: auction_entity.SortHighestBid, Page: firstPage},
+			total:    4,
+			expected: []string{"Notebook Dell", "Bicicleta Caloi", "Mouse sem fio", "Capa para notebook"},
+		},
+		{
+			name:     "categoria",
+			query:    auction_entity.AuctionQuery{Category: "Eletrônicos", Page: firstPage},
+			total:    2,
+			expected: []string{"Mouse sem fio", "Notebook Dell"},
+		},
+		{
+			name:     "nome do produto sem diferenciar maiúsculas",
+			query:    auction_entity.AuctionQuery{ProductName: "NOTEBOOK", Page: firstPage},
+			total:    2,
+			expected: []string{"Capa para notebook", "Notebook Dell"},
+		},
+		{
+			name:     "nome do produto com caracteres especiais",
+			query:    auction_entity.AuctionQuery{ProductName: "Dell.*", Page: firstPage},
+			total:    0,
+			expected: nil,
+		},
+		{
+			name:     "busca por relevância",
+			query:    auction_entity.AuctionQuery{Search: "notebook", Page: firstPage},
+			total:    3,
+			expected: []string{"Notebook Dell", "Capa para notebook", "Mouse sem fio"},
+		},
+		{
+			name:     "busca com status",
+			query:    auction_entity.AuctionQuery{Search: "notebook", Status: &active, Page: firstPage},
+			total:    2,
+			expected: []string{"Notebook Dell", "Capa para notebook"},
+		},
+		{
+			name:     "busca sem resultados",
+			query:    auction_entity.AuctionQuery{Search: "geladeira", Page: firstPage},
+			total:    0,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auctions, total, internalErr := repository.FindAuctions(context.Background(), tt.query)
+			if internalErr != nil {
+				t.Fatalf("Erro ao buscar leilões: %v", internalErr)
+			}
+
+			if total != tt.total {
+				t.Errorf("Esperado total %d, mas obteve %d", tt.total, total)
+			}
+			if len(auctions) != len(tt.expected) {
+				t.Fatalf("Esperado %d leilões, mas obteve %d", len(tt.expected), len(auctions))
+			}
+			for i, auction := range auctions {
+				if auction.ProductName != tt.expected[i] {
+					t.Errorf("Esperado %s na posição %d, mas obteve %s", tt.expected[i], i, auction.ProductName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UpdateAuction stores an auction edited with Auction.Update. The edit only
+// applies if the auction is still active, has no bids and is still at the
+// revision the edit started from, so it cannot race with a first bid or
+// another edit.
+func (ar *AuctionRepository) UpdateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	filter := bson.M{
+		"_id":            auctionEntity.Id,
+		"status":         auction_entity.Active,
+		"revision":       zeroOrMissing(auctionEntity.Revision - 1),
+		"bid_count":      zeroOrMissing(0),
+		"highest_bid_id": nil,
+	}
+	update := bson.M{"$set": bson.M{
+		"product_name":             auctionEntity.ProductName,
+		"category":                 auctionEntity.Category,
+		"description":              auctionEntity.Description,
+		"condition":                auctionEntity.Condition,
+		"end_time":                 auctionEntity.EndTime.Unix(),
+		"starting_price":           auctionEntity.BidRules.StartingPrice,
+		"reserve_price":            auctionEntity.BidRules.ReservePrice,
+		"min_increment":            auctionEntity.BidRules.MinIncrement,
+		"min_increment_percent":    auctionEntity.BidRules.MinIncrementPercent,
+		"price_drop":               auctionEntity.BidRules.PriceDrop,
+		"price_drop_interval":      int64(auctionEntity.BidRules.PriceDropInterval / time.Second),
+		"soft_close_window":        int64(auctionEntity.SoftClose.Window / time.Second),
+		"soft_close_extension":     int64(auctionEntity.SoftClose.Extension / time.Second),
+		"soft_close_max_extension": int64(auctionEntity.SoftClose.MaxExtension / time.Second),
+		"revision":                 auctionEntity.Revision,
+	}}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to update auction %s", auctionEntity.Id), err)
+		return internal_error.NewInternalServerError("Error trying to update auction")
+	}
+	if result.MatchedCount == 0 {
+		return internal_error.NewBadRequestError(
+			"Auction can no longer be updated: it received a bid, ended or was changed meanwhile")
+	}
+
+	return nil
+}
+
+// EndAuction stores the status and end time of an auction cancelled or
+// closed by its seller, provided it is still active.
+func (ar *AuctionRepository) EndAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	filter := bson.M{"_id": auctionEntity.Id, "status": auction_entity.Active}
+	update := bson.M{"$set": bson.M{
+		"status":   auctionEntity.Status,
+		"end_time": auctionEntity.EndTime.Unix(),
+	}}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to end auction %s", auctionEntity.Id), err)
+		return internal_error.NewInternalServerError("Error trying to end auction")
+	}
+	if result.MatchedCount == 0 {
+		return internal_error.NewBadRequestError("Auction is no longer active")
+	}
+
+	return nil
+}
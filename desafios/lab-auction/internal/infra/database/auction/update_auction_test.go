@@ -0,0 +1,144 @@
+package auction
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// edit applies a valid edit to auction, as the use case does before calling
+// UpdateAuction.
+func edit(t *testing.T, auction *auction_entity.Auction, productName string) {
+	t.Helper()
+
+	err := auction.Update(
+		productName, auction.Category, auction.Description, auction.Condition,
+		time.Time{}, auction.BidRules, auction.SoftClose, time.Now())
+	if err != nil {
+		t.Fatalf("Erro ao editar leilão: %v", err)
+	}
+}
+
+func TestUpdateAuction_StoresEditOfLegacyAuction(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, bson.M{"revision": nil, "bid_count": nil})
+
+	edit(t, auction, "Notebook Lenovo")
+	if err := repository.UpdateAuction(ctx, auction); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.ProductName != "Notebook Lenovo" || stored.Revision != 1 {
+		t.Errorf("Esperado produto editado na revisão 1, obteve %q na revisão %d", stored.ProductName, stored.Revision)
+	}
+}
+
+func TestUpdateAuction_RejectsStaleRevision(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	first := *auction
+	second := *auction
+	edit(t, &first, "Notebook Lenovo")
+	edit(t, &second, "Notebook Acer")
+
+	if err := repository.UpdateAuction(ctx, &first); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	err := repository.UpdateAuction(ctx, &second)
+	if err == nil || err.Err != "bad_request" {
+		t.Fatalf("Esperado bad_request para edição desatualizada, obteve %v", err)
+	}
+
+	if stored := findAuction(t, repository, auction.Id); stored.ProductName != "Notebook Lenovo" {
+		t.Errorf("Esperado a primeira edição mantida, obteve %q", stored.ProductName)
+	}
+}
+
+func TestUpdateAuction_RejectsAuctionWithBids(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		bid  func(repository *AuctionRepository, auction *auction_entity.Auction) (bool, error)
+	}{
+		{
+			name: "high bid",
+			bid: func(repository *AuctionRepository, auction *auction_entity.Auction) (bool, error) {
+				updated, err := repository.UpdateHighestBid(ctx, auction, newBid(auction.Id, 100), auction.EndTime, 0)
+				if err != nil {
+					return false, err
+				}
+				return updated, nil
+			},
+		},
+		{
+			name: "sealed bid",
+			bid: func(repository *AuctionRepository, auction *auction_entity.Auction) (bool, error) {
+				registered, err := repository.RegisterSealedBid(ctx, auction)
+				if err != nil {
+					return false, err
+				}
+				return registered, nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := NewAuctionRepository(mongotest.NewDatabase(t))
+			auction := insertAuction(t, repository, nil)
+
+			// The bid lands after the seller loaded the auction.
+			if placed, err := tc.bid(repository, auction); err != nil || !placed {
+				t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", placed, err)
+			}
+
+			edit(t, auction, "Notebook Lenovo")
+			err := repository.UpdateAuction(ctx, auction)
+			if err == nil || err.Err != "bad_request" {
+				t.Fatalf("Esperado bad_request para leilão com lances, obteve %v", err)
+			}
+
+			if stored := findAuction(t, repository, auction.Id); stored.ProductName != "Notebook Dell" {
+				t.Errorf("Esperado leilão sem alteração, obteve %q", stored.ProductName)
+			}
+		})
+	}
+}
+
+func TestEndAuction_EndsActiveAuctionOnce(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	cancelled := *auction
+	closed := *auction
+	now := time.Now().Truncate(time.Second)
+	if err := cancelled.Cancel(now); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if err := closed.CloseEarly(now); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if err := repository.EndAuction(ctx, &cancelled); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	err := repository.EndAuction(ctx, &closed)
+	if err == nil || err.Err != "bad_request" {
+		t.Fatalf("Esperado bad_request para leilão já encerrado, obteve %v", err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.Status != auction_entity.Cancelled || !stored.EndTime.Equal(now) {
+		t.Errorf("Esperado leilão cancelado em %v, obteve status %d em %v", now, stored.Status, stored.EndTime)
+	}
+}
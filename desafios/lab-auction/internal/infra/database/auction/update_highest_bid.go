@@ -0,0 +1,136 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UpdateHighestBid makes bid the high bid of the auction and stores the
+// end time resulting from the bid, provided the auction is still active and
+// neither its high bid nor its revision changed since the caller validated
+// against them. It returns false when another bid or an edit by the seller
+// got there first, in which case the caller must reload the auction and
+// validate again.
+func (ar *AuctionRepository) UpdateHighestBid(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	bid *bid_entity.Bid,
+	endTime time.Time,
+	extendedBy time.Duration) (bool, *internal_error.InternalError) {
+	filter := bson.M{
+		"_id":            auctionEntity.Id,
+		"status":         auction_entity.Active,
+		"highest_bid_id": highestBidFilter(auctionEntity.HighestBidId),
+		"revision":       zeroOrMissing(auctionEntity.Revision),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"highest_bid_id":      bid.Id,
+			"highest_bid_user_id": bid.UserId,
+			"highest_bid_amount":  bid.Amount,
+			"end_time":            endTime.Unix(),
+			"extended_by":         int64(extendedBy / time.Second),
+		},
+		"$inc": bson.M{"bid_count": 1},
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to update highest bid of auction %s", auctionEntity.Id), err)
+		return false, internal_error.NewInternalServerError("Error trying to update highest bid")
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// RestoreHighestBid undoes UpdateHighestBid when the bid could not be stored,
+// unless a newer bid already replaced it.
+func (ar *AuctionRepository) RestoreHighestBid(
+	ctx context.Context,
+	previous *auction_entity.Auction,
+	bidId string) *internal_error.InternalError {
+	filter := bson.M{"_id": previous.Id, "highest_bid_id": bidId}
+
+	restored := bson.M{
+		"end_time":    previous.EndTime.Unix(),
+		"extended_by": int64(previous.ExtendedBy / time.Second),
+	}
+	update := bson.M{
+		"$set":   restored,
+		"$unset": bson.M{"highest_bid_id": "", "highest_bid_user_id": "", "highest_bid_amount": ""},
+		"$inc":   bson.M{"bid_count": -1},
+	}
+	if previous.HasBids() {
+		restored["highest_bid_id"] = previous.HighestBidId
+		restored["highest_bid_user_id"] = previous.HighestBidUserId
+		restored["highest_bid_amount"] = previous.HighestBidAmount
+		delete(update, "$unset")
+	}
+
+	if _, err := ar.Collection.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error(fmt.Sprintf("Error trying to restore highest bid of auction %s", previous.Id), err)
+		return internal_error.NewInternalServerError("Error trying to restore highest bid")
+	}
+
+	return nil
+}
+
+// RegisterSealedBid counts a sealed bid, provided the auction is still
+// active and at the revision the bid was validated against. Sealed bids do
+// not compete, so they leave the high bid untouched. It returns false when
+// the auction changed, in which case the caller must validate again.
+func (ar *AuctionRepository) RegisterSealedBid(
+	ctx context.Context, auctionEntity *auction_entity.Auction) (bool, *internal_error.InternalError) {
+	filter := bson.M{
+		"_id":      auctionEntity.Id,
+		"status":   auction_entity.Active,
+		"revision": zeroOrMissing(auctionEntity.Revision),
+	}
+	update := bson.M{"$inc": bson.M{"bid_count": 1}}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to register sealed bid of auction %s", auctionEntity.Id), err)
+		return false, internal_error.NewInternalServerError("Error trying to register sealed bid")
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// UnregisterSealedBid undoes RegisterSealedBid when the bid could not be
+// stored.
+func (ar *AuctionRepository) UnregisterSealedBid(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	update := bson.M{"$inc": bson.M{"bid_count": -1}}
+
+	if _, err := ar.Collection.UpdateOne(ctx, bson.M{"_id": auctionEntity.Id}, update); err != nil {
+		logger.Error(fmt.Sprintf("Error trying to unregister sealed bid of auction %s", auctionEntity.Id), err)
+		return internal_error.NewInternalServerError("Error trying to unregister sealed bid")
+	}
+
+	return nil
+}
+
+// highestBidFilter matches a missing high bid when id is empty.
+func highestBidFilter(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+// zeroOrMissing matches value, treating a missing field as zero for
+// documents stored before the field existed.
+func zeroOrMissing(value int64) interface{} {
+	if value == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return value
+}
@@ -0,0 +1,306 @@
+package auction
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// insertAuction stores document as an active auction ending in an hour and
+// returns it as the repository reads it.
+func insertAuction(t *testing.T, repository *AuctionRepository, document bson.M) *auction_entity.Auction {
+	t.Helper()
+	ctx := context.Background()
+
+	now := time.Now()
+	auction := bson.M{
+		"_id":          uuid.New().String(),
+		"seller_id":    uuid.New().String(),
+		"product_name": "Notebook Dell",
+		"category":     "Eletrônicos",
+		"description":  "Notebook usado em bom estado",
+		"condition":    auction_entity.New,
+		"type":         auction_entity.English,
+		"status":       auction_entity.Active,
+		"timestamp":    now.Unix(),
+		"end_time":     now.Add(time.Hour).Unix(),
+	}
+	for key, value := range document {
+		if value == nil {
+			delete(auction, key)
+			continue
+		}
+		auction[key] = value
+	}
+
+	if _, err := repository.Collection.InsertOne(ctx, auction); err != nil {
+		t.Fatalf("Erro ao criar leilão no banco: %v", err)
+	}
+
+	stored, err := repository.FindAuctionById(ctx, auction["_id"].(string))
+	if err != nil {
+		t.Fatalf("Erro ao buscar leilão: %v", err)
+	}
+	return stored
+}
+
+func findAuction(t *testing.T, repository *AuctionRepository, id string) *auction_entity.Auction {
+	t.Helper()
+
+	auction, err := repository.FindAuctionById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Erro ao buscar leilão: %v", err)
+	}
+	return auction
+}
+
+func newBid(auctionId string, amount float64) *bid_entity.Bid {
+	return &bid_entity.Bid{
+		Id:        uuid.New().String(),
+		UserId:    uuid.New().String(),
+		AuctionId: auctionId,
+		Amount:    amount,
+		Timestamp: time.Now(),
+	}
+}
+
+func TestUpdateHighestBid_OnlyOneBidWinsAgainstTheSameHighBid(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	first := newBid(auction.Id, 100)
+	second := newBid(auction.Id, 120)
+
+	updated, err := repository.UpdateHighestBid(ctx, auction, first, auction.EndTime, 0)
+	if err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	// The second bid was validated against the auction without high bid.
+	updated, err = repository.UpdateHighestBid(ctx, auction, second, auction.EndTime, 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if updated {
+		t.Fatal("Esperado lance rejeitado por ter sido validado contra um lance maior desatualizado")
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.HighestBidId != first.Id || stored.HighestBidAmount != 100 || stored.BidCount != 1 {
+		t.Errorf("Esperado lance %s de 100 com 1 lance, obteve %s de %v com %d",
+			first.Id, stored.HighestBidId, stored.HighestBidAmount, stored.BidCount)
+	}
+
+	updated, err = repository.UpdateHighestBid(ctx, stored, second, stored.EndTime, 0)
+	if err != nil || !updated {
+		t.Fatalf("Esperado lance aceito contra o lance maior atual, obteve %v (erro %v)", updated, err)
+	}
+	if stored = findAuction(t, repository, auction.Id); stored.HighestBidId != second.Id || stored.BidCount != 2 {
+		t.Errorf("Esperado lance %s com 2 lances, obteve %s com %d", second.Id, stored.HighestBidId, stored.BidCount)
+	}
+}
+
+func TestUpdateHighestBid_StoresEndTimeAndExtension(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	endTime := auction.EndTime.Add(2 * time.Minute)
+	updated, err := repository.UpdateHighestBid(ctx, auction, newBid(auction.Id, 100), endTime, 2*time.Minute)
+	if err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if !stored.EndTime.Equal(endTime) || stored.ExtendedBy != 2*time.Minute {
+		t.Errorf("Esperado término %v estendido em 2m, obteve %v estendido em %v",
+			endTime, stored.EndTime, stored.ExtendedBy)
+	}
+}
+
+func TestUpdateHighestBid_RejectsEndedAuction(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, bson.M{"status": auction_entity.Completed})
+
+	updated, err := repository.UpdateHighestBid(ctx, auction, newBid(auction.Id, 100), auction.EndTime, 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if updated {
+		t.Error("Esperado lance rejeitado em leilão encerrado")
+	}
+}
+
+func TestUpdateHighestBid_MatchesRevision(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stored   bson.M
+		revision int64
+		updated  bool
+	}{
+		{"legacy auction without revision", bson.M{"revision": nil}, 0, true},
+		{"auction at revision zero", bson.M{"revision": int64(0)}, 0, true},
+		{"auction at the validated revision", bson.M{"revision": int64(2)}, 2, true},
+		{"auction edited after validation", bson.M{"revision": int64(2)}, 1, false},
+		{"legacy auction validated at a later revision", bson.M{"revision": nil}, 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repository := NewAuctionRepository(mongotest.NewDatabase(t))
+			auction := insertAuction(t, repository, tc.stored)
+			auction.Revision = tc.revision
+
+			updated, err := repository.UpdateHighestBid(ctx, auction, newBid(auction.Id, 100), auction.EndTime, 0)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if updated != tc.updated {
+				t.Errorf("Esperado lance aceito %v, obteve %v", tc.updated, updated)
+			}
+
+			registered, err := repository.RegisterSealedBid(ctx, auction)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if registered != tc.updated {
+				t.Errorf("Esperado lance selado aceito %v, obteve %v", tc.updated, registered)
+			}
+		})
+	}
+}
+
+func TestRestoreHighestBid_RestoresPreviousHighBid(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	first := newBid(auction.Id, 100)
+	if updated, err := repository.UpdateHighestBid(ctx, auction, first, auction.EndTime, 0); err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+	previous := findAuction(t, repository, auction.Id)
+
+	second := newBid(auction.Id, 120)
+	extended := previous.EndTime.Add(time.Minute)
+	if updated, err := repository.UpdateHighestBid(ctx, previous, second, extended, time.Minute); err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	if err := repository.RestoreHighestBid(ctx, previous, second.Id); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.HighestBidId != first.Id || stored.HighestBidUserId != first.UserId || stored.HighestBidAmount != 100 {
+		t.Errorf("Esperado lance %s de 100 restaurado, obteve %s de %v",
+			first.Id, stored.HighestBidId, stored.HighestBidAmount)
+	}
+	if stored.BidCount != 1 || !stored.EndTime.Equal(previous.EndTime) || stored.ExtendedBy != 0 {
+		t.Errorf("Esperado 1 lance e término %v sem extensão, obteve %d lances e término %v estendido em %v",
+			previous.EndTime, stored.BidCount, stored.EndTime, stored.ExtendedBy)
+	}
+}
+
+func TestRestoreHighestBid_RemovesOnlyHighBid(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	bid := newBid(auction.Id, 100)
+	if updated, err := repository.UpdateHighestBid(ctx, auction, bid, auction.EndTime, 0); err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	if err := repository.RestoreHighestBid(ctx, auction, bid.Id); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.HasBids() || stored.HighestBidAmount != 0 || stored.BidCount != 0 {
+		t.Errorf("Esperado leilão sem lances, obteve lance %s de %v com %d lances",
+			stored.HighestBidId, stored.HighestBidAmount, stored.BidCount)
+	}
+
+	// With the high bid gone, a bid validated against an empty auction wins.
+	if updated, err := repository.UpdateHighestBid(ctx, auction, newBid(auction.Id, 90), auction.EndTime, 0); err != nil || !updated {
+		t.Errorf("Esperado lance aceito após a restauração, obteve %v (erro %v)", updated, err)
+	}
+}
+
+func TestRestoreHighestBid_KeepsNewerHighBid(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, nil)
+
+	failed := newBid(auction.Id, 100)
+	if updated, err := repository.UpdateHighestBid(ctx, auction, failed, auction.EndTime, 0); err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+	newer := newBid(auction.Id, 120)
+	if updated, err := repository.UpdateHighestBid(ctx, findAuction(t, repository, auction.Id), newer, auction.EndTime, 0); err != nil || !updated {
+		t.Fatalf("Esperado lance aceito, obteve %v (erro %v)", updated, err)
+	}
+
+	if err := repository.RestoreHighestBid(ctx, auction, failed.Id); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.HighestBidId != newer.Id || stored.BidCount != 2 {
+		t.Errorf("Esperado lance %s mantido com 2 lances, obteve %s com %d", newer.Id, stored.HighestBidId, stored.BidCount)
+	}
+}
+
+func TestRegisterSealedBid_CountsBidWithoutHighBid(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, bson.M{"type": auction_entity.SealedFirstPrice})
+
+	for i := 0; i < 2; i++ {
+		if registered, err := repository.RegisterSealedBid(ctx, auction); err != nil || !registered {
+			t.Fatalf("Esperado lance selado aceito, obteve %v (erro %v)", registered, err)
+		}
+	}
+
+	stored := findAuction(t, repository, auction.Id)
+	if stored.BidCount != 2 || stored.HasBids() {
+		t.Errorf("Esperado 2 lances sem lance maior, obteve %d lances e lance maior %q", stored.BidCount, stored.HighestBidId)
+	}
+
+	if err := repository.UnregisterSealedBid(ctx, auction); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if stored = findAuction(t, repository, auction.Id); stored.BidCount != 1 {
+		t.Errorf("Esperado 1 lance após desfazer o registro, obteve %d", stored.BidCount)
+	}
+}
+
+func TestRegisterSealedBid_RejectsEndedAuction(t *testing.T) {
+	ctx := context.Background()
+	repository := NewAuctionRepository(mongotest.NewDatabase(t))
+	auction := insertAuction(t, repository, bson.M{
+		"type":   auction_entity.SealedFirstPrice,
+		"status": auction_entity.Cancelled,
+	})
+
+	registered, err := repository.RegisterSealedBid(ctx, auction)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if registered {
+		t.Error("Esperado lance selado rejeitado em leilão cancelado")
+	}
+	if stored := findAuction(t, repository, auction.Id); stored.BidCount != 0 {
+		t.Errorf("Esperado nenhum lance registrado, obteve %d", stored.BidCount)
+	}
+}
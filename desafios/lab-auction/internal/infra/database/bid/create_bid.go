@@ -7,115 +7,152 @@ import (
 	"fullcycle-auction_go/internal/entity/bid_entity"
 	"fullcycle-auction_go/internal/infra/database/auction"
 	"fullcycle-auction_go/internal/internal_error"
-	"os"
 	"sync"
-	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// maxBidAttempts bounds how many times a bid is revalidated when concurrent
+// bids keep replacing the high bid of the same auction.
+const maxBidAttempts = 10
+
 type BidEntityMongo struct {
 	Id        string  `bson:"_id"`
 	UserId    string  `bson:"user_id"`
 	AuctionId string  `bson:"auction_id"`
 	Amount    float64 `bson:"amount"`
 	Timestamp int64   `bson:"timestamp"`
+	Automatic bool    `bson:"automatic,omitempty"`
 }
 
 type BidRepository struct {
-	Collection            *mongo.Collection
-	AuctionRepository     *auction.AuctionRepository
-	auctionInterval       time.Duration
-	auctionStatusMap      map[string]auction_entity.AuctionStatus
-	auctionEndTimeMap     map[string]time.Time
-	auctionStatusMapMutex *sync.Mutex
-	auctionEndTimeMutex   *sync.Mutex
+	Collection         *mongo.Collection
+	AuctionRepository  *auction.AuctionRepository
+	ProxyBidRepository *ProxyBidRepository
 }
 
-func NewBidRepository(database *mongo.Database, auctionRepository *auction.AuctionRepository) *BidRepository {
+func NewBidRepository(
+	database *mongo.Database,
+	auctionRepository *auction.AuctionRepository,
+	proxyBidRepository *ProxyBidRepository) *BidRepository {
 	return &BidRepository{
-		auctionInterval:       getAuctionInterval(),
-		auctionStatusMap:      make(map[string]auction_entity.AuctionStatus),
-		auctionEndTimeMap:     make(map[string]time.Time),
-		auctionStatusMapMutex: &sync.Mutex{},
-		auctionEndTimeMutex:   &sync.Mutex{},
-		Collection:            database.Collection("bids"),
-		AuctionRepository:     auctionRepository,
+		Collection:         database.Collection("bids"),
+		AuctionRepository:  auctionRepository,
+		ProxyBidRepository: proxyBidRepository,
 	}
 }
 
 func (bd *BidRepository) CreateBid(
 	ctx context.Context,
-	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	bidEntities []bid_entity.Bid) ([]bid_entity.BidResult, *internal_error.InternalError) {
+	results := make([]bid_entity.BidResult, len(bidEntities))
+
 	var wg sync.WaitGroup
-	for _, bid := range bidEntities {
+	for i, bid := range bidEntities {
 		wg.Add(1)
-		go func(bidValue bid_entity.Bid) {
+		go func(i int, bidValue bid_entity.Bid) {
 			defer wg.Done()
+			results[i] = bd.placeBid(ctx, bidValue)
+			if results[i].Status == bid_entity.BidAccepted {
+				results[i].AutoBids = bd.resolveProxyBids(ctx, bidValue.AuctionId)
+			}
+		}(i, bid)
+	}
+	wg.Wait()
 
-			bd.auctionStatusMapMutex.Lock()
-			auctionStatus, okStatus := bd.auctionStatusMap[bidValue.AuctionId]
-			bd.auctionStatusMapMutex.Unlock()
+	return results, nil
+}
 
-			bd.auctionEndTimeMutex.Lock()
-			auctionEndTime, okEndTime := bd.auctionEndTimeMap[bidValue.AuctionId]
-			bd.auctionEndTimeMutex.Unlock()
+// placeBid validates the bid against the current state of its auction and
+// stores it. The auction's high bid is swapped with a compare-and-set, so of
+// two concurrent bids validated against the same high bid only one is
+// accepted; the other is validated again against the new high bid.
+func (bd *BidRepository) placeBid(
+	ctx context.Context, bidValue bid_entity.Bid) bid_entity.BidResult {
+	bidEntityMongo := &BidEntityMongo{
+		Id:        bidValue.Id,
+		UserId:    bidValue.UserId,
+		AuctionId: bidValue.AuctionId,
+		Amount:    bidValue.Amount,
+		Timestamp: bidValue.Timestamp.Unix(),
+		Automatic: bidValue.Automatic,
+	}
 
-			bidEntityMongo := &BidEntityMongo{
-				Id:        bidValue.Id,
-				UserId:    bidValue.UserId,
-				AuctionId: bidValue.AuctionId,
-				Amount:    bidValue.Amount,
-				Timestamp: bidValue.Timestamp.Unix(),
+	for attempt := 0; attempt < maxBidAttempts; attempt++ {
+		auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
+		if err != nil {
+			if err.Err == "not_found" {
+				return bid_entity.BidResult{Status: bid_entity.BidInvalid, Reason: err.Message}
 			}
+			return bid_entity.BidResult{Err: err}
+		}
 
-			if okEndTime && okStatus {
-				now := time.Now()
-				if auctionStatus == auction_entity.Completed || now.After(auctionEndTime) {
-					return
-				}
+		if !auctionEntity.AcceptsBids(bidValue.Timestamp) {
+			return bid_entity.BidResult{
+				Status: bid_entity.BidAuctionClosed,
+				Reason: "Auction is not accepting bids",
+			}
+		}
 
-				if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-					logger.Error("Error trying to insert bid", err)
-					return
-				}
+		if err := auctionEntity.CheckBidder(bidValue.UserId); err != nil {
+			return bid_entity.BidResult{Status: bid_entity.BidInvalid, Reason: err.Message}
+		}
 
-				return
+		if err := auctionEntity.ValidateBid(bidValue.Amount, bidValue.Timestamp); err != nil {
+			status := bid_entity.BidInvalid
+			if auctionEntity.HasBids() {
+				status = bid_entity.BidOutbid
 			}
+			return bid_entity.BidResult{Status: status, Reason: err.Message}
+		}
 
-			auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
+		if auctionEntity.Strategy().Sealed() {
+			registered, err := bd.AuctionRepository.RegisterSealedBid(ctx, auctionEntity)
 			if err != nil {
-				logger.Error("Error trying to find auction by id", err)
-				return
+				return bid_entity.BidResult{Err: err}
 			}
-			if auctionEntity.Status == auction_entity.Completed {
-				return
+			if !registered {
+				continue
 			}
+			return bd.insertSealedBid(ctx, auctionEntity, bidEntityMongo)
+		}
+
+		endTime, extendedBy := auctionEntity.EndTimeAfterBid(bidValue.Timestamp)
+		updated, err := bd.AuctionRepository.UpdateHighestBid(
+			ctx, auctionEntity, &bidValue, endTime, extendedBy)
+		if err != nil {
+			return bid_entity.BidResult{Err: err}
+		}
+		if !updated {
+			continue
+		}
+
+		if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
+			logger.Error("Error trying to insert bid", err)
+			bd.AuctionRepository.RestoreHighestBid(ctx, auctionEntity, bidValue.Id)
+			return bid_entity.BidResult{Err: internal_error.NewInternalServerError("Error trying to insert bid")}
+		}
+
+		return bid_entity.BidResult{Status: bid_entity.BidAccepted, EndTime: endTime}
+	}
 
-			bd.auctionStatusMapMutex.Lock()
-			bd.auctionStatusMap[bidValue.AuctionId] = auctionEntity.Status
-			bd.auctionStatusMapMutex.Unlock()
-
-			bd.auctionEndTimeMutex.Lock()
-			bd.auctionEndTimeMap[bidValue.AuctionId] = auctionEntity.Timestamp.Add(bd.auctionInterval)
-			bd.auctionEndTimeMutex.Unlock()
-
-			if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-				logger.Error("Error trying to insert bid", err)
-				return
-			}
-		}(bid)
+	return bid_entity.BidResult{
+		Err: internal_error.NewInternalServerError("Error trying to place bid: auction is under contention"),
 	}
-	wg.Wait()
-	return nil
 }
 
-func getAuctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
-	if err != nil {
-		return time.Minute * 5
+// insertSealedBid stores a bid of a sealed auction already counted with
+// RegisterSealedBid. Sealed bids do not compete when placed, so the
+// auction's high bid is left untouched.
+func (bd *BidRepository) insertSealedBid(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	bidEntityMongo *BidEntityMongo) bid_entity.BidResult {
+	if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
+		logger.Error("Error trying to insert bid", err)
+		bd.AuctionRepository.UnregisterSealedBid(ctx, auctionEntity)
+		return bid_entity.BidResult{Err: internal_error.NewInternalServerError("Error trying to insert bid")}
 	}
 
-	return duration
+	return bid_entity.BidResult{Status: bid_entity.BidAccepted, EndTime: auctionEntity.EndTime, Sealed: true}
 }
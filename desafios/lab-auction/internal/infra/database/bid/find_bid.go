@@ -5,21 +5,40 @@ import (
 	"fmt"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/page_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
 func (bd *BidRepository) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auctionId": auctionId}
+	ctx context.Context,
+	auctionId string,
+	page page_entity.Page) ([]bid_entity.Bid, int64, *internal_error.InternalError) {
+	filter := bson.M{"auction_id": auctionId}
 
-	cursor, err := bd.Collection.Find(ctx, filter)
+	total, err := bd.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId), err)
+		return nil, 0, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+	}
+
+	// Timestamps have second precision, so bids placed in the same second
+	// are ordered by amount, which only grows in open auctions.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "amount", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(page.Skip()).
+		SetLimit(page.Size)
+
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
 	if err != nil {
 		logger.Error(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
-		return nil, internal_error.NewInternalServerError(
+		return nil, 0, internal_error.NewInternalServerError(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
 	}
 
@@ -27,22 +46,23 @@ func (bd *BidRepository) FindBidByAuctionId(
 	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
 		logger.Error(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
-		return nil, internal_error.NewInternalServerError(
+		return nil, 0, internal_error.NewInternalServerError(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
 	}
 
-	var bidEntities []bid_entity.Bid
-	for _, bidEntityMongo := range bidEntitiesMongo {
-		bidEntities = append(bidEntities, bid_entity.Bid{
+	bidEntities := make([]bid_entity.Bid, len(bidEntitiesMongo))
+	for i, bidEntityMongo := range bidEntitiesMongo {
+		bidEntities[i] = bid_entity.Bid{
 			Id:        bidEntityMongo.Id,
 			UserId:    bidEntityMongo.UserId,
 			AuctionId: bidEntityMongo.AuctionId,
 			Amount:    bidEntityMongo.Amount,
 			Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
-		})
+			Automatic: bidEntityMongo.Automatic,
+		}
 	}
 
-	return bidEntities, nil
+	return bidEntities, total, nil
 }
 
 func (bd *BidRepository) FindWinningBidByAuctionId(
@@ -50,7 +70,7 @@ func (bd *BidRepository) FindWinningBidByAuctionId(
 	filter := bson.M{"auction_id": auctionId}
 
 	var bidEntityMongo BidEntityMongo
-	opts := options.FindOne().SetSort(bson.D{{"amount", -1}})
+	opts := options.FindOne().SetSort(bson.D{{Key: "amount", Value: -1}})
 	if err := bd.Collection.FindOne(ctx, filter, opts).Decode(&bidEntityMongo); err != nil {
 		logger.Error("Error trying to find the auction winner", err)
 		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner")
@@ -62,5 +82,49 @@ func (bd *BidRepository) FindWinningBidByAuctionId(
 		AuctionId: bidEntityMongo.AuctionId,
 		Amount:    bidEntityMongo.Amount,
 		Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
+		Automatic: bidEntityMongo.Automatic,
 	}, nil
 }
+
+func (bd *BidRepository) FindRankedBidsByAuctionId(
+	ctx context.Context, auctionId string, limit int) ([]bid_entity.Bid, *internal_error.InternalError) {
+	ranking := bson.D{{Key: "amount", Value: -1}, {Key: "timestamp", Value: 1}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"auction_id": auctionId}}},
+		{{Key: "$sort", Value: ranking}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "bid": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$bid"}}},
+		{{Key: "$sort", Value: ranking}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to rank bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to rank bids by auctionId %s", auctionId))
+	}
+
+	var bidEntitiesMongo []BidEntityMongo
+	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to rank bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to rank bids by auctionId %s", auctionId))
+	}
+
+	bidEntities := make([]bid_entity.Bid, len(bidEntitiesMongo))
+	for i, bidEntityMongo := range bidEntitiesMongo {
+		bidEntities[i] = bid_entity.Bid{
+			Id:        bidEntityMongo.Id,
+			UserId:    bidEntityMongo.UserId,
+			AuctionId: bidEntityMongo.AuctionId,
+			Amount:    bidEntityMongo.Amount,
+			Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
+			Automatic: bidEntityMongo.Automatic,
+		}
+	}
+
+	return bidEntities, nil
+}
@@ -0,0 +1,166 @@
+package bid
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/page_entity"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/migration"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type placedBid struct {
+	userId string
+	amount float64
+}
+
+// newBidRepositoryWithBids stores an English auction and places the bids on
+// it one second apart, in order.
+func newBidRepositoryWithBids(t *testing.T, bids []placedBid) (*BidRepository, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	database := mongotest.NewDatabase(t)
+	if err := migration.NewMigrator(database, migration.Migrations).Run(ctx); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+
+	auctionRepository := auction.NewAuctionRepository(database)
+	repository := NewBidRepository(database, auctionRepository, NewProxyBidRepository(database))
+
+	auctionEntity, internalErr := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Produto Teste",
+		"Categoria Teste",
+		"Descrição do produto de teste para validação",
+		auction_entity.New,
+		auction_entity.English,
+		time.Now().Add(time.Hour),
+		auction_entity.BidRules{},
+		auction_entity.SoftClose{},
+	)
+	if internalErr != nil {
+		t.Fatalf("Erro ao criar entidade de leilão: %v", internalErr)
+	}
+	if internalErr := auctionRepository.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Erro ao criar leilão no banco: %v", internalErr)
+	}
+
+	start := time.Now()
+	for i, placed := range bids {
+		bid, internalErr := bid_entity.CreateBid(placed.userId, auctionEntity.Id, placed.amount)
+		if internalErr != nil {
+			t.Fatalf("Erro ao criar entidade de lance: %v", internalErr)
+		}
+		bid.Timestamp = start.Add(time.Duration(i) * time.Second)
+
+		results, internalErr := repository.CreateBid(ctx, []bid_entity.Bid{*bid})
+		if internalErr != nil {
+			t.Fatalf("Erro ao registrar lance: %v", internalErr)
+		}
+		if results[0].Status != bid_entity.BidAccepted {
+			t.Fatalf("Esperado lance de %.2f aceito, mas obteve %s: %s",
+				placed.amount, results[0].Status, results[0].Reason)
+		}
+	}
+
+	return repository, auctionEntity.Id
+}
+
+func TestFindBidByAuctionId(t *testing.T) {
+	ctx := context.Background()
+	alice, bob, carol := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	repository, auctionId := newBidRepositoryWithBids(t, []placedBid{
+		{alice, 10}, {bob, 20}, {alice, 30}, {carol, 40}, {bob, 50},
+	})
+
+	tests := []struct {
+		page     page_entity.Page
+		expected []float64
+	}{
+		{page_entity.Page{Number: 1, Size: 2}, []float64{50, 40}},
+		{page_entity.Page{Number: 2, Size: 2}, []float64{30, 20}},
+		{page_entity.Page{Number: 3, Size: 2}, []float64{10}},
+		{page_entity.Page{Number: 4, Size: 2}, nil},
+	}
+
+	for _, tt := range tests {
+		bids, total, internalErr := repository.FindBidByAuctionId(ctx, auctionId, tt.page)
+		if internalErr != nil {
+			t.Fatalf("Erro ao buscar lances: %v", internalErr)
+		}
+
+		if total != 5 {
+			t.Errorf("Página %d: esperado total 5, mas obteve %d", tt.page.Number, total)
+		}
+		if len(bids) != len(tt.expected) {
+			t.Fatalf("Página %d: esperado %d lances, mas obteve %d", tt.page.Number, len(tt.expected), len(bids))
+		}
+		for i, bid := range bids {
+			if bid.Amount != tt.expected[i] {
+				t.Errorf("Página %d: esperado lance %.2f na posição %d, mas obteve %.2f",
+					tt.page.Number, tt.expected[i], i, bid.Amount)
+			}
+			if bid.AuctionId != auctionId {
+				t.Errorf("Esperado lance do leilão %s, mas obteve %s", auctionId, bid.AuctionId)
+			}
+		}
+	}
+}
+
+func TestFindBidByAuctionId_OtherAuction(t *testing.T) {
+	repository, _ := newBidRepositoryWithBids(t, []placedBid{{uuid.New().String(), 10}})
+
+	bids, total, internalErr := repository.FindBidByAuctionId(
+		context.Background(), uuid.New().String(), page_entity.Page{Number: 1, Size: 20})
+	if internalErr != nil {
+		t.Fatalf("Erro ao buscar lances: %v", internalErr)
+	}
+
+	if total != 0 || len(bids) != 0 {
+		t.Errorf("Esperado nenhum lance, mas obteve %d de %d", len(bids), total)
+	}
+}
+
+func TestFindWinningBidByAuctionId(t *testing.T) {
+	alice, bob := uuid.New().String(), uuid.New().String()
+	repository, auctionId := newBidRepositoryWithBids(t, []placedBid{{alice, 10}, {bob, 20}, {alice, 30}})
+
+	winner, internalErr := repository.FindWinningBidByAuctionId(context.Background(), auctionId)
+	if internalErr != nil {
+		t.Fatalf("Erro ao buscar lance vencedor: %v", internalErr)
+	}
+
+	if winner.UserId != alice || winner.Amount != 30 {
+		t.Errorf("Esperado lance vencedor de 30.00 de %s, mas obteve %.2f de %s", alice, winner.Amount, winner.UserId)
+	}
+}
+
+func TestFindRankedBidsByAuctionId(t *testing.T) {
+	alice, bob, carol := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	repository, auctionId := newBidRepositoryWithBids(t, []placedBid{
+		{alice, 10}, {bob, 20}, {alice, 30}, {carol, 40}, {bob, 50},
+	})
+
+	ranked, internalErr := repository.FindRankedBidsByAuctionId(context.Background(), auctionId, 2)
+	if internalErr != nil {
+		t.Fatalf("Erro ao buscar ranking de lances: %v", internalErr)
+	}
+
+	expected := []placedBid{{bob, 50}, {carol, 40}}
+	if len(ranked) != len(expected) {
+		t.Fatalf("Esperado %d lances no ranking, mas obteve %d", len(expected), len(ranked))
+	}
+	for i, bid := range ranked {
+		if bid.UserId != expected[i].userId || bid.Amount != expected[i].amount {
+			t.Errorf("Esperado lance %.2f de %s na posição %d, mas obteve %.2f de %s",
+				expected[i].amount, expected[i].userId, i, bid.Amount, bid.UserId)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package bid
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxProxyRounds bounds how many automatic bids one resolution places. Every
+// automatic bid raises the high bid, so resolution ends on its own; the
+// bound only guards against a pathological increment.
+const maxProxyRounds = 1000
+
+type ProxyBidEntityMongo struct {
+	Id        string  `bson:"_id"`
+	UserId    string  `bson:"user_id"`
+	AuctionId string  `bson:"auction_id"`
+	MaxAmount float64 `bson:"max_amount"`
+	Timestamp int64   `bson:"timestamp"`
+}
+
+type ProxyBidRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewProxyBidRepository(database *mongo.Database) *ProxyBidRepository {
+	return &ProxyBidRepository{
+		Collection: database.Collection("proxy_bids"),
+	}
+}
+
+// UpsertProxyBid stores one proxy bid per user and auction. Raising the
+// maximum keeps the original id and timestamp, so the proxy bid keeps its
+// precedence over later ones with the same maximum.
+func (pr *ProxyBidRepository) UpsertProxyBid(
+	ctx context.Context, proxyBid *bid_entity.ProxyBid) *internal_error.InternalError {
+	filter := bson.M{"auction_id": proxyBid.AuctionId, "user_id": proxyBid.UserId}
+	update := bson.M{
+		"$set": bson.M{"max_amount": proxyBid.MaxAmount},
+		"$setOnInsert": bson.M{
+			"_id":       proxyBid.Id,
+			"timestamp": proxyBid.Timestamp.Unix(),
+		},
+	}
+
+	if _, err := pr.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		logger.Error("Error trying to store proxy bid", err)
+		return internal_error.NewInternalServerError("Error trying to store proxy bid")
+	}
+
+	return nil
+}
+
+func (pr *ProxyBidRepository) FindProxyBidsByAuctionId(
+	ctx context.Context, auctionId string) ([]bid_entity.ProxyBid, *internal_error.InternalError) {
+	cursor, err := pr.Collection.Find(ctx, bson.M{"auction_id": auctionId})
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to find proxy bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find proxy bids by auctionId %s", auctionId))
+	}
+
+	var proxyBidsMongo []ProxyBidEntityMongo
+	if err := cursor.All(ctx, &proxyBidsMongo); err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to find proxy bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find proxy bids by auctionId %s", auctionId))
+	}
+
+	proxyBids := make([]bid_entity.ProxyBid, len(proxyBidsMongo))
+	for i, proxyBidMongo := range proxyBidsMongo {
+		proxyBids[i] = bid_entity.ProxyBid{
+			Id:        proxyBidMongo.Id,
+			UserId:    proxyBidMongo.UserId,
+			AuctionId: proxyBidMongo.AuctionId,
+			MaxAmount: proxyBidMongo.MaxAmount,
+			Timestamp: time.Unix(proxyBidMongo.Timestamp, 0),
+		}
+	}
+
+	return proxyBids, nil
+}
+
+// CreateProxyBid registers the proxy bid and lets the proxy bids of the
+// auction bid against each other. The result is accepted when the user
+// leads the auction afterwards and outbid when a higher maximum leads.
+func (bd *BidRepository) CreateProxyBid(
+	ctx context.Context, proxyBid *bid_entity.ProxyBid) bid_entity.ProxyBidResult {
+	auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, proxyBid.AuctionId)
+	if err != nil {
+		if err.Err == "not_found" {
+			return bid_entity.ProxyBidResult{Status: bid_entity.BidInvalid, Reason: err.Message}
+		}
+		return bid_entity.ProxyBidResult{Err: err}
+	}
+
+	if !auctionEntity.AcceptsBids(proxyBid.Timestamp) {
+		return bid_entity.ProxyBidResult{
+			Status: bid_entity.BidAuctionClosed,
+			Reason: "Auction is not accepting bids",
+		}
+	}
+
+	if auctionEntity.Type != auction_entity.English {
+		return bid_entity.ProxyBidResult{
+			Status: bid_entity.BidInvalid,
+			Reason: "Proxy bids are only available in english auctions",
+		}
+	}
+
+	if err := auctionEntity.CheckBidder(proxyBid.UserId); err != nil {
+		return bid_entity.ProxyBidResult{Status: bid_entity.BidInvalid, Reason: err.Message}
+	}
+
+	leading := auctionEntity.HasBids() && auctionEntity.HighestBidUserId == proxyBid.UserId
+	if leading && proxyBid.MaxAmount < auctionEntity.HighestBidAmount {
+		return bid_entity.ProxyBidResult{
+			Status: bid_entity.BidInvalid,
+			Reason: fmt.Sprintf("Maximum must be at least your current bid of %.2f", auctionEntity.HighestBidAmount),
+		}
+	}
+	if !leading {
+		if err := auctionEntity.ValidateBid(proxyBid.MaxAmount, proxyBid.Timestamp); err != nil {
+			status := bid_entity.BidInvalid
+			if auctionEntity.HasBids() {
+				status = bid_entity.BidOutbid
+			}
+			return bid_entity.ProxyBidResult{Status: status, Reason: err.Message}
+		}
+	}
+
+	if err := bd.ProxyBidRepository.UpsertProxyBid(ctx, proxyBid); err != nil {
+		return bid_entity.ProxyBidResult{Err: err}
+	}
+
+	autoBids := bd.resolveProxyBids(ctx, proxyBid.AuctionId)
+
+	auctionEntity, err = bd.AuctionRepository.FindAuctionById(ctx, proxyBid.AuctionId)
+	if err != nil {
+		return bid_entity.ProxyBidResult{AutoBids: autoBids, Err: err}
+	}
+
+	result := bid_entity.ProxyBidResult{
+		Status:     bid_entity.BidAccepted,
+		HighestBid: auctionEntity.HighestBidAmount,
+		AutoBids:   autoBids,
+	}
+	if auctionEntity.HighestBidUserId != proxyBid.UserId {
+		result.Status = bid_entity.BidOutbid
+		result.Reason = "A higher maximum bid is leading the auction"
+	}
+
+	return result
+}
+
+// resolveProxyBids places the automatic bids the proxy bids of the auction
+// call for, one at a time and each against the current high bid, until no
+// proxy bid can beat the leader. Failures are logged and end the resolution;
+// the bids already placed stand.
+func (bd *BidRepository) resolveProxyBids(
+	ctx context.Context, auctionId string) []bid_entity.PlacedBid {
+	var placed []bid_entity.PlacedBid
+
+	for round := 0; round < maxProxyRounds; round++ {
+		auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, auctionId)
+		if err != nil {
+			logger.Error("Error trying to resolve proxy bids", err)
+			return placed
+		}
+
+		now := time.Now()
+		if !auctionEntity.AcceptsBids(now) {
+			return placed
+		}
+
+		proxyBids, err := bd.ProxyBidRepository.FindProxyBidsByAuctionId(ctx, auctionId)
+		if err != nil {
+			return placed
+		}
+
+		userId, amount, ok := auctionEntity.NextProxyBid(proxyBids, now)
+		if !ok {
+			return placed
+		}
+
+		bid := bid_entity.Bid{
+			Id:        uuid.New().String(),
+			UserId:    userId,
+			AuctionId: auctionId,
+			Amount:    amount,
+			Timestamp: now,
+			Automatic: true,
+		}
+
+		result := bd.placeBid(ctx, bid)
+		if result.Err != nil {
+			logger.Error("Error trying to place automatic bid", result.Err)
+			return placed
+		}
+		if result.Status == bid_entity.BidAccepted {
+			placed = append(placed, bid_entity.PlacedBid{Bid: bid, EndTime: result.EndTime})
+		}
+	}
+
+	return placed
+}
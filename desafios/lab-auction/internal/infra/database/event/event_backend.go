@@ -0,0 +1,179 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	eventsCollection = "auction_events"
+	eventsSizeBytes  = 16 * 1024 * 1024
+
+	// namespaceExistsCode is returned when the capped collection was already
+	// created by another replica.
+	namespaceExistsCode = 48
+
+	reopenDelay = time.Second
+)
+
+type EventEntityMongo struct {
+	Id        primitive.ObjectID     `bson:"_id,omitempty"`
+	Type      event_entity.EventType `bson:"type"`
+	AuctionId string                 `bson:"auction_id"`
+	BidId     string                 `bson:"bid_id,omitempty"`
+	UserId    string                 `bson:"user_id,omitempty"`
+	Amount    float64                `bson:"amount,omitempty"`
+	EndTime   int64                  `bson:"end_time,omitempty"`
+	Timestamp int64                  `bson:"timestamp"`
+}
+
+// EventBackend shares auction events between replicas through a capped
+// collection read with a tailable cursor, which works on a standalone
+// MongoDB (change streams would require a replica set).
+type EventBackend struct {
+	Collection *mongo.Collection
+}
+
+func NewEventBackend(ctx context.Context, database *mongo.Database) (*EventBackend, error) {
+	err := database.CreateCollection(ctx, eventsCollection,
+		options.CreateCollection().SetCapped(true).SetSizeInBytes(eventsSizeBytes))
+
+	var commandErr mongo.CommandError
+	if err != nil && !(errors.As(err, &commandErr) && commandErr.Code == namespaceExistsCode) {
+		logger.Error("Error trying to create auction events collection", err)
+		return nil, err
+	}
+
+	return &EventBackend{
+		Collection: database.Collection(eventsCollection),
+	}, nil
+}
+
+func (eb *EventBackend) Publish(ctx context.Context, event event_entity.AuctionEvent) error {
+	_, err := eb.Collection.InsertOne(ctx, &EventEntityMongo{
+		Type:      event.Type,
+		AuctionId: event.AuctionId,
+		BidId:     event.BidId,
+		UserId:    event.UserId,
+		Amount:    event.Amount,
+		EndTime:   endTimeUnix(event.EndTime),
+		Timestamp: event.Timestamp.UnixNano(),
+	})
+	return err
+}
+
+// Subscribe tails the collection from now on until ctx is done. The cursor
+// dies while the collection is empty or after a network error, so it is
+// reopened after the last event seen.
+//
+// Events are read in insertion ($natural) order. Neither the ObjectIds nor
+// the clocks of different replicas are ordered, so a reopened cursor reads
+// the collection from the start and skips up to the last event seen; if that
+// event was already evicted, so was everything before it.
+func (eb *EventBackend) Subscribe(
+	ctx context.Context, handler func(event_entity.AuctionEvent)) error {
+	opts := options.Find().
+		SetCursorType(options.TailableAwait).
+		SetMaxAwaitTime(time.Second)
+
+	last, err := eb.newestEventId(ctx)
+	for err != nil {
+		logger.Error("Error finding the newest auction event", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reopenDelay):
+		}
+		last, err = eb.newestEventId(ctx)
+	}
+
+	for {
+		skipping, err := eb.isStored(ctx, last)
+		var cursor *mongo.Cursor
+		if err == nil {
+			cursor, err = eb.Collection.Find(ctx, bson.M{}, opts)
+		}
+		if err == nil {
+			for cursor.Next(ctx) {
+				id, _ := cursor.Current.Lookup("_id").ObjectIDOK()
+				if skipping {
+					skipping = id != last
+					continue
+				}
+				last = id
+
+				var eventMongo EventEntityMongo
+				if err := cursor.Decode(&eventMongo); err != nil {
+					logger.Error("Error decoding auction event", err)
+					continue
+				}
+
+				var endTime time.Time
+				if eventMongo.EndTime != 0 {
+					endTime = time.Unix(eventMongo.EndTime, 0)
+				}
+
+				handler(event_entity.AuctionEvent{
+					Type:      eventMongo.Type,
+					AuctionId: eventMongo.AuctionId,
+					BidId:     eventMongo.BidId,
+					UserId:    eventMongo.UserId,
+					Amount:    eventMongo.Amount,
+					EndTime:   endTime,
+					Timestamp: time.Unix(0, eventMongo.Timestamp),
+				})
+			}
+			err = cursor.Err()
+			cursor.Close(context.Background())
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Error("Error tailing auction events", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reopenDelay):
+		}
+	}
+}
+
+// newestEventId returns the id of the last event inserted, or NilObjectID if
+// there is none.
+func (eb *EventBackend) newestEventId(ctx context.Context) (primitive.ObjectID, error) {
+	var newest EventEntityMongo
+	err := eb.Collection.FindOne(ctx, bson.M{},
+		options.FindOne().SetSort(bson.D{{Key: "$natural", Value: -1}})).Decode(&newest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, nil
+	}
+	return newest.Id, err
+}
+
+// isStored reports whether the event is still in the capped collection.
+func (eb *EventBackend) isStored(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	if id.IsZero() {
+		return false, nil
+	}
+	count, err := eb.Collection.CountDocuments(ctx, bson.M{"_id": id})
+	return count > 0, err
+}
+
+func endTimeUnix(endTime time.Time) int64 {
+	if endTime.IsZero() {
+		return 0
+	}
+	return endTime.Unix()
+}
@@ -0,0 +1,102 @@
+// Package migration applies the schema and index changes the repositories
+// depend on when the service starts.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Migration is one change to the database. Replicas may start at the same
+// time and run the same migration, so Up must be idempotent.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database) error
+}
+
+type MigrationEntityMongo struct {
+	Version     int    `bson:"_id"`
+	Description string `bson:"description"`
+	AppliedAt   int64  `bson:"applied_at"`
+}
+
+type Migrator struct {
+	Database   *mongo.Database
+	Collection *mongo.Collection
+	Migrations []Migration
+}
+
+func NewMigrator(database *mongo.Database, migrations []Migration) *Migrator {
+	return &Migrator{
+		Database:   database,
+		Collection: database.Collection("schema_migrations"),
+		Migrations: migrations,
+	}
+}
+
+// Run applies the migrations that were not applied yet, in version order,
+// and records each one in schema_migrations.
+func (m *Migrator) Run(ctx context.Context) error {
+	for i, migration := range m.Migrations {
+		if i > 0 && migration.Version <= m.Migrations[i-1].Version {
+			return fmt.Errorf("migration %d must come after migration %d",
+				migration.Version, m.Migrations[i-1].Version)
+		}
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.Migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.Database); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := MigrationEntityMongo{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().Unix(),
+		}
+		// A duplicate key means another replica applied it meanwhile.
+		if _, err := m.Collection.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+
+		logger.Info("Applied migration",
+			zap.Int("version", migration.Version), zap.String("description", migration.Description))
+	}
+
+	return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	cursor, err := m.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("finding applied migrations: %w", err)
+	}
+
+	var records []MigrationEntityMongo
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("finding applied migrations: %w", err)
+	}
+
+	applied := make(map[int]bool, len(records))
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,150 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMigrator_Run(t *testing.T) {
+	ctx := context.Background()
+	database := mongotest.NewDatabase(t)
+
+	for run := 1; run <= 2; run++ {
+		if err := NewMigrator(database, Migrations).Run(ctx); err != nil {
+			t.Fatalf("Erro ao aplicar as migrações na execução %d: %v", run, err)
+		}
+	}
+
+	count, err := database.Collection("schema_migrations").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("Erro ao contar as migrações aplicadas: %v", err)
+	}
+	if count != int64(len(Migrations)) {
+		t.Errorf("Esperado %d migrações registradas, mas obteve %d", len(Migrations), count)
+	}
+
+	expected := map[string][]string{
+		"auctions":   {"_id_", "auction_text", "status_end_time", "relisted_from"},
+		"bids":       {"_id_", "auction_id_amount_timestamp", "auction_id_timestamp", "user_id"},
+		"proxy_bids": {"_id_", "auction_id_user_id"},
+		"users":      {"_id_", "email_live"},
+	}
+	for collection, names := range expected {
+		specs, err := database.Collection(collection).Indexes().ListSpecifications(ctx)
+		if err != nil {
+			t.Fatalf("Erro ao listar os índices de %s: %v", collection, err)
+		}
+		if len(specs) != len(names) {
+			t.Errorf("Esperado %d índices em %s, mas obteve %d", len(names), collection, len(specs))
+			continue
+		}
+		for i, spec := range specs {
+			if spec.Name != names[i] {
+				t.Errorf("Esperado índice %s em %s, mas obteve %s", names[i], collection, spec.Name)
+			}
+		}
+	}
+}
+
+func TestMigrations_MarkExistingUsersForTheUniqueEmailIndex(t *testing.T) {
+	ctx := context.Background()
+	database := mongotest.NewDatabase(t)
+	users := database.Collection("users")
+
+	if _, err := users.InsertMany(ctx, []interface{}{
+		bson.M{"_id": "live", "email": "maria@example.com"},
+		bson.M{"_id": "deleted", "email": "maria@example.com", "deleted_at": int64(1700000000)},
+	}); err != nil {
+		t.Fatalf("Erro ao inserir usuários: %v", err)
+	}
+
+	if err := NewMigrator(database, Migrations).Run(ctx); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+
+	for id, deleted := range map[string]bool{"live": false, "deleted": true} {
+		var user bson.M
+		if err := users.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+			t.Fatalf("Erro ao buscar o usuário %s: %v", id, err)
+		}
+		if user["deleted"] != deleted {
+			t.Errorf("Esperado deleted=%v para o usuário %s, mas obteve %v", deleted, id, user["deleted"])
+		}
+	}
+
+	_, err := users.InsertOne(ctx, bson.M{"_id": "duplicate", "email": "maria@example.com", "deleted": false})
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("Esperado erro de chave duplicada para outro usuário ativo com o mesmo email, mas obteve %v", err)
+	}
+}
+
+func TestMigrator_RunSkipsAppliedMigrations(t *testing.T) {
+	ctx := context.Background()
+	database := mongotest.NewDatabase(t)
+
+	applied := 0
+	migrations := []Migration{{
+		Version:     1,
+		Description: "count runs",
+		Up: func(context.Context, *mongo.Database) error {
+			applied++
+			return nil
+		},
+	}}
+
+	for run := 0; run < 2; run++ {
+		if err := NewMigrator(database, migrations).Run(ctx); err != nil {
+			t.Fatalf("Erro ao aplicar as migrações: %v", err)
+		}
+	}
+
+	if applied != 1 {
+		t.Errorf("Esperado que a migração fosse aplicada uma vez, mas foi aplicada %d vezes", applied)
+	}
+}
+
+func TestMigrator_RunStopsAtFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	database := mongotest.NewDatabase(t)
+
+	failure := errors.New("falha")
+	applied := false
+	migrations := []Migration{
+		{Version: 1, Description: "fail", Up: func(context.Context, *mongo.Database) error { return failure }},
+		{Version: 2, Description: "never runs", Up: func(context.Context, *mongo.Database) error {
+			applied = true
+			return nil
+		}},
+	}
+
+	if err := NewMigrator(database, migrations).Run(ctx); !errors.Is(err, failure) {
+		t.Fatalf("Esperado erro da migração, mas obteve %v", err)
+	}
+	if applied {
+		t.Error("Esperado que as migrações seguintes não fossem aplicadas")
+	}
+
+	count, err := database.Collection("schema_migrations").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("Erro ao contar as migrações aplicadas: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Esperado nenhuma migração registrada, mas obteve %d", count)
+	}
+}
+
+func TestMigrator_RunRejectsUnorderedMigrations(t *testing.T) {
+	migrations := []Migration{
+		{Version: 2, Description: "second", Up: func(context.Context, *mongo.Database) error { return nil }},
+		{Version: 1, Description: "first", Up: func(context.Context, *mongo.Database) error { return nil }},
+	}
+
+	if err := NewMigrator(mongotest.NewDatabase(t), migrations).Run(context.Background()); err == nil {
+		t.Error("Esperado erro para migrações fora de ordem")
+	}
+}
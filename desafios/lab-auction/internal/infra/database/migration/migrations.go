@@ -0,0 +1,153 @@
+package migration
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexNotFoundCode is returned when dropping an index that does not exist.
+const indexNotFoundCode = 27
+
+// Migrations are the migrations of the service, in the order they are
+// applied. Applied migrations must not be changed; add a new one instead.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index auctions for search and closing",
+		Up: createIndexes("auctions",
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "product_name", Value: "text"},
+					{Key: "description", Value: "text"},
+				},
+				Options: options.Index().
+					SetName("auction_text").
+					SetWeights(bson.D{{Key: "product_name", Value: 5}, {Key: "description", Value: 1}}),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "end_time", Value: 1}},
+				Options: options.Index().SetName("status_end_time"),
+			},
+		),
+	},
+	{
+		Version:     2,
+		Description: "index bids by auction and user",
+		Up: createIndexes("bids",
+			// Winning and ranked bids.
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "auction_id", Value: 1},
+					{Key: "amount", Value: -1},
+					{Key: "timestamp", Value: 1},
+				},
+				Options: options.Index().SetName("auction_id_amount_timestamp"),
+			},
+			// Bid listings, newest first.
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "auction_id", Value: 1}, {Key: "timestamp", Value: -1}},
+				Options: options.Index().SetName("auction_id_timestamp"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetName("user_id"),
+			},
+		),
+	},
+	{
+		Version:     3,
+		Description: "allow one proxy bid per user and auction",
+		Up: createIndexes("proxy_bids",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "auction_id", Value: 1}, {Key: "user_id", Value: 1}},
+				Options: options.Index().SetName("auction_id_user_id").SetUnique(true),
+			},
+		),
+	},
+	{
+		Version:     4,
+		Description: "index users by email",
+		// Not unique: deleted users keep their email.
+		Up: createIndexes("users",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email"),
+			},
+		),
+	},
+	{
+		Version:     5,
+		Description: "allow one live user per email",
+		Up:          uniqueLiveUserEmail,
+	},
+	{
+		Version:     6,
+		Description: "allow one relist per auction",
+		// Sparse: only relisted auctions have relisted_from.
+		Up: createIndexes("auctions",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "relisted_from", Value: 1}},
+				Options: options.Index().SetName("relisted_from").SetUnique(true).SetSparse(true),
+			},
+		),
+	},
+}
+
+// uniqueLiveUserEmail marks every user as deleted or not and replaces the
+// email index with one that is unique among live users, so concurrent sign
+// ups with the same email cannot both succeed. It fails if live users
+// already share an email; those must be fixed by hand.
+func uniqueLiveUserEmail(ctx context.Context, database *mongo.Database) error {
+	users := database.Collection("users")
+
+	if _, err := users.UpdateMany(ctx,
+		bson.M{"deleted_at": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"deleted": true}}); err != nil {
+		return err
+	}
+	if _, err := users.UpdateMany(ctx,
+		bson.M{"deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted": false}}); err != nil {
+		return err
+	}
+
+	// The old index has the same key, so it goes first.
+	if err := dropIndex(ctx, users, "email"); err != nil {
+		return err
+	}
+
+	return createIndexes("users",
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().
+				SetName("email_live").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"deleted": false}),
+		},
+	)(ctx, database)
+}
+
+// createIndexes returns a migration step that creates indexes on a
+// collection. Creating an index that already exists does nothing.
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, database *mongo.Database) error {
+		_, err := database.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
+
+// dropIndex drops an index. Dropping an index that no longer exists does
+// nothing, so the migration can run again after a partial failure.
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == indexNotFoundCode {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,289 @@
+package mongotest
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (s *Server) aggregate(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	pipeline, ok := lookup(command, "pipeline").(bson.A)
+	if !ok {
+		return nil, badValue("pipeline must be an array")
+	}
+
+	coll := s.collection(dbName, collName, false)
+	var documents []bson.D
+	if coll != nil {
+		for _, document := range coll.documents {
+			documents = append(documents, clone(document).(bson.D))
+		}
+	}
+
+	var text *textSearch
+	for n, value := range pipeline {
+		stage, ok := value.(bson.D)
+		if !ok || len(stage) != 1 {
+			return nil, badValue("each pipeline stage must be an object with exactly one field")
+		}
+
+		var err *commandError
+		switch name, argument := stage[0].Key, stage[0].Value; name {
+		case "$match":
+			filter, ok := argument.(bson.D)
+			if !ok {
+				return nil, badValue("$match must be an object")
+			}
+			if lookup(filter, "$text") != nil && n != 0 {
+				return nil, badValue("$match with $text is only allowed as the first pipeline stage")
+			}
+			var m *matcher
+			if m, err = newMatcher(coll, filter); err == nil {
+				documents, err = filterDocuments(documents, m)
+				if m.text != nil {
+					text = m.text
+				}
+			}
+		case "$sort":
+			spec, ok := argument.(bson.D)
+			if !ok {
+				return nil, badValue("$sort must be an object")
+			}
+			err = sortDocuments(documents, spec, text)
+		case "$skip", "$limit":
+			count, ok := toInt64(argument)
+			if !ok || count < 0 || (name == "$limit" && count == 0) {
+				return nil, badValue("invalid argument to %s: %v", name, argument)
+			}
+			if name == "$skip" {
+				documents = page(documents, count, 0)
+			} else {
+				documents = page(documents, 0, count)
+			}
+		case "$group":
+			spec, ok := argument.(bson.D)
+			if !ok {
+				return nil, badValue("$group must be an object")
+			}
+			documents, err = group(documents, spec)
+		case "$replaceRoot":
+			spec, ok := argument.(bson.D)
+			if !ok {
+				return nil, badValue("$replaceRoot must be an object")
+			}
+			documents, err = replaceRoot(documents, lookup(spec, "newRoot"))
+		case "$project":
+			spec, ok := argument.(bson.D)
+			if !ok {
+				return nil, badValue("$project must be an object")
+			}
+			for i, document := range documents {
+				if documents[i], err = project(document, spec, text); err != nil {
+					break
+				}
+			}
+		case "$count":
+			field, ok := argument.(string)
+			if !ok || field == "" {
+				return nil, badValue("$count needs a field name")
+			}
+			if len(documents) > 0 {
+				documents = []bson.D{{{Key: field, Value: int32(len(documents))}}}
+			}
+		default:
+			return nil, badValue("Unrecognized pipeline stage name: '%s'", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cursorReply(dbName, collName, documents), nil
+}
+
+func filterDocuments(documents []bson.D, m *matcher) ([]bson.D, *commandError) {
+	var matched []bson.D
+	for _, document := range documents {
+		ok, err := m.matches(document)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, document)
+		}
+	}
+
+	return matched, nil
+}
+
+// group implements $group with the $sum, $first, $last, $min, $max and
+// $push accumulators. Groups come out in the order they were first seen.
+// Like MongoDB, the _id may appear anywhere in the specification.
+func group(documents []bson.D, spec bson.D) ([]bson.D, *commandError) {
+	var groupId interface{}
+	var accumulators bson.D
+	hasId := false
+	for _, field := range spec {
+		if field.Key == "_id" {
+			groupId, hasId = field.Value, true
+			continue
+		}
+		accumulators = append(accumulators, field)
+	}
+	if !hasId {
+		return nil, badValue("a group specification must include an _id")
+	}
+
+	for _, field := range accumulators {
+		accumulator, ok := field.Value.(bson.D)
+		if !ok || len(accumulator) != 1 {
+			return nil, badValue("the field '%s' must be an accumulator object", field.Key)
+		}
+		switch accumulator[0].Key {
+		case "$sum", "$first", "$last", "$min", "$max", "$push":
+		default:
+			return nil, badValue("unknown group operator '%s'", accumulator[0].Key)
+		}
+	}
+
+	var groups []bson.D
+	for _, document := range documents {
+		id, err := evaluate(document, groupId)
+		if err != nil {
+			return nil, err
+		}
+
+		var current bson.D
+		for _, existing := range groups {
+			if equal(existing[0].Value, id) {
+				current = existing
+				break
+			}
+		}
+
+		if current == nil {
+			current = bson.D{{Key: "_id", Value: id}}
+			for _, field := range accumulators {
+				accumulator := field.Value.(bson.D)[0]
+				value, err := evaluate(document, accumulator.Value)
+				if err != nil {
+					return nil, err
+				}
+				current = append(current, bson.E{Key: field.Key, Value: initial(accumulator.Key, value)})
+			}
+			groups = append(groups, current)
+			continue
+		}
+
+		for n, field := range accumulators {
+			accumulator := field.Value.(bson.D)[0]
+			value, err := evaluate(document, accumulator.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			accumulated := &current[n+1]
+			switch accumulator.Key {
+			case "$sum":
+				if isNumber(value) {
+					accumulated.Value = add(accumulated.Value, value)
+				}
+			case "$last":
+				accumulated.Value = value
+			case "$min":
+				if value != nil && (accumulated.Value == nil || compare(value, accumulated.Value) < 0) {
+					accumulated.Value = value
+				}
+			case "$max":
+				if value != nil && compare(value, accumulated.Value) > 0 {
+					accumulated.Value = value
+				}
+			case "$push":
+				accumulated.Value = append(accumulated.Value.(bson.A), value)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// initial is the value an accumulator starts from with the first document
+// of a group.
+func initial(accumulator string, value interface{}) interface{} {
+	switch accumulator {
+	case "$sum":
+		if !isNumber(value) {
+			return int32(0)
+		}
+	case "$push":
+		return bson.A{value}
+	}
+
+	return value
+}
+
+func replaceRoot(documents []bson.D, newRoot interface{}) ([]bson.D, *commandError) {
+	replaced := make([]bson.D, len(documents))
+	for i, document := range documents {
+		value, err := evaluate(document, newRoot)
+		if err != nil {
+			return nil, err
+		}
+		root, ok := value.(bson.D)
+		if !ok {
+			return nil, badValue("'newRoot' expression must evaluate to an object, but resulting value was: %v", value)
+		}
+		replaced[i] = root
+	}
+
+	return replaced, nil
+}
+
+// evaluate computes an aggregation expression: a field path such as
+// "$amount", the "$$ROOT" variable, an object of expressions or a literal.
+// Expression operators are not supported.
+func evaluate(document bson.D, expression interface{}) (interface{}, *commandError) {
+	switch e := expression.(type) {
+	case string:
+		switch {
+		case e == "$$ROOT" || e == "$$CURRENT":
+			return clone(document), nil
+		case strings.HasPrefix(e, "$$"):
+			return nil, badValue("unsupported variable: %s", e)
+		case strings.HasPrefix(e, "$"):
+			values := lookupPath(document, strings.Split(e[1:], "."))
+			if len(values) == 0 {
+				return nil, nil
+			}
+			return clone(values[0]), nil
+		}
+		return e, nil
+	case bson.D:
+		if len(e) > 0 && strings.HasPrefix(e[0].Key, "$") {
+			return nil, badValue("unsupported expression operator: %s", e[0].Key)
+		}
+		evaluated := make(bson.D, len(e))
+		for i, element := range e {
+			value, err := evaluate(document, element.Value)
+			if err != nil {
+				return nil, err
+			}
+			evaluated[i] = bson.E{Key: element.Key, Value: value}
+		}
+		return evaluated, nil
+	case bson.A:
+		evaluated := make(bson.A, len(e))
+		for i, item := range e {
+			value, err := evaluate(document, item)
+			if err != nil {
+				return nil, err
+			}
+			evaluated[i] = value
+		}
+		return evaluated, nil
+	}
+
+	return expression, nil
+}
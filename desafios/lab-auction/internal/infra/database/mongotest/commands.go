@@ -0,0 +1,556 @@
+package mongotest
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type database struct {
+	collections map[string]*collection
+}
+
+type collection struct {
+	documents []bson.D
+	indexes   []*index
+}
+
+// commandError is a failed command, reported with the code and code name
+// MongoDB uses for the same failure.
+type commandError struct {
+	Code     int32
+	CodeName string
+	Message  string
+}
+
+func (e *commandError) Error() string {
+	return e.Message
+}
+
+func badValue(format string, args ...interface{}) *commandError {
+	return &commandError{Code: 2, CodeName: "BadValue", Message: fmt.Sprintf(format, args...)}
+}
+
+func internalError(err error) *commandError {
+	return &commandError{Code: 1, CodeName: "InternalError", Message: err.Error()}
+}
+
+func namespaceNotFound(namespace string) *commandError {
+	return &commandError{Code: 26, CodeName: "NamespaceNotFound", Message: "ns does not exist: " + namespace}
+}
+
+func errorReply(err *commandError) bson.D {
+	return bson.D{
+		{Key: "ok", Value: 0.0},
+		{Key: "errmsg", Value: err.Message},
+		{Key: "code", Value: err.Code},
+		{Key: "codeName", Value: err.CodeName},
+	}
+}
+
+type handler func(s *Server, dbName string, command bson.D) (bson.D, *commandError)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"hello":           (*Server).hello,
+		"isMaster":        (*Server).hello,
+		"ismaster":        (*Server).hello,
+		"ping":            okCommand,
+		"endSessions":     okCommand,
+		"buildInfo":       buildInfo,
+		"insert":          (*Server).insert,
+		"update":          (*Server).update,
+		"delete":          (*Server).delete,
+		"find":            (*Server).find,
+		"getMore":         getMore,
+		"killCursors":     killCursors,
+		"aggregate":       (*Server).aggregate,
+		"count":           (*Server).count,
+		"create":          (*Server).create,
+		"drop":            (*Server).drop,
+		"dropDatabase":    (*Server).dropDatabase,
+		"listCollections": (*Server).listCollections,
+		"createIndexes":   (*Server).createIndexes,
+		"listIndexes":     (*Server).listIndexes,
+		"dropIndexes":     (*Server).dropIndexes,
+	}
+}
+
+// run executes a command and returns its reply.
+func (s *Server) run(dbName string, command bson.D) bson.D {
+	if len(command) == 0 {
+		return errorReply(badValue("empty command"))
+	}
+
+	name := command[0].Key
+	handle, ok := handlers[name]
+	if !ok {
+		return errorReply(&commandError{
+			Code: 59, CodeName: "CommandNotFound", Message: fmt.Sprintf("no such command: '%s'", name)})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := handle(s, dbName, command)
+	if err != nil {
+		return errorReply(err)
+	}
+
+	return append(reply, bson.E{Key: "ok", Value: 1.0})
+}
+
+func (s *Server) hello(dbName string, command bson.D) (bson.D, *commandError) {
+	return bson.D{
+		{Key: "helloOk", Value: true},
+		{Key: "ismaster", Value: true},
+		{Key: "isWritablePrimary", Value: true},
+		{Key: "maxBsonObjectSize", Value: int32(16 * 1024 * 1024)},
+		{Key: "maxMessageSizeBytes", Value: int32(maxMessageSize)},
+		{Key: "maxWriteBatchSize", Value: int32(100000)},
+		{Key: "localTime", Value: primitive.NewDateTimeFromTime(time.Now())},
+		{Key: "minWireVersion", Value: int32(0)},
+		{Key: "maxWireVersion", Value: int32(17)},
+		{Key: "readOnly", Value: false},
+	}, nil
+}
+
+func okCommand(s *Server, dbName string, command bson.D) (bson.D, *commandError) {
+	return bson.D{}, nil
+}
+
+func buildInfo(s *Server, dbName string, command bson.D) (bson.D, *commandError) {
+	return bson.D{
+		{Key: "version", Value: "6.0.0"},
+		{Key: "versionArray", Value: bson.A{int32(6), int32(0), int32(0), int32(0)}},
+	}, nil
+}
+
+// getMore always fails: every cursor is returned whole in its first batch,
+// so no cursor is ever left open.
+func getMore(s *Server, dbName string, command bson.D) (bson.D, *commandError) {
+	return nil, &commandError{Code: 43, CodeName: "CursorNotFound", Message: "cursor not found"}
+}
+
+func killCursors(s *Server, dbName string, command bson.D) (bson.D, *commandError) {
+	return bson.D{
+		{Key: "cursorsKilled", Value: bson.A{}},
+		{Key: "cursorsNotFound", Value: lookup(command, "cursors")},
+		{Key: "cursorsAlive", Value: bson.A{}},
+		{Key: "cursorsUnknown", Value: bson.A{}},
+	}, nil
+}
+
+// collection returns the named collection, creating it when create is set
+// and returning nil otherwise.
+func (s *Server) collection(dbName, name string, create bool) *collection {
+	db, ok := s.databases[dbName]
+	if !ok {
+		if !create {
+			return nil
+		}
+		db = &database{collections: make(map[string]*collection)}
+		s.databases[dbName] = db
+	}
+
+	coll, ok := db.collections[name]
+	if !ok && create {
+		coll = &collection{}
+		db.collections[name] = coll
+	}
+
+	return coll
+}
+
+func cursorReply(dbName, collName string, documents []bson.D) bson.D {
+	batch := make(bson.A, len(documents))
+	for i, document := range documents {
+		batch[i] = document
+	}
+
+	return bson.D{{Key: "cursor", Value: bson.D{
+		{Key: "firstBatch", Value: batch},
+		{Key: "id", Value: int64(0)},
+		{Key: "ns", Value: dbName + "." + collName},
+	}}}
+}
+
+func (s *Server) insert(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	documents, _ := lookup(command, "documents").(bson.A)
+	coll := s.collection(dbName, collName, true)
+
+	var inserted int32
+	var writeErrors bson.A
+	for i, value := range documents {
+		document, ok := value.(bson.D)
+		if !ok {
+			return nil, badValue("documents must be objects")
+		}
+		document = clone(document).(bson.D)
+		if lookup(document, "_id") == nil {
+			document = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, document...)
+		}
+
+		if err := coll.checkUnique(dbName, collName, document, -1); err != nil {
+			writeErrors = append(writeErrors, writeError(i, err))
+			if ordered(command) {
+				break
+			}
+			continue
+		}
+
+		coll.documents = append(coll.documents, document)
+		inserted++
+	}
+
+	reply := bson.D{{Key: "n", Value: inserted}}
+	if len(writeErrors) > 0 {
+		reply = append(reply, bson.E{Key: "writeErrors", Value: writeErrors})
+	}
+	return reply, nil
+}
+
+func (s *Server) update(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	statements, _ := lookup(command, "updates").(bson.A)
+	coll := s.collection(dbName, collName, true)
+
+	var matched, modified int32
+	var upserted, writeErrors bson.A
+	for i, value := range statements {
+		statement, ok := value.(bson.D)
+		if !ok {
+			return nil, badValue("updates must be objects")
+		}
+
+		n, nModified, upsertedId, err := coll.updateStatement(dbName, collName, statement)
+		if err != nil {
+			if err.Code != 11000 && err.Code != 66 {
+				return nil, err
+			}
+			writeErrors = append(writeErrors, writeError(i, err))
+			if ordered(command) {
+				break
+			}
+			continue
+		}
+
+		matched += n
+		modified += nModified
+		if upsertedId != nil {
+			upserted = append(upserted, bson.D{{Key: "index", Value: int32(i)}, {Key: "_id", Value: upsertedId}})
+		}
+	}
+
+	reply := bson.D{{Key: "n", Value: matched + int32(len(upserted))}, {Key: "nModified", Value: modified}}
+	if len(upserted) > 0 {
+		reply = append(reply, bson.E{Key: "upserted", Value: upserted})
+	}
+	if len(writeErrors) > 0 {
+		reply = append(reply, bson.E{Key: "writeErrors", Value: writeErrors})
+	}
+	return reply, nil
+}
+
+// updateStatement applies one statement of an update command and returns
+// how many documents matched and changed, and the id of the document it
+// inserted, if any.
+func (c *collection) updateStatement(
+	dbName, collName string, statement bson.D) (int32, int32, interface{}, *commandError) {
+	filter, err := asDocument(lookup(statement, "q"), "q")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	modifier, ok := lookup(statement, "u").(bson.D)
+	if !ok {
+		return 0, 0, nil, badValue("u must be an object; pipeline updates are not supported")
+	}
+	multi, _ := lookup(statement, "multi").(bool)
+	upsert, _ := lookup(statement, "upsert").(bool)
+
+	m, err := newMatcher(c, filter)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	var matched, modified int32
+	for i, document := range c.documents {
+		ok, err := m.matches(document)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		updated, err := applyUpdate(document, modifier, false)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if err := c.checkUnique(dbName, collName, updated, i); err != nil {
+			return 0, 0, nil, err
+		}
+
+		matched++
+		if compare(bson.D(updated), document) != 0 || len(updated) != len(document) {
+			c.documents[i] = updated
+			modified++
+		}
+		if !multi {
+			break
+		}
+	}
+
+	if matched > 0 || !upsert {
+		return matched, modified, nil, nil
+	}
+
+	document, err := applyUpdate(upsertSeed(filter), modifier, true)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if lookup(document, "_id") == nil {
+		document = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, document...)
+	}
+	if err := c.checkUnique(dbName, collName, document, -1); err != nil {
+		return 0, 0, nil, err
+	}
+
+	c.documents = append(c.documents, document)
+	return 0, 0, lookup(document, "_id"), nil
+}
+
+func (s *Server) delete(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	statements, _ := lookup(command, "deletes").(bson.A)
+	coll := s.collection(dbName, collName, false)
+
+	var deleted int32
+	for _, value := range statements {
+		statement, ok := value.(bson.D)
+		if !ok {
+			return nil, badValue("deletes must be objects")
+		}
+		if coll == nil {
+			continue
+		}
+
+		filter, err := asDocument(lookup(statement, "q"), "q")
+		if err != nil {
+			return nil, err
+		}
+		limit, _ := toInt64(lookup(statement, "limit"))
+
+		m, err := newMatcher(coll, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := coll.documents[:0:0]
+		for _, document := range coll.documents {
+			ok, err := m.matches(document)
+			if err != nil {
+				return nil, err
+			}
+			if ok && (limit == 0 || deleted == 0) {
+				deleted++
+				continue
+			}
+			kept = append(kept, document)
+		}
+		coll.documents = kept
+	}
+
+	return bson.D{{Key: "n", Value: deleted}}, nil
+}
+
+func (s *Server) find(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	coll := s.collection(dbName, collName, false)
+	if coll == nil {
+		return cursorReply(dbName, collName, nil), nil
+	}
+
+	filter, err := asDocument(lookup(command, "filter"), "filter")
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMatcher(coll, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []bson.D
+	for _, document := range coll.documents {
+		ok, err := m.matches(document)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			documents = append(documents, document)
+		}
+	}
+
+	if sortSpec, ok := lookup(command, "sort").(bson.D); ok {
+		if err := sortDocuments(documents, sortSpec, m.text); err != nil {
+			return nil, err
+		}
+	}
+
+	skip, _ := toInt64(lookup(command, "skip"))
+	limit, _ := toInt64(lookup(command, "limit"))
+	documents = page(documents, skip, limit)
+
+	results := make([]bson.D, len(documents))
+	for i, document := range documents {
+		results[i] = clone(document).(bson.D)
+	}
+	if projection, ok := lookup(command, "projection").(bson.D); ok {
+		for i, document := range documents {
+			projected, err := project(document, projection, m.text)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = projected
+		}
+	}
+
+	return cursorReply(dbName, collName, results), nil
+}
+
+func (s *Server) count(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	coll := s.collection(dbName, collName, false)
+	if coll == nil {
+		return bson.D{{Key: "n", Value: int32(0)}}, nil
+	}
+
+	filter, err := asDocument(lookup(command, "query"), "query")
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMatcher(coll, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []bson.D
+	for _, document := range coll.documents {
+		ok, err := m.matches(document)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			documents = append(documents, document)
+		}
+	}
+
+	skip, _ := toInt64(lookup(command, "skip"))
+	limit, _ := toInt64(lookup(command, "limit"))
+	return bson.D{{Key: "n", Value: int32(len(page(documents, skip, limit)))}}, nil
+}
+
+func (s *Server) create(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	if s.collection(dbName, collName, false) != nil {
+		return nil, &commandError{
+			Code: 48, CodeName: "NamespaceExists", Message: "Collection already exists. NS: " + dbName + "." + collName}
+	}
+
+	s.collection(dbName, collName, true)
+	return bson.D{}, nil
+}
+
+func (s *Server) drop(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	if s.collection(dbName, collName, false) == nil {
+		return nil, namespaceNotFound(dbName + "." + collName)
+	}
+
+	delete(s.databases[dbName].collections, collName)
+	return bson.D{{Key: "ns", Value: dbName + "." + collName}}, nil
+}
+
+func (s *Server) dropDatabase(dbName string, command bson.D) (bson.D, *commandError) {
+	delete(s.databases, dbName)
+	return bson.D{}, nil
+}
+
+func (s *Server) listCollections(dbName string, command bson.D) (bson.D, *commandError) {
+	filter, err := asDocument(lookup(command, "filter"), "filter")
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMatcher(nil, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []bson.D
+	if db, ok := s.databases[dbName]; ok {
+		for name := range db.collections {
+			info := bson.D{
+				{Key: "name", Value: name},
+				{Key: "type", Value: "collection"},
+				{Key: "options", Value: bson.D{}},
+				{Key: "info", Value: bson.D{{Key: "readOnly", Value: false}}},
+			}
+			ok, err := m.matches(info)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				collections = append(collections, info)
+			}
+		}
+	}
+
+	return cursorReply(dbName, "$cmd.listCollections", collections), nil
+}
+
+func ordered(command bson.D) bool {
+	value, ok := lookup(command, "ordered").(bool)
+	return !ok || value
+}
+
+func writeError(i int, err *commandError) bson.D {
+	return bson.D{
+		{Key: "index", Value: int32(i)},
+		{Key: "code", Value: err.Code},
+		{Key: "errmsg", Value: err.Message},
+	}
+}
+
+// page applies skip and limit; a negative limit is a single batch of that
+// size.
+func page(documents []bson.D, skip, limit int64) []bson.D {
+	if skip >= int64(len(documents)) {
+		return nil
+	}
+	documents = documents[skip:]
+
+	if limit < 0 {
+		limit = -limit
+	}
+	if limit > 0 && limit < int64(len(documents)) {
+		documents = documents[:limit]
+	}
+
+	return documents
+}
+
+func asDocument(value interface{}, field string) (bson.D, *commandError) {
+	switch document := value.(type) {
+	case nil:
+		return bson.D{}, nil
+	case bson.D:
+		return document, nil
+	default:
+		return nil, badValue("%s must be an object", field)
+	}
+}
@@ -0,0 +1,418 @@
+package mongotest
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type index struct {
+	name    string
+	key     bson.D
+	unique  bool
+	sparse  bool
+	partial bson.D
+	weights bson.D
+	spec    bson.D
+}
+
+var idIndex = &index{
+	name: "_id_",
+	key:  bson.D{{Key: "_id", Value: int32(1)}},
+	spec: bson.D{
+		{Key: "v", Value: int32(2)},
+		{Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}},
+		{Key: "name", Value: "_id_"},
+	},
+	unique: true,
+}
+
+func (i *index) isText() bool {
+	for _, element := range i.key {
+		if element.Value == "text" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameOptions reports whether two indexes with the same key would behave the
+// same, so creating one when the other exists is a no-op.
+func (i *index) sameOptions(other *index) bool {
+	return i.unique == other.unique &&
+		i.sparse == other.sparse &&
+		compare(i.partial, other.partial) == 0 &&
+		compare(i.weights, other.weights) == 0
+}
+
+func parseIndex(value interface{}) (*index, *commandError) {
+	spec, ok := value.(bson.D)
+	if !ok {
+		return nil, badValue("index specifications must be objects")
+	}
+
+	i := &index{spec: append(bson.D{{Key: "v", Value: int32(2)}}, spec...)}
+	for _, element := range spec {
+		switch element.Key {
+		case "key":
+			i.key, ok = element.Value.(bson.D)
+			if !ok || len(i.key) == 0 {
+				return nil, badValue("index key must be a nonempty object")
+			}
+		case "name":
+			i.name, _ = element.Value.(string)
+		case "unique":
+			i.unique = truthy(element.Value)
+		case "partialFilterExpression":
+			i.partial, ok = element.Value.(bson.D)
+			if !ok {
+				return nil, badValue("partialFilterExpression must be an object")
+			}
+			if err := validatePartial(i.partial); err != nil {
+				return nil, err
+			}
+		case "weights":
+			i.weights, ok = element.Value.(bson.D)
+			if !ok {
+				return nil, badValue("weights must be an object")
+			}
+		case "sparse":
+			i.sparse = truthy(element.Value)
+		case "background", "default_language", "language_override", "textIndexVersion", "v":
+		default:
+			return nil, badValue("unsupported index option: %s", element.Key)
+		}
+	}
+	if i.name == "" {
+		return nil, badValue("index specifications must have a name")
+	}
+
+	for _, element := range i.key {
+		direction, isInt := toInt64(element.Value)
+		if element.Value != "text" && (!isInt || (direction != 1 && direction != -1)) {
+			return nil, badValue("unsupported index key: {%s: %v}", element.Key, element.Value)
+		}
+	}
+
+	return i, nil
+}
+
+// validatePartial rejects the partial filter expressions MongoDB rejects,
+// such as {$exists: false} or $ne.
+func validatePartial(filter bson.D) *commandError {
+	for _, element := range filter {
+		switch element.Key {
+		case "$and", "$or":
+			clauses, _ := element.Value.(bson.A)
+			for _, clause := range clauses {
+				document, ok := clause.(bson.D)
+				if !ok {
+					return badValue("%s entries must be objects", element.Key)
+				}
+				if err := validatePartial(document); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		operators, ok := element.Value.(bson.D)
+		if !ok || len(operators) == 0 || !strings.HasPrefix(operators[0].Key, "$") {
+			continue
+		}
+		for _, operator := range operators {
+			switch operator.Key {
+			case "$eq", "$gt", "$gte", "$lt", "$lte", "$in":
+				continue
+			case "$exists":
+				if truthy(operator.Value) {
+					continue
+				}
+			}
+			return &commandError{Code: 67, CodeName: "CannotCreateIndex", Message: fmt.Sprintf(
+				"Expression not supported in partial index: {%s: {%s: %v}}", element.Key, operator.Key, operator.Value)}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) createIndexes(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	specs, ok := lookup(command, "indexes").(bson.A)
+	if !ok || len(specs) == 0 {
+		return nil, badValue("indexes must be a nonempty array")
+	}
+
+	created := s.collection(dbName, collName, false) == nil
+	coll := s.collection(dbName, collName, true)
+	before := int32(len(coll.indexes) + 1)
+
+	for _, value := range specs {
+		i, err := parseIndex(value)
+		if err != nil {
+			return nil, err
+		}
+
+		exists := false
+		for _, existing := range coll.allIndexes() {
+			sameKey := compare(existing.key, i.key) == 0
+			switch {
+			case existing.name == i.name && sameKey && existing.sameOptions(i):
+				exists = true
+			case existing.name == i.name:
+				return nil, &commandError{Code: 86, CodeName: "IndexKeySpecsConflict", Message: fmt.Sprintf(
+					"An existing index has the same name as the requested index but a different key or options: %s", i.name)}
+			case sameKey || (existing.isText() && i.isText()):
+				return nil, &commandError{Code: 85, CodeName: "IndexOptionsConflict", Message: fmt.Sprintf(
+					"An equivalent index already exists with a different name: %s", existing.name)}
+			}
+		}
+		if exists {
+			continue
+		}
+
+		if i.unique {
+			for position, document := range coll.documents {
+				if err := coll.checkIndex(dbName, collName, i, document, position); err != nil {
+					return nil, err
+				}
+			}
+		}
+		coll.indexes = append(coll.indexes, i)
+	}
+
+	return bson.D{
+		{Key: "createdCollectionAutomatically", Value: created},
+		{Key: "numIndexesBefore", Value: before},
+		{Key: "numIndexesAfter", Value: int32(len(coll.indexes) + 1)},
+	}, nil
+}
+
+func (s *Server) listIndexes(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	coll := s.collection(dbName, collName, false)
+	if coll == nil {
+		return nil, namespaceNotFound(dbName + "." + collName)
+	}
+
+	var specs []bson.D
+	for _, i := range coll.allIndexes() {
+		specs = append(specs, clone(i.spec).(bson.D))
+	}
+
+	return cursorReply(dbName, collName, specs), nil
+}
+
+func (s *Server) dropIndexes(dbName string, command bson.D) (bson.D, *commandError) {
+	collName, _ := command[0].Value.(string)
+	coll := s.collection(dbName, collName, false)
+	if coll == nil {
+		return nil, namespaceNotFound(dbName + "." + collName)
+	}
+
+	before := int32(len(coll.indexes) + 1)
+	switch name := lookup(command, "index"); name {
+	case "*":
+		coll.indexes = nil
+	default:
+		kept := coll.indexes[:0:0]
+		for _, i := range coll.indexes {
+			if i.name != name {
+				kept = append(kept, i)
+			}
+		}
+		if len(kept) == len(coll.indexes) {
+			return nil, &commandError{Code: 27, CodeName: "IndexNotFound", Message: fmt.Sprintf("index not found with name [%v]", name)}
+		}
+		coll.indexes = kept
+	}
+
+	return bson.D{{Key: "nIndexesWas", Value: before}}, nil
+}
+
+func (c *collection) allIndexes() []*index {
+	return append([]*index{idIndex}, c.indexes...)
+}
+
+// checkUnique fails with a duplicate key error when document, stored at
+// position (or new, when position is -1), clashes with another document on
+// a unique index.
+func (c *collection) checkUnique(dbName, collName string, document bson.D, position int) *commandError {
+	for _, i := range c.allIndexes() {
+		if !i.unique {
+			continue
+		}
+		if err := c.checkIndex(dbName, collName, i, document, position); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *collection) checkIndex(dbName, collName string, i *index, document bson.D, position int) *commandError {
+	covered := func(document bson.D) bool {
+		if i.sparse && !hasAnyKey(i, document) {
+			return false
+		}
+		if len(i.partial) == 0 {
+			return true
+		}
+		m := &matcher{filter: i.partial}
+		ok, _ := m.matches(document)
+		return ok
+	}
+	if !covered(document) {
+		return nil
+	}
+
+	key := indexKey(i, document)
+	for other, stored := range c.documents {
+		if other == position || !covered(stored) {
+			continue
+		}
+		if compare(indexKey(i, stored), key) == 0 {
+			var values []string
+			for n, element := range i.key {
+				values = append(values, fmt.Sprintf("%s: %v", element.Key, key[n]))
+			}
+			return &commandError{Code: 11000, CodeName: "DuplicateKey", Message: fmt.Sprintf(
+				"E11000 duplicate key error collection: %s.%s index: %s dup key: { %s }",
+				dbName, collName, i.name, strings.Join(values, ", "))}
+		}
+	}
+
+	return nil
+}
+
+// hasAnyKey reports whether the document has one of the fields of the index;
+// sparse indexes skip documents that have none.
+func hasAnyKey(i *index, document bson.D) bool {
+	for _, element := range i.key {
+		if len(lookupPath(document, strings.Split(element.Key, "."))) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexKey is the value a document has in an index; missing fields are
+// indexed as null.
+func indexKey(i *index, document bson.D) bson.A {
+	key := make(bson.A, len(i.key))
+	for n, element := range i.key {
+		if values := lookupPath(document, strings.Split(element.Key, ".")); len(values) > 0 {
+			key[n] = values[0]
+		}
+	}
+
+	return key
+}
+
+// textSearch is a $text query. Terms match whole words of the text-indexed
+// fields, ignoring case; stemming, stop words and phrases are not supported,
+// and the score is the weighted count of matching words rather than
+// MongoDB's formula.
+type textSearch struct {
+	index    *index
+	terms    []string
+	excluded []string
+}
+
+func newTextSearch(coll *collection, search bson.D) (*textSearch, *commandError) {
+	var textIndex *index
+	if coll != nil {
+		for _, i := range coll.indexes {
+			if i.isText() {
+				textIndex = i
+			}
+		}
+	}
+	if textIndex == nil {
+		return nil, &commandError{Code: 27, CodeName: "IndexNotFound", Message: "text index required for $text query"}
+	}
+
+	query, ok := lookup(search, "$search").(string)
+	if !ok {
+		return nil, badValue("$search needs a string")
+	}
+	if strings.Contains(query, `"`) {
+		return nil, badValue("phrase searches are not supported")
+	}
+
+	text := &textSearch{index: textIndex}
+	for _, word := range strings.Fields(query) {
+		excluded := strings.HasPrefix(word, "-")
+		for _, term := range words(word) {
+			if excluded {
+				text.excluded = append(text.excluded, term)
+			} else {
+				text.terms = append(text.terms, term)
+			}
+		}
+	}
+
+	return text, nil
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (t *textSearch) matches(document bson.D) bool {
+	found := t.found(document)
+	for _, term := range t.excluded {
+		if found[term] > 0 {
+			return false
+		}
+	}
+	for _, term := range t.terms {
+		if found[term] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *textSearch) score(document bson.D) float64 {
+	found := t.found(document)
+
+	var score float64
+	for _, term := range t.terms {
+		score += found[term]
+	}
+	return score
+}
+
+// found weighs the words of the indexed fields of document.
+func (t *textSearch) found(document bson.D) map[string]float64 {
+	found := map[string]float64{}
+	for _, element := range t.index.key {
+		if element.Value != "text" {
+			continue
+		}
+
+		weight := 1.0
+		if value := lookup(t.index.weights, element.Key); isNumber(value) {
+			weight = toFloat(value)
+		}
+
+		for _, value := range lookupPath(document, strings.Split(element.Key, ".")) {
+			if s, ok := value.(string); ok {
+				for _, word := range words(s) {
+					found[word] += weight
+				}
+			}
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,636 @@
+package mongotest
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func lookup(document bson.D, key string) interface{} {
+	for _, element := range document {
+		if element.Key == key {
+			return element.Value
+		}
+	}
+
+	return nil
+}
+
+// clone copies the documents and arrays in value, so that stored documents
+// never share memory with requests or replies.
+func clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		copied := make(bson.D, len(v))
+		for i, element := range v {
+			copied[i] = bson.E{Key: element.Key, Value: clone(element.Value)}
+		}
+		return copied
+	case bson.A:
+		copied := make(bson.A, len(v))
+		for i, item := range v {
+			copied[i] = clone(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// lookupPath returns the values at a dotted path. Arrays met along the way
+// are traversed, so a path can reach several values; a missing path reaches
+// none.
+func lookupPath(value interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+
+	switch v := value.(type) {
+	case bson.D:
+		for _, element := range v {
+			if element.Key == path[0] {
+				return lookupPath(element.Value, path[1:])
+			}
+		}
+	case bson.A:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i >= 0 && i < len(v) {
+				return lookupPath(v[i], path[1:])
+			}
+			return nil
+		}
+		var values []interface{}
+		for _, item := range v {
+			if _, ok := item.(bson.D); ok {
+				values = append(values, lookupPath(item, path)...)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+
+	return 0, false
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int32, int64, float64:
+		return true
+	}
+
+	return false
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	return math.NaN()
+}
+
+// typeOrder is the position of the type of value in MongoDB's comparison
+// order; values of different types compare by it.
+func typeOrder(value interface{}) int {
+	switch value.(type) {
+	case primitive.MinKey:
+		return 0
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, float64:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	case primitive.MaxKey:
+		return 13
+	}
+
+	return 12
+}
+
+// compare orders two values the way MongoDB sorts them.
+func compare(a, b interface{}) int {
+	if oa, ob := typeOrder(a), typeOrder(b); oa != ob {
+		return sign(oa - ob)
+	}
+
+	switch va := a.(type) {
+	case int32, int64, float64:
+		ia, aInt := toExactInt(a)
+		ib, bInt := toExactInt(b)
+		if aInt && bInt {
+			return compareInt64(ia, ib)
+		}
+		fa, fb := toFloat(a), toFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(va, asString(b))
+	case primitive.Symbol:
+		return strings.Compare(string(va), asString(b))
+	case bson.D:
+		vb := b.(bson.D)
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if c := compare(va[i].Value, vb[i].Value); c != 0 {
+				return c
+			}
+			if c := strings.Compare(va[i].Key, vb[i].Key); c != 0 {
+				return c
+			}
+		}
+		return sign(len(va) - len(vb))
+	case bson.A:
+		vb := b.(bson.A)
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if c := compare(va[i], vb[i]); c != 0 {
+				return c
+			}
+		}
+		return sign(len(va) - len(vb))
+	case primitive.Binary:
+		vb := b.(primitive.Binary)
+		if len(va.Data) != len(vb.Data) {
+			return sign(len(va.Data) - len(vb.Data))
+		}
+		if va.Subtype != vb.Subtype {
+			return sign(int(va.Subtype) - int(vb.Subtype))
+		}
+		return bytes.Compare(va.Data, vb.Data)
+	case primitive.ObjectID:
+		vb := b.(primitive.ObjectID)
+		return bytes.Compare(va[:], vb[:])
+	case bool:
+		vb := b.(bool)
+		switch {
+		case va == vb:
+			return 0
+		case vb:
+			return -1
+		}
+		return 1
+	case primitive.DateTime, time.Time:
+		return compareInt64(asMillis(a), asMillis(b))
+	case primitive.Timestamp:
+		vb := b.(primitive.Timestamp)
+		return primitive.CompareTimestamp(va, vb)
+	case primitive.Regex:
+		vb := b.(primitive.Regex)
+		if c := strings.Compare(va.Pattern, vb.Pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(va.Options, vb.Options)
+	}
+
+	return 0
+}
+
+func equal(a, b interface{}) bool {
+	return typeOrder(a) == typeOrder(b) && compare(a, b) == 0
+}
+
+func toExactInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+func asString(value interface{}) string {
+	if symbol, ok := value.(primitive.Symbol); ok {
+		return string(symbol)
+	}
+	s, _ := value.(string)
+	return s
+}
+
+func asMillis(value interface{}) int64 {
+	if t, ok := value.(time.Time); ok {
+		return t.UnixMilli()
+	}
+	return int64(value.(primitive.DateTime))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int32, int64, float64:
+		return toFloat(v) != 0
+	}
+
+	return true
+}
+
+// matcher evaluates a query filter against the documents of a collection.
+type matcher struct {
+	filter bson.D
+	text   *textSearch
+}
+
+func newMatcher(coll *collection, filter bson.D) (*matcher, *commandError) {
+	m := &matcher{filter: filter}
+
+	if search, ok := lookup(filter, "$text").(bson.D); ok {
+		text, err := newTextSearch(coll, search)
+		if err != nil {
+			return nil, err
+		}
+		m.text = text
+	}
+
+	return m, nil
+}
+
+func (m *matcher) matches(document bson.D) (bool, *commandError) {
+	return m.matchesFilter(document, m.filter)
+}
+
+func (m *matcher) matchesFilter(document bson.D, filter bson.D) (bool, *commandError) {
+	for _, element := range filter {
+		ok, err := m.matchesElement(document, element)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (m *matcher) matchesElement(document bson.D, element bson.E) (bool, *commandError) {
+	switch element.Key {
+	case "$and", "$or", "$nor":
+		clauses, ok := element.Value.(bson.A)
+		if !ok || len(clauses) == 0 {
+			return false, badValue("%s must be a nonempty array", element.Key)
+		}
+
+		matched := 0
+		for _, value := range clauses {
+			clause, ok := value.(bson.D)
+			if !ok {
+				return false, badValue("%s entries must be objects", element.Key)
+			}
+			ok, err := m.matchesFilter(document, clause)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched++
+			}
+		}
+
+		switch element.Key {
+		case "$and":
+			return matched == len(clauses), nil
+		case "$or":
+			return matched > 0, nil
+		}
+		return matched == 0, nil
+	case "$text":
+		if m.text == nil {
+			return false, badValue("$text must be an object")
+		}
+		return m.text.matches(document), nil
+	}
+
+	if strings.HasPrefix(element.Key, "$") {
+		return false, badValue("unknown top level operator: %s", element.Key)
+	}
+
+	values := lookupPath(document, strings.Split(element.Key, "."))
+	if operators, ok := element.Value.(bson.D); ok && len(operators) > 0 && strings.HasPrefix(operators[0].Key, "$") {
+		return matchesOperators(values, operators)
+	}
+
+	return matchesCondition(values, "$eq", element.Value)
+}
+
+func matchesOperators(values []interface{}, operators bson.D) (bool, *commandError) {
+	for _, operator := range operators {
+		var ok bool
+		var err *commandError
+
+		switch operator.Key {
+		case "$options":
+			if _, hasRegex := lookup(operators, "$regex").(string); !hasRegex {
+				return false, badValue("$options needs a $regex")
+			}
+			continue
+		case "$regex":
+			var regex primitive.Regex
+			switch pattern := operator.Value.(type) {
+			case string:
+				options, _ := lookup(operators, "$options").(string)
+				regex = primitive.Regex{Pattern: pattern, Options: options}
+			case primitive.Regex:
+				regex = pattern
+			default:
+				return false, badValue("$regex has to be a string")
+			}
+			ok, err = matchesCondition(values, "$eq", regex)
+		case "$not":
+			switch not := operator.Value.(type) {
+			case bson.D:
+				ok, err = matchesOperators(values, not)
+			case primitive.Regex:
+				ok, err = matchesCondition(values, "$eq", not)
+			default:
+				return false, badValue("$not needs a regex or a document")
+			}
+			ok = !ok
+		default:
+			ok, err = matchesCondition(values, operator.Key, operator.Value)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchesCondition applies one comparison operator to the values found at a
+// path. Like MongoDB, an array matches when the array itself or any of its
+// elements does, and a missing field equals null.
+func matchesCondition(values []interface{}, operator string, operand interface{}) (bool, *commandError) {
+	switch operator {
+	case "$eq", "$gt", "$gte", "$lt", "$lte":
+		if regex, ok := operand.(primitive.Regex); ok && operator == "$eq" {
+			re, err := compileRegex(regex)
+			if err != nil {
+				return false, err
+			}
+			return anyValue(values, func(v interface{}) bool {
+				s, ok := v.(string)
+				return ok && re.MatchString(s)
+			}), nil
+		}
+		if operand == nil && (operator == "$eq" || operator == "$gte" || operator == "$lte") && len(values) == 0 {
+			return true, nil
+		}
+		return anyValue(values, func(v interface{}) bool {
+			if typeOrder(v) != typeOrder(operand) {
+				return false
+			}
+			c := compare(v, operand)
+			switch operator {
+			case "$gt":
+				return c > 0
+			case "$gte":
+				return c >= 0
+			case "$lt":
+				return c < 0
+			case "$lte":
+				return c <= 0
+			}
+			return c == 0
+		}), nil
+	case "$ne":
+		ok, err := matchesCondition(values, "$eq", operand)
+		return !ok, err
+	case "$in", "$nin":
+		candidates, ok := operand.(bson.A)
+		if !ok {
+			return false, badValue("%s needs an array", operator)
+		}
+		found := false
+		for _, candidate := range candidates {
+			ok, err := matchesCondition(values, "$eq", candidate)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				found = true
+				break
+			}
+		}
+		return found == (operator == "$in"), nil
+	case "$exists":
+		return (len(values) > 0) == truthy(operand), nil
+	}
+
+	return false, badValue("unknown operator: %s", operator)
+}
+
+func anyValue(values []interface{}, predicate func(v interface{}) bool) bool {
+	for _, value := range values {
+		if predicate(value) {
+			return true
+		}
+		if array, ok := value.(bson.A); ok {
+			for _, item := range array {
+				if predicate(item) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func compileRegex(regex primitive.Regex) (*regexp.Regexp, *commandError) {
+	flags := ""
+	for _, option := range regex.Options {
+		switch option {
+		case 'i', 'm', 's':
+			flags += string(option)
+		default:
+			return nil, badValue("unsupported regex option: %c", option)
+		}
+	}
+	if flags != "" {
+		flags = "(?" + flags + ")"
+	}
+
+	re, err := regexp.Compile(flags + regex.Pattern)
+	if err != nil {
+		return nil, badValue("invalid regex: %v", err)
+	}
+	return re, nil
+}
+
+// sortDocuments sorts documents by a sort specification. Sorting by
+// {$meta: "textScore"} puts the best matches of a text search first.
+func sortDocuments(documents []bson.D, spec bson.D, text *textSearch) *commandError {
+	type key struct {
+		path      []string
+		direction int
+		score     bool
+	}
+
+	keys := make([]key, len(spec))
+	for i, element := range spec {
+		keys[i].path = strings.Split(element.Key, ".")
+
+		if meta, ok := element.Value.(bson.D); ok {
+			if lookup(meta, "$meta") != "textScore" || text == nil {
+				return badValue("sorting by %v requires a $text query", meta)
+			}
+			keys[i].score = true
+			continue
+		}
+
+		direction, ok := toInt64(element.Value)
+		if !ok || (direction != 1 && direction != -1) {
+			return badValue("sort direction for %s must be 1 or -1", element.Key)
+		}
+		keys[i].direction = int(direction)
+	}
+
+	sortValue := func(document bson.D, path []string) interface{} {
+		values := lookupPath(document, path)
+		if len(values) == 0 {
+			return nil
+		}
+		return values[0]
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		for _, k := range keys {
+			var c int
+			if k.score {
+				c = -compare(text.score(documents[i]), text.score(documents[j]))
+			} else {
+				c = k.direction * compare(sortValue(documents[i], k.path), sortValue(documents[j], k.path))
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	return nil
+}
+
+// project applies an inclusion or exclusion projection of top-level fields.
+// Fields projected as {$meta: "textScore"} receive the text search score.
+func project(document bson.D, projection bson.D, text *textSearch) (bson.D, *commandError) {
+	include, exclude := map[string]bool{}, map[string]bool{}
+	var meta []string
+	idIncluded, idListed := true, false
+
+	for _, element := range projection {
+		if strings.Contains(element.Key, ".") {
+			return nil, badValue("projection of nested fields is not supported: %s", element.Key)
+		}
+		if value, ok := element.Value.(bson.D); ok {
+			if lookup(value, "$meta") != "textScore" || text == nil {
+				return nil, badValue("projecting %v requires a $text query", value)
+			}
+			meta = append(meta, element.Key)
+			continue
+		}
+
+		switch {
+		case element.Key == "_id":
+			idIncluded, idListed = truthy(element.Value), true
+		case truthy(element.Value):
+			include[element.Key] = true
+		default:
+			exclude[element.Key] = true
+		}
+	}
+	if len(include) > 0 && len(exclude) > 0 {
+		return nil, badValue("cannot mix inclusion and exclusion in a projection")
+	}
+	inclusion := len(include) > 0 || (len(exclude) == 0 && idListed && idIncluded)
+
+	var projected bson.D
+	for _, element := range document {
+		switch {
+		case element.Key == "_id":
+			if !idIncluded {
+				continue
+			}
+		case inclusion:
+			if !include[element.Key] {
+				continue
+			}
+		case exclude[element.Key]:
+			continue
+		}
+		projected = append(projected, bson.E{Key: element.Key, Value: clone(element.Value)})
+	}
+
+	for _, key := range meta {
+		projected = append(projected, bson.E{Key: key, Value: text.score(document)})
+	}
+
+	return projected, nil
+}
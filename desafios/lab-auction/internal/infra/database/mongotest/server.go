@@ -0,0 +1,354 @@
+// Package mongotest runs an in-process stand-in for MongoDB that the
+// repositories can be tested against without a database server.
+//
+// The stand-in speaks the MongoDB wire protocol on a loopback port and
+// keeps its data in memory. It implements the commands, query and update
+// operators and aggregation stages the repositories use, with MongoDB's
+// matching semantics; anything else fails with an error instead of being
+// silently ignored, so a test never passes against behaviour the real
+// server would not have. Commands run one at a time. Tailable cursors,
+// sessions and transactions are not supported.
+//
+// NewDatabase uses the stand-in by default, so go test ./... runs the
+// repository tests anywhere. With MONGODB_URL set, the same tests run
+// against that server instead, each in a database of its own.
+package mongotest
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+
+	maxMessageSize = 48000000
+)
+
+// Server is a running stand-in.
+type Server struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	databases map[string]*database
+
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// NewServer starts a stand-in listening on a loopback port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener:  listener,
+		databases: make(map[string]*database),
+		conns:     make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.accept()
+
+	return s, nil
+}
+
+// URI is the connection string of the stand-in.
+func (s *Server) URI() string {
+	return fmt.Sprintf("mongodb://%s/?directConnection=true", s.listener.Addr())
+}
+
+// Close stops the stand-in and drops its data.
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.connsMu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connsMu.Unlock()
+
+	s.wg.Wait()
+}
+
+// NewDatabase returns an empty database for the duration of the test: on
+// the server in MONGODB_URL when it is set, and on a stand-in started for
+// the test otherwise. On a real server each test gets its own database,
+// dropped when the test ends, so tests never see each other's data or the
+// data of MONGODB_DB.
+func NewDatabase(t testing.TB) *mongo.Database {
+	t.Helper()
+
+	if uri := os.Getenv("MONGODB_URL"); uri != "" {
+		database := connect(t, uri).Database("test_" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+		t.Cleanup(func() { database.Drop(context.Background()) })
+		return database
+	}
+
+	server, err := NewServer()
+	if err != nil {
+		t.Fatalf("Erro ao iniciar o MongoDB em memória: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	return connect(t, server.URI()).Database("test")
+}
+
+func connect(t testing.TB, uri string) *mongo.Client {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao MongoDB: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("Erro ao conectar ao MongoDB: %v", err)
+	}
+
+	return client
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.connsMu.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMu.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// serve answers the requests of one connection until it is closed.
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.connsMu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return
+		}
+
+		length := int32(binary.LittleEndian.Uint32(header))
+		if length < 16 || length > maxMessageSize {
+			return
+		}
+		requestId := int32(binary.LittleEndian.Uint32(header[4:]))
+		opCode := int32(binary.LittleEndian.Uint32(header[12:]))
+
+		body := make([]byte, length-16)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+
+		var reply []byte
+		switch opCode {
+		case opMsg:
+			dbName, command, err := parseMsg(body)
+			if err != nil {
+				return
+			}
+			reply = encodeMsg(requestId, s.run(dbName, command))
+		case opQuery:
+			dbName, command, err := parseQuery(body)
+			if err != nil {
+				return
+			}
+			reply = encodeReply(requestId, s.run(dbName, command))
+		default:
+			return
+		}
+
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// parseMsg decodes an OP_MSG request. Document sequences are added to the
+// command under their identifier, as the server does.
+func parseMsg(body []byte) (string, bson.D, error) {
+	if len(body) < 4 {
+		return "", nil, errors.New("short OP_MSG")
+	}
+	flags := binary.LittleEndian.Uint32(body)
+	body = body[4:]
+	if flags&1 != 0 {
+		body = body[:len(body)-4]
+	}
+
+	var command bson.D
+	var sequences []bson.E
+	for len(body) > 0 {
+		kind := body[0]
+		body = body[1:]
+
+		switch kind {
+		case 0:
+			raw, rest, err := readDocument(body)
+			if err != nil {
+				return "", nil, err
+			}
+			if err := bson.Unmarshal(raw, &command); err != nil {
+				return "", nil, err
+			}
+			body = rest
+		case 1:
+			if len(body) < 4 {
+				return "", nil, errors.New("short document sequence")
+			}
+			size := int(binary.LittleEndian.Uint32(body))
+			if size < 4 || size > len(body) {
+				return "", nil, errors.New("invalid document sequence")
+			}
+			section := body[4:size]
+			body = body[size:]
+
+			end := strings.IndexByte(string(section), 0)
+			if end < 0 {
+				return "", nil, errors.New("invalid document sequence")
+			}
+			identifier := string(section[:end])
+			section = section[end+1:]
+
+			var documents bson.A
+			for len(section) > 0 {
+				raw, rest, err := readDocument(section)
+				if err != nil {
+					return "", nil, err
+				}
+				var document bson.D
+				if err := bson.Unmarshal(raw, &document); err != nil {
+					return "", nil, err
+				}
+				documents = append(documents, document)
+				section = rest
+			}
+			sequences = append(sequences, bson.E{Key: identifier, Value: documents})
+		default:
+			return "", nil, fmt.Errorf("unknown OP_MSG section kind %d", kind)
+		}
+	}
+
+	command = append(command, sequences...)
+	dbName, _ := lookup(command, "$db").(string)
+	return dbName, command, nil
+}
+
+// parseQuery decodes a legacy OP_QUERY request, which the driver only uses
+// for the connection handshake.
+func parseQuery(body []byte) (string, bson.D, error) {
+	if len(body) < 4 {
+		return "", nil, errors.New("short OP_QUERY")
+	}
+	body = body[4:]
+
+	end := strings.IndexByte(string(body), 0)
+	if end < 0 || len(body) < end+9 {
+		return "", nil, errors.New("invalid OP_QUERY")
+	}
+	namespace := string(body[:end])
+	body = body[end+9:]
+
+	raw, _, err := readDocument(body)
+	if err != nil {
+		return "", nil, err
+	}
+	var command bson.D
+	if err := bson.Unmarshal(raw, &command); err != nil {
+		return "", nil, err
+	}
+
+	// Commands may be wrapped as {$query: command, $readPreference: ...}.
+	if query, ok := lookup(command, "$query").(bson.D); ok {
+		command = query
+	}
+
+	dbName, _, _ := strings.Cut(namespace, ".")
+	return dbName, command, nil
+}
+
+func readDocument(b []byte) (bson.Raw, []byte, error) {
+	if len(b) < 5 {
+		return nil, nil, errors.New("short document")
+	}
+	size := int(binary.LittleEndian.Uint32(b))
+	if size < 5 || size > len(b) {
+		return nil, nil, errors.New("invalid document length")
+	}
+
+	return bson.Raw(b[:size]), b[size:], nil
+}
+
+func encodeMsg(responseTo int32, document bson.D) []byte {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		raw, _ = bson.Marshal(errorReply(internalError(err)))
+	}
+
+	message := header(responseTo, opMsg, 4+1+len(raw))
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = append(message, 0)
+	return append(message, raw...)
+}
+
+func encodeReply(responseTo int32, document bson.D) []byte {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		raw, _ = bson.Marshal(errorReply(internalError(err)))
+	}
+
+	message := header(responseTo, opReply, 4+8+4+4+len(raw))
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = binary.LittleEndian.AppendUint64(message, 0)
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = binary.LittleEndian.AppendUint32(message, 1)
+	return append(message, raw...)
+}
+
+func header(responseTo int32, opCode int32, bodyLength int) []byte {
+	message := make([]byte, 0, 16+bodyLength)
+	message = binary.LittleEndian.AppendUint32(message, uint32(16+bodyLength))
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = binary.LittleEndian.AppendUint32(message, uint32(responseTo))
+	return binary.LittleEndian.AppendUint32(message, uint32(opCode))
+}
@@ -0,0 +1,93 @@
+package mongotest
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNewDatabase_UniqueIndex(t *testing.T) {
+	ctx := context.Background()
+	collection := NewDatabase(t).Collection("users")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		t.Fatalf("Erro ao criar índice: %v", err)
+	}
+
+	if _, err := collection.InsertOne(ctx, bson.M{"_id": "1", "email": "a@example.com"}); err != nil {
+		t.Fatalf("Erro ao inserir documento: %v", err)
+	}
+
+	_, err = collection.InsertOne(ctx, bson.M{"_id": "2", "email": "a@example.com"})
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("Esperado erro de chave duplicada, mas obteve %v", err)
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": "3"}, bson.M{"$set": bson.M{"email": "a@example.com"}}, options.Update().SetUpsert(true))
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("Esperado erro de chave duplicada no upsert, mas obteve %v", err)
+	}
+}
+
+func TestNewDatabase_UnsupportedOperator(t *testing.T) {
+	ctx := context.Background()
+	collection := NewDatabase(t).Collection("auctions")
+
+	if _, err := collection.InsertOne(ctx, bson.M{"_id": "1", "amount": 10}); err != nil {
+		t.Fatalf("Erro ao inserir documento: %v", err)
+	}
+
+	if _, err := collection.CountDocuments(ctx, bson.M{"amount": bson.M{"$mod": bson.A{2, 0}}}); err == nil {
+		t.Error("Esperado erro para operador não suportado")
+	}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": "1"}, bson.M{"$mul": bson.M{"amount": 2}}); err == nil {
+		t.Error("Esperado erro para modificador não suportado")
+	}
+
+	if _, err := collection.Find(ctx, bson.M{"$text": bson.M{"$search": "produto"}}); err == nil {
+		t.Error("Esperado erro para busca de texto sem índice de texto")
+	}
+}
+
+func TestNewDatabase_GroupIdInAnyPosition(t *testing.T) {
+	ctx := context.Background()
+	collection := NewDatabase(t).Collection("bids")
+
+	documents := []interface{}{
+		bson.M{"_id": "1", "user_id": "a", "amount": 10},
+		bson.M{"_id": "2", "user_id": "a", "amount": 20},
+		bson.M{"_id": "3", "user_id": "b", "amount": 5},
+	}
+	if _, err := collection.InsertMany(ctx, documents); err != nil {
+		t.Fatalf("Erro ao inserir documentos: %v", err)
+	}
+
+	pipeline := []bson.D{{{Key: "$group", Value: bson.D{
+		{Key: "total", Value: bson.M{"$sum": "$amount"}},
+		{Key: "_id", Value: "$user_id"},
+	}}}}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	var groups []struct {
+		Id    string `bson:"_id"`
+		Total int    `bson:"total"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		t.Fatalf("Erro ao decodificar grupos: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Id != "a" || groups[0].Total != 30 || groups[1].Id != "b" || groups[1].Total != 5 {
+		t.Errorf("Esperado totais a=30 e b=5, obteve %+v", groups)
+	}
+}
@@ -0,0 +1,184 @@
+package mongotest
+
+import (
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// applyUpdate returns document changed by an update: either a replacement
+// document or update operators. $setOnInsert only applies when inserting
+// the result of an upsert.
+func applyUpdate(document bson.D, update bson.D, inserting bool) (bson.D, *commandError) {
+	updated := clone(document).(bson.D)
+
+	if len(update) > 0 && !strings.HasPrefix(update[0].Key, "$") {
+		return replace(updated, update)
+	}
+
+	for _, operator := range update {
+		fields, ok := operator.Value.(bson.D)
+		if !ok {
+			return nil, badValue("modifiers of %s must be an object", operator.Key)
+		}
+
+		for _, field := range fields {
+			if field.Key == "_id" && operator.Key != "$setOnInsert" &&
+				!(operator.Key == "$set" && equal(lookup(document, "_id"), field.Value)) {
+				return nil, &commandError{
+					Code: 66, CodeName: "ImmutableField",
+					Message: "Performing an update on the path '_id' would modify the immutable field '_id'"}
+			}
+
+			path := strings.Split(field.Key, ".")
+			var err *commandError
+			switch operator.Key {
+			case "$set":
+				updated, err = setPath(updated, path, clone(field.Value))
+			case "$setOnInsert":
+				if inserting {
+					updated, err = setPath(updated, path, clone(field.Value))
+				}
+			case "$unset":
+				updated = unsetPath(updated, path)
+			case "$inc":
+				if !isNumber(field.Value) {
+					return nil, badValue("cannot increment with non-numeric argument: {%s: %v}", field.Key, field.Value)
+				}
+				current := lookupPath(updated, path)
+				switch {
+				case len(current) == 0:
+					updated, err = setPath(updated, path, field.Value)
+				case isNumber(current[0]):
+					updated, err = setPath(updated, path, add(current[0], field.Value))
+				default:
+					return nil, badValue("cannot apply $inc to a value of non-numeric type at %s", field.Key)
+				}
+			default:
+				return nil, badValue("Unknown modifier: %s", operator.Key)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+func replace(document bson.D, replacement bson.D) (bson.D, *commandError) {
+	id := lookup(document, "_id")
+	replaced := bson.D{}
+	if id != nil {
+		replaced = append(replaced, bson.E{Key: "_id", Value: id})
+	}
+
+	for _, element := range replacement {
+		if element.Key == "_id" {
+			if id != nil && !equal(id, element.Value) {
+				return nil, &commandError{
+					Code: 66, CodeName: "ImmutableField",
+					Message: "the (immutable) field '_id' was found to have been altered"}
+			}
+			if id == nil {
+				replaced = append(replaced, element)
+			}
+			continue
+		}
+		replaced = append(replaced, bson.E{Key: element.Key, Value: clone(element.Value)})
+	}
+
+	return replaced, nil
+}
+
+func setPath(document bson.D, path []string, value interface{}) (bson.D, *commandError) {
+	for i, element := range document {
+		if element.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			document[i].Value = value
+			return document, nil
+		}
+
+		nested, ok := element.Value.(bson.D)
+		if !ok {
+			return nil, badValue("cannot create field '%s' in element {%s: %v}", path[1], element.Key, element.Value)
+		}
+		nested, err := setPath(nested, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		document[i].Value = nested
+		return document, nil
+	}
+
+	if len(path) == 1 {
+		return append(document, bson.E{Key: path[0], Value: value}), nil
+	}
+	nested, err := setPath(bson.D{}, path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	return append(document, bson.E{Key: path[0], Value: nested}), nil
+}
+
+func unsetPath(document bson.D, path []string) bson.D {
+	for i, element := range document {
+		if element.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return append(document[:i:i], document[i+1:]...)
+		}
+		if nested, ok := element.Value.(bson.D); ok {
+			document[i].Value = unsetPath(nested, path[1:])
+		}
+		return document
+	}
+
+	return document
+}
+
+// add sums two numbers with MongoDB's type promotion: int32 widens to int64
+// on overflow, and any double makes the result a double.
+func add(a, b interface{}) interface{} {
+	ia, aInt := toExactInt(a)
+	ib, bInt := toExactInt(b)
+	if !aInt || !bInt {
+		return toFloat(a) + toFloat(b)
+	}
+
+	sum := ia + ib
+	_, a32 := a.(int32)
+	_, b32 := b.(int32)
+	if a32 && b32 && sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		return int32(sum)
+	}
+	return sum
+}
+
+// upsertSeed is the document an upsert starts from: the equality
+// conditions of its filter.
+func upsertSeed(filter bson.D) bson.D {
+	seed := bson.D{}
+	for _, element := range filter {
+		if strings.HasPrefix(element.Key, "$") {
+			continue
+		}
+
+		value := element.Value
+		if operators, ok := value.(bson.D); ok && len(operators) > 0 && strings.HasPrefix(operators[0].Key, "$") {
+			eq, ok := lookup(operators, "$eq"), len(operators) == 1 && operators[0].Key == "$eq"
+			if !ok {
+				continue
+			}
+			value = eq
+		}
+
+		seed, _ = setPath(seed, strings.Split(element.Key, "."), clone(value))
+	}
+
+	return seed
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errEmailTaken = internal_error.NewBadRequestError("Email is already registered")
+
+// CreateUser stores a new user. The email must not belong to another active
+// user, which the unique index on the email of live users enforces.
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	userEntityMongo := &UserEntityMongo{
+		Id:           userEntity.Id,
+		Name:         userEntity.Name,
+		Email:        userEntity.Email,
+		PasswordHash: userEntity.PasswordHash,
+		CreatedAt:    userEntity.CreatedAt.Unix(),
+		UpdatedAt:    userEntity.UpdatedAt.Unix(),
+	}
+
+	if _, err := ur.Collection.InsertOne(ctx, userEntityMongo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errEmailTaken
+		}
+		logger.Error("Error trying to insert user", err)
+		return internal_error.NewInternalServerError("Error trying to insert user")
+	}
+
+	return nil
+}
+
+// UpdateUser stores the profile of an active user.
+func (ur *UserRepository) UpdateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	update := bson.M{"$set": bson.M{
+		"name":          userEntity.Name,
+		"email":         userEntity.Email,
+		"password_hash": userEntity.PasswordHash,
+		"updated_at":    userEntity.UpdatedAt.Unix(),
+	}}
+
+	result, err := ur.Collection.UpdateOne(ctx, notDeleted(bson.M{"_id": userEntity.Id}), update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errEmailTaken
+		}
+		logger.Error("Error trying to update user", err)
+		return internal_error.NewInternalServerError("Error trying to update user")
+	}
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userEntity.Id))
+	}
+
+	return nil
+}
+
+// DeleteUser soft deletes the user: the document is kept, marked with the
+// deletion time, and is no longer returned by the other queries.
+func (ur *UserRepository) DeleteUser(
+	ctx context.Context, userId string, at time.Time) *internal_error.InternalError {
+	update := bson.M{"$set": bson.M{"deleted": true, "deleted_at": at.Unix(), "updated_at": at.Unix()}}
+
+	result, err := ur.Collection.UpdateOne(ctx, notDeleted(bson.M{"_id": userId}), update)
+	if err != nil {
+		logger.Error("Error trying to delete user", err)
+		return internal_error.NewInternalServerError("Error trying to delete user")
+	}
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/migration"
+	"fullcycle-auction_go/internal/infra/database/mongotest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newUserRepository(t *testing.T) *UserRepository {
+	t.Helper()
+
+	database := mongotest.NewDatabase(t)
+	if err := migration.NewMigrator(database, migration.Migrations).Run(context.Background()); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+
+	return NewUserRepository(database)
+}
+
+func newUser(t *testing.T, email string) *user_entity.User {
+	t.Helper()
+
+	user, err := user_entity.CreateUser("Maria", email, "senha-segura")
+	if err != nil {
+		t.Fatalf("Erro ao criar entidade de usuário: %v", err)
+	}
+	return user
+}
+
+func TestCreateUser_ConcurrentSignUpsWithSameEmail(t *testing.T) {
+	repository := newUserRepository(t)
+
+	const signUps = 5
+	errs := make(chan error, signUps)
+	var wg sync.WaitGroup
+	for i := 0; i < signUps; i++ {
+		wg.Add(1)
+		go func(user *user_entity.User) {
+			defer wg.Done()
+			if err := repository.CreateUser(context.Background(), user); err != nil {
+				errs <- err
+			}
+		}(newUser(t, "maria@example.com"))
+	}
+	wg.Wait()
+	close(errs)
+
+	rejected := 0
+	for err := range errs {
+		if err.Error() != errEmailTaken.Error() {
+			t.Errorf("Esperado erro de email já cadastrado, mas obteve %v", err)
+		}
+		rejected++
+	}
+	if rejected != signUps-1 {
+		t.Errorf("Esperado que só um cadastro fosse aceito, mas %d foram recusados", rejected)
+	}
+}
+
+func TestCreateUser_EmailOfDeletedUserCanBeReused(t *testing.T) {
+	ctx := context.Background()
+	repository := newUserRepository(t)
+
+	first := newUser(t, "maria@example.com")
+	if err := repository.CreateUser(ctx, first); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if err := repository.DeleteUser(ctx, first.Id, time.Now()); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	second := newUser(t, "maria@example.com")
+	if err := repository.CreateUser(ctx, second); err != nil {
+		t.Fatalf("Esperado que o email de um usuário removido pudesse ser reutilizado, mas obteve %v", err)
+	}
+
+	found, err := repository.FindUserByEmail(ctx, "maria@example.com")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if found.Id != second.Id {
+		t.Errorf("Esperado usuário %s, mas obteve %s", second.Id, found.Id)
+	}
+}
+
+func TestUpdateUser_RejectsEmailOfAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	repository := newUserRepository(t)
+
+	maria := newUser(t, "maria@example.com")
+	joao := newUser(t, "joao@example.com")
+	for _, user := range []*user_entity.User{maria, joao} {
+		if err := repository.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+	}
+
+	joao.Email = "maria@example.com"
+	if err := repository.UpdateUser(ctx, joao); err == nil || err.Error() != errEmailTaken.Error() {
+		t.Errorf("Esperado erro de email já cadastrado, mas obteve %v", err)
+	}
+
+	maria.Name = "Maria Silva"
+	if err := repository.UpdateUser(ctx, maria); err != nil {
+		t.Errorf("Esperado que o usuário mantivesse o próprio email, mas obteve %v", err)
+	}
+}
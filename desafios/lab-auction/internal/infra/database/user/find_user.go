@@ -7,14 +7,25 @@ import (
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/user_entity"
 	"fullcycle-auction_go/internal/internal_error"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserEntityMongo struct {
-	Id   string `bson:"_id"`
-	Name string `bson:"name"`
+	Id           string `bson:"_id"`
+	Name         string `bson:"name"`
+	Email        string `bson:"email,omitempty"`
+	PasswordHash string `bson:"password_hash,omitempty"`
+	CreatedAt    int64  `bson:"created_at,omitempty"`
+	UpdatedAt    int64  `bson:"updated_at,omitempty"`
+	DeletedAt    int64  `bson:"deleted_at,omitempty"`
+
+	// Deleted is stored on every user, because the unique index on the
+	// email of live users filters on it: a partial index can match
+	// deleted: false, but not a missing deleted_at.
+	Deleted bool `bson:"deleted"`
 }
 
 type UserRepository struct {
@@ -27,9 +38,15 @@ func NewUserRepository(database *mongo.Database) *UserRepository {
 	}
 }
 
+// notDeleted restricts a filter to users that were not soft deleted.
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted"] = false
+	return filter
+}
+
 func (ur *UserRepository) FindUserById(
 	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
-	filter := bson.M{"_id": userId}
+	filter := notDeleted(bson.M{"_id": userId})
 
 	var userEntityMongo UserEntityMongo
 	err := ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
@@ -44,17 +61,30 @@ func (ur *UserRepository) FindUserById(
 		return nil, internal_error.NewInternalServerError("Error trying to find user by userId")
 	}
 
-	userEntity := &user_entity.User{
-		Id:   userEntityMongo.Id,
-		Name: userEntityMongo.Name,
+	return userEntityMongo.toEntity(), nil
+}
+
+func (ur *UserRepository) FindUserByEmail(
+	ctx context.Context, email string) (*user_entity.User, *internal_error.InternalError) {
+	filter := notDeleted(bson.M{"email": email})
+
+	var userEntityMongo UserEntityMongo
+	err := ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError("User not found with this email")
+		}
+
+		logger.Error("Error trying to find user by email", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by email")
 	}
 
-	return userEntity, nil
+	return userEntityMongo.toEntity(), nil
 }
 
 func (ur *UserRepository) FindAllUsers(
 	ctx context.Context) ([]user_entity.User, *internal_error.InternalError) {
-	cursor, err := ur.Collection.Find(ctx, bson.M{})
+	cursor, err := ur.Collection.Find(ctx, notDeleted(bson.M{}))
 	if err != nil {
 		logger.Error("Error trying to find all users", err)
 		return nil, internal_error.NewInternalServerError("Error trying to find all users")
@@ -69,11 +99,28 @@ func (ur *UserRepository) FindAllUsers(
 
 	var usersEntity []user_entity.User
 	for _, user := range usersMongo {
-		usersEntity = append(usersEntity, user_entity.User{
-			Id:   user.Id,
-			Name: user.Name,
-		})
+		usersEntity = append(usersEntity, *user.toEntity())
 	}
 
 	return usersEntity, nil
 }
+
+func (um *UserEntityMongo) toEntity() *user_entity.User {
+	user := &user_entity.User{
+		Id:           um.Id,
+		Name:         um.Name,
+		Email:        um.Email,
+		PasswordHash: um.PasswordHash,
+	}
+	if um.CreatedAt != 0 {
+		user.CreatedAt = time.Unix(um.CreatedAt, 0)
+	}
+	if um.UpdatedAt != 0 {
+		user.UpdatedAt = time.Unix(um.UpdatedAt, 0)
+	}
+	if um.DeletedAt != 0 {
+		user.DeletedAt = time.Unix(um.DeletedAt, 0)
+	}
+
+	return user
+}
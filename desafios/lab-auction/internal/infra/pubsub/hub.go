@@ -0,0 +1,132 @@
+package pubsub
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// new high bids are dropped for it.
+const subscriberBuffer = 16
+
+// Backend carries events between replicas. Every published event, including
+// the ones published by this replica, is delivered back through Subscribe.
+type Backend interface {
+	Publish(ctx context.Context, event event_entity.AuctionEvent) error
+	Subscribe(ctx context.Context, handler func(event_entity.AuctionEvent)) error
+}
+
+// Hub fans auction events out to the subscribers of each auction. Without a
+// backend it only reaches subscribers of this process.
+type Hub struct {
+	backend Backend
+
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[string]map[chan event_entity.AuctionEvent]struct{}
+}
+
+func NewHub(backend Backend) *Hub {
+	return &Hub{
+		backend:     backend,
+		subscribers: make(map[string]map[chan event_entity.AuctionEvent]struct{}),
+	}
+}
+
+// Run delivers the events received from the backend until ctx is done. It
+// returns immediately for an in-process hub.
+func (h *Hub) Run(ctx context.Context) error {
+	if h.backend == nil {
+		return nil
+	}
+	return h.backend.Subscribe(ctx, h.deliver)
+}
+
+func (h *Hub) Publish(ctx context.Context, event event_entity.AuctionEvent) {
+	if h.backend == nil {
+		h.deliver(event)
+		return
+	}
+
+	if err := h.backend.Publish(ctx, event); err != nil {
+		logger.Error("Error trying to publish auction event", err,
+			zap.String("auction_id", event.AuctionId))
+	}
+}
+
+func (h *Hub) Subscribe(auctionId string) (<-chan event_entity.AuctionEvent, func()) {
+	events := make(chan event_entity.AuctionEvent, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	if h.subscribers[auctionId] == nil {
+		h.subscribers[auctionId] = make(map[chan event_entity.AuctionEvent]struct{})
+	}
+	h.subscribers[auctionId][events] = struct{}{}
+
+	return events, func() { h.unsubscribe(auctionId, events) }
+}
+
+// Close ends every subscription, so streaming responses finish and the HTTP
+// server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for auctionId, subscribers := range h.subscribers {
+		for events := range subscribers {
+			close(events)
+		}
+		delete(h.subscribers, auctionId)
+	}
+}
+
+func (h *Hub) unsubscribe(auctionId string, events chan event_entity.AuctionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[auctionId][events]; !ok {
+		return
+	}
+
+	delete(h.subscribers[auctionId], events)
+	if len(h.subscribers[auctionId]) == 0 {
+		delete(h.subscribers, auctionId)
+	}
+	close(events)
+}
+
+// deliver never blocks: a subscriber whose buffer is full misses a high
+// bid. An event that ends the auction is never missed that way; the
+// subscription is ended instead, so the stream does not outlive the
+// auction.
+func (h *Hub) deliver(event event_entity.AuctionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ends := event.Type == event_entity.AuctionClosed || event.Type == event_entity.AuctionCancelled
+	for events := range h.subscribers[event.AuctionId] {
+		select {
+		case events <- event:
+		default:
+			if ends {
+				delete(h.subscribers[event.AuctionId], events)
+				close(events)
+			}
+		}
+	}
+	if len(h.subscribers[event.AuctionId]) == 0 {
+		delete(h.subscribers, event.AuctionId)
+	}
+}
@@ -0,0 +1,149 @@
+package pubsub
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"sync"
+	"testing"
+	"time"
+)
+
+// backendFake simula um backend compartilhado: o que um hub publica chega a
+// todos os hubs inscritos, inclusive ao que publicou.
+type backendFake struct {
+	mu       sync.Mutex
+	handlers []func(event_entity.AuctionEvent)
+}
+
+func (b *backendFake) Publish(ctx context.Context, event event_entity.AuctionEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *backendFake) Subscribe(ctx context.Context, handler func(event_entity.AuctionEvent)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func receive(t *testing.T, events <-chan event_entity.AuctionEvent) event_entity.AuctionEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Esperado evento, mas nenhum chegou")
+		return event_entity.AuctionEvent{}
+	}
+}
+
+func TestHub_DeliversOnlyToSubscribersOfTheAuction(t *testing.T) {
+	hub := NewHub(nil)
+	first, unsubscribe := hub.Subscribe("auction-1")
+	defer unsubscribe()
+	other, unsubscribeOther := hub.Subscribe("auction-2")
+	defer unsubscribeOther()
+
+	hub.Publish(context.Background(), event_entity.AuctionEvent{
+		Type: event_entity.HighBid, AuctionId: "auction-1", Amount: 10})
+
+	if event := receive(t, first); event.Amount != 10 {
+		t.Errorf("Evento inesperado: %+v", event)
+	}
+	if len(other) != 0 {
+		t.Error("Inscrito de outro leilão não deveria receber o evento")
+	}
+}
+
+func TestHub_FansOutThroughBackend(t *testing.T) {
+	backend := &backendFake{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replicaA, replicaB := NewHub(backend), NewHub(backend)
+	go replicaA.Run(ctx)
+	go replicaB.Run(ctx)
+	for {
+		backend.mu.Lock()
+		ready := len(backend.handlers) == 2
+		backend.mu.Unlock()
+		if ready {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	onA, unsubscribeA := replicaA.Subscribe("auction-1")
+	defer unsubscribeA()
+	onB, unsubscribeB := replicaB.Subscribe("auction-1")
+	defer unsubscribeB()
+
+	replicaA.Publish(ctx, event_entity.AuctionEvent{Type: event_entity.AuctionClosed, AuctionId: "auction-1"})
+
+	for _, events := range []<-chan event_entity.AuctionEvent{onA, onB} {
+		if event := receive(t, events); event.Type != event_entity.AuctionClosed {
+			t.Errorf("Evento inesperado: %+v", event)
+		}
+	}
+}
+
+func TestHub_SlowSubscriberDoesNotBlockPublish(t *testing.T) {
+	hub := NewHub(nil)
+	events, unsubscribe := hub.Subscribe("auction-1")
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer*2; i++ {
+		hub.Publish(context.Background(), event_entity.AuctionEvent{AuctionId: "auction-1"})
+	}
+
+	if len(events) != subscriberBuffer {
+		t.Errorf("Esperado buffer cheio com %d eventos, mas obteve %d", subscriberBuffer, len(events))
+	}
+}
+
+func TestHub_SlowSubscriberIsEndedByAuctionEnd(t *testing.T) {
+	for _, eventType := range []event_entity.EventType{event_entity.AuctionClosed, event_entity.AuctionCancelled} {
+		hub := NewHub(nil)
+		events, unsubscribe := hub.Subscribe("auction-1")
+
+		for i := 0; i < subscriberBuffer; i++ {
+			hub.Publish(context.Background(), event_entity.AuctionEvent{Type: event_entity.HighBid, AuctionId: "auction-1"})
+		}
+		hub.Publish(context.Background(), event_entity.AuctionEvent{Type: eventType, AuctionId: "auction-1"})
+
+		received := 0
+		for range events {
+			received++
+		}
+		if received != subscriberBuffer {
+			t.Errorf("Esperado %d eventos antes do fim da inscrição, mas obteve %d", subscriberBuffer, received)
+		}
+		unsubscribe()
+	}
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	hub := NewHub(nil)
+	events, unsubscribe := hub.Subscribe("auction-1")
+
+	hub.Close()
+	unsubscribe()
+
+	if _, ok := <-events; ok {
+		t.Error("Canal deveria estar fechado após o Close")
+	}
+	if _, ok := <-mustSubscribe(hub); ok {
+		t.Error("Inscrição após o Close deveria vir fechada")
+	}
+}
+
+func mustSubscribe(hub *Hub) <-chan event_entity.AuctionEvent {
+	events, _ := hub.Subscribe("auction-1")
+	return events
+}
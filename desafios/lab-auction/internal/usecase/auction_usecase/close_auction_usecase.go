@@ -0,0 +1,90 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuctionCloser completes overdue auctions. Closing state lives only in the
+// database, so auctions that ended while the service was down are completed
+// on the first run after startup.
+type AuctionCloser struct {
+	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface
+	eventPublisher             event_entity.EventPublisher
+	interval                   time.Duration
+	now                        func() time.Time
+}
+
+// NewAuctionCloser creates a closer that checks every interval using now as
+// its clock; tests inject a fake clock. Closed auctions are announced to
+// eventPublisher, which may be nil.
+func NewAuctionCloser(
+	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface,
+	eventPublisher event_entity.EventPublisher,
+	interval time.Duration,
+	now func() time.Time) *AuctionCloser {
+	return &AuctionCloser{
+		auctionRepositoryInterface: auctionRepositoryInterface,
+		eventPublisher:             eventPublisher,
+		interval:                   interval,
+		now:                        now,
+	}
+}
+
+// Run closes overdue auctions immediately and then on every interval until
+// ctx is done.
+func (ac *AuctionCloser) Run(ctx context.Context) {
+	ticker := time.NewTicker(ac.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := ac.CloseExpiredAuctions(ctx); err != nil {
+			logger.Error("error trying to close expired auctions", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ac *AuctionCloser) CloseExpiredAuctions(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	now := ac.now()
+	closed, err := ac.auctionRepositoryInterface.CloseExpiredAuctions(ctx, now)
+
+	for _, auctionId := range closed {
+		if ac.eventPublisher != nil {
+			ac.eventPublisher.Publish(ctx, event_entity.AuctionEvent{
+				Type:      event_entity.AuctionClosed,
+				AuctionId: auctionId,
+				Timestamp: now,
+			})
+		}
+	}
+
+	if len(closed) > 0 {
+		logger.Info("expired auctions closed", zap.Int("count", len(closed)))
+	}
+
+	return int64(len(closed)), err
+}
+
+func GetAuctionCloserInterval() time.Duration {
+	closerInterval := os.Getenv("AUCTION_CLOSER_INTERVAL")
+	duration, err := time.ParseDuration(closerInterval)
+	if err != nil || duration <= 0 {
+		return 10 * time.Second
+	}
+
+	return duration
+}
@@ -0,0 +1,191 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"github.com/google/uuid"
+	"sync"
+	"testing"
+	"time"
+)
+
+type auctionRepositoryFake struct {
+	mu       sync.Mutex
+	auctions map[string]*auction_entity.Auction
+	calls    chan time.Time
+}
+
+func (r *auctionRepositoryFake) CreateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.auctions[auctionEntity.Id] = auctionEntity
+	return nil
+}
+
+func (r *auctionRepositoryFake) FindAuctions(
+	ctx context.Context,
+	query auction_entity.AuctionQuery) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func (r *auctionRepositoryFake) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auction, ok := r.auctions[id]
+	if !ok {
+		return nil, internal_error.NewNotFoundError("auction not found")
+	}
+	copied := *auction
+	return &copied, nil
+}
+
+func (r *auctionRepositoryFake) UpdateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	return r.CreateAuction(ctx, auctionEntity)
+}
+
+func (r *auctionRepositoryFake) EndAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	return r.CreateAuction(ctx, auctionEntity)
+}
+
+func (r *auctionRepositoryFake) CloseExpiredAuctions(
+	ctx context.Context, now time.Time) ([]string, *internal_error.InternalError) {
+	r.mu.Lock()
+	var closed []string
+	for _, auction := range r.auctions {
+		if auction.Status == auction_entity.Active && !auction.EndTime.After(now) {
+			auction.Status = auction_entity.Completed
+			closed = append(closed, auction.Id)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.calls != nil {
+		r.calls <- now
+	}
+	return closed, nil
+}
+
+func newAuction(t *testing.T, repository *auctionRepositoryFake, endTime time.Time) string {
+	t.Helper()
+
+	auction, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Produto Teste", "Categoria Teste", "Descrição do produto de teste", auction_entity.New, auction_entity.English, endTime, auction_entity.BidRules{}, auction_entity.SoftClose{})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	repository.CreateAuction(context.Background(), auction)
+	return auction.Id
+}
+
+func statusOf(t *testing.T, repository *auctionRepositoryFake, id string) auction_entity.AuctionStatus {
+	t.Helper()
+
+	auction, err := repository.FindAuctionById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	return auction.Status
+}
+
+func TestAuctionCloser_ClosesOnlyOverdueAuctions(t *testing.T) {
+	repository := &auctionRepositoryFake{auctions: make(map[string]*auction_entity.Auction)}
+	start := time.Now()
+	short := newAuction(t, repository, start.Add(time.Minute))
+	long := newAuction(t, repository, start.Add(time.Hour))
+
+	now := start
+	closer := NewAuctionCloser(repository, nil, time.Second, func() time.Time { return now })
+
+	if closed, _ := closer.CloseExpiredAuctions(context.Background()); closed != 0 {
+		t.Errorf("Nenhum leilão deveria fechar antes do fim, mas %d fecharam", closed)
+	}
+
+	now = start.Add(2 * time.Minute)
+	if closed, _ := closer.CloseExpiredAuctions(context.Background()); closed != 1 {
+		t.Errorf("Esperado 1 leilão fechado, mas obteve %d", closed)
+	}
+	if status := statusOf(t, repository, short); status != auction_entity.Completed {
+		t.Errorf("Esperado status Completed para o leilão vencido, mas obteve %d", status)
+	}
+	if status := statusOf(t, repository, long); status != auction_entity.Active {
+		t.Errorf("Esperado status Active para o leilão em andamento, mas obteve %d", status)
+	}
+}
+
+type eventPublisherFake struct {
+	events []event_entity.AuctionEvent
+}
+
+func (p *eventPublisherFake) Publish(ctx context.Context, event event_entity.AuctionEvent) {
+	p.events = append(p.events, event)
+}
+
+func TestAuctionCloser_PublishesClosedAuctions(t *testing.T) {
+	repository := &auctionRepositoryFake{auctions: make(map[string]*auction_entity.Auction)}
+	id := newAuction(t, repository, time.Now().Add(time.Minute))
+	publisher := &eventPublisherFake{}
+
+	closedAt := time.Now().Add(time.Hour)
+	closer := NewAuctionCloser(repository, publisher, time.Second, func() time.Time { return closedAt })
+	closer.CloseExpiredAuctions(context.Background())
+	closer.CloseExpiredAuctions(context.Background())
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("Esperado 1 evento, mas obteve %d", len(publisher.events))
+	}
+	if event := publisher.events[0]; event.Type != event_entity.AuctionClosed || event.AuctionId != id {
+		t.Errorf("Evento inesperado para o leilão fechado: %+v", event)
+	}
+}
+
+func TestAuctionCloser_RunClosesOverdueAuctionsAtStartup(t *testing.T) {
+	repository := &auctionRepositoryFake{
+		auctions: make(map[string]*auction_entity.Auction),
+		calls:    make(chan time.Time, 1),
+	}
+	id := newAuction(t, repository, time.Now().Add(time.Minute))
+
+	// Simula um reinício depois do fim do leilão.
+	restartedAt := time.Now().Add(time.Hour)
+	closer := NewAuctionCloser(repository, nil, time.Hour, func() time.Time { return restartedAt })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go closer.Run(ctx)
+
+	select {
+	case <-repository.calls:
+	case <-time.After(time.Second):
+		t.Fatal("O fechamento deveria rodar na inicialização")
+	}
+
+	if status := statusOf(t, repository, id); status != auction_entity.Completed {
+		t.Errorf("Esperado status Completed após o reinício, mas obteve %d", status)
+	}
+}
+
+func TestCreateAuction_EndTime(t *testing.T) {
+	t.Setenv("AUCTION_INTERVAL", "30m")
+
+	auction, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Produto Teste", "Categoria Teste", "Descrição do produto de teste", auction_entity.New, auction_entity.English, time.Time{}, auction_entity.BidRules{}, auction_entity.SoftClose{})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if expected := auction.Timestamp.Add(30 * time.Minute); !auction.EndTime.Equal(expected) {
+		t.Errorf("Esperado EndTime padrão %v, mas obteve %v", expected, auction.EndTime)
+	}
+
+	_, err = auction_entity.CreateAuction(
+		uuid.New().String(), "Produto Teste", "Categoria Teste", "Descrição do produto de teste", auction_entity.New, auction_entity.English,
+		time.Now().Add(-time.Minute), auction_entity.BidRules{}, auction_entity.SoftClose{})
+	if err == nil || err.Err != "bad_request" {
+		t.Errorf("Esperado bad request para EndTime no passado, mas obteve %v", err)
+	}
+}
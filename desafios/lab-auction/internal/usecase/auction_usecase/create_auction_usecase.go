@@ -4,39 +4,84 @@ import (
 	"context"
 	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"time"
 )
 
+// AuctionInputDTO is the body of a create or update auction request.
+// SellerId is the authenticated user, set by the controller.
 type AuctionInputDTO struct {
+	SellerId    string           `json:"-"`
 	ProductName string           `json:"product_name" binding:"required,min=1"`
 	Category    string           `json:"category" binding:"required,min=2"`
 	Description string           `json:"description" binding:"required,min=10,max=200"`
 	Condition   ProductCondition `json:"condition" binding:"oneof=0 1 2"`
+	Type        AuctionType      `json:"type" binding:"omitempty,oneof=english dutch sealed_first_price vickrey"`
+	EndTime     time.Time        `json:"end_time"`
+
+	StartingPrice       float64 `json:"starting_price" binding:"gte=0"`
+	ReservePrice        float64 `json:"reserve_price" binding:"gte=0"`
+	MinIncrement        float64 `json:"min_increment" binding:"gte=0"`
+	MinIncrementPercent float64 `json:"min_increment_percent" binding:"gte=0"`
+
+	PriceDrop                float64 `json:"price_drop" binding:"gte=0"`
+	PriceDropIntervalSeconds int64   `json:"price_drop_interval_seconds" binding:"gte=0"`
+
+	SoftCloseWindowSeconds       int64 `json:"soft_close_window_seconds" binding:"gte=0"`
+	SoftCloseExtensionSeconds    int64 `json:"soft_close_extension_seconds" binding:"gte=0"`
+	SoftCloseMaxExtensionSeconds int64 `json:"soft_close_max_extension_seconds" binding:"gte=0"`
 }
 
 type AuctionOutputDTO struct {
 	Id          string           `json:"id"`
+	SellerId    string           `json:"seller_id,omitempty"`
 	ProductName string           `json:"product_name"`
 	Category    string           `json:"category"`
 	Description string           `json:"description"`
 	Condition   ProductCondition `json:"condition"`
+	Type        AuctionType      `json:"type"`
 	Status      AuctionStatus    `json:"status"`
 	Timestamp   time.Time        `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	EndTime     time.Time        `json:"end_time" time_format:"2006-01-02 15:04:05"`
+
+	StartingPrice       float64 `json:"starting_price"`
+	MinIncrement        float64 `json:"min_increment,omitempty"`
+	MinIncrementPercent float64 `json:"min_increment_percent,omitempty"`
+	PriceDrop           float64 `json:"price_drop,omitempty"`
+	HighestBid          float64 `json:"highest_bid,omitempty"`
+	MinimumBid          float64 `json:"minimum_bid"`
+
+	PriceDropIntervalSeconds int64 `json:"price_drop_interval_seconds,omitempty"`
+
+	SoftCloseWindowSeconds       int64 `json:"soft_close_window_seconds,omitempty"`
+	SoftCloseExtensionSeconds    int64 `json:"soft_close_extension_seconds,omitempty"`
+	SoftCloseMaxExtensionSeconds int64 `json:"soft_close_max_extension_seconds,omitempty"`
+	ExtendedSeconds              int64 `json:"extended_seconds,omitempty"`
+
+	RelistedFrom string `json:"relisted_from,omitempty"`
 }
 
+// WinningInfoOutputDTO reports the leading bid of an auction and the price
+// it pays, which in Vickrey auctions is below the bid itself.
 type WinningInfoOutputDTO struct {
-	Auction AuctionOutputDTO          `json:"auction"`
-	Bid     *bid_usecase.BidOutputDTO `json:"bid,omitempty"`
+	Auction    AuctionOutputDTO          `json:"auction"`
+	Bid        *bid_usecase.BidOutputDTO `json:"bid,omitempty"`
+	Price      float64                   `json:"price,omitempty"`
+	ReserveMet bool                      `json:"reserve_met"`
 }
 
+// NewAuctionUseCase creates the auction use case. Auctions ended by their
+// seller are announced to eventPublisher, which may be nil.
 func NewAuctionUseCase(
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface,
-	bidRepositoryInterface bid_entity.BidEntityRepository) AuctionUseCaseInterface {
+	bidRepositoryInterface bid_entity.BidEntityRepository,
+	eventPublisher event_entity.EventPublisher) AuctionUseCaseInterface {
 	return &AuctionUseCase{
 		auctionRepositoryInterface: auctionRepositoryInterface,
 		bidRepositoryInterface:     bidRepositoryInterface,
+		eventPublisher:             eventPublisher,
 	}
 }
 
@@ -50,30 +95,52 @@ type AuctionUseCaseInterface interface {
 
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError)
+		findInput FindAuctionsInputDTO) (*AuctionPageOutputDTO, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context,
 		auctionId string) (*WinningInfoOutputDTO, *internal_error.InternalError)
+
+	UpdateAuction(
+		ctx context.Context,
+		auctionId string,
+		auctionInput AuctionInputDTO) (*AuctionOutputDTO, *internal_error.InternalError)
+
+	CancelAuction(
+		ctx context.Context, sellerId, auctionId string) *internal_error.InternalError
+
+	CloseAuction(
+		ctx context.Context, sellerId, auctionId string) *internal_error.InternalError
+
+	RelistAuction(
+		ctx context.Context,
+		sellerId, auctionId string,
+		relistInput RelistInputDTO) (*AuctionOutputDTO, *internal_error.InternalError)
 }
 
 type ProductCondition int64
+type AuctionType string
 type AuctionStatus int64
 
 type AuctionUseCase struct {
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface
 	bidRepositoryInterface     bid_entity.BidEntityRepository
+	eventPublisher             event_entity.EventPublisher
 }
 
 func (au *AuctionUseCase) CreateAuction(
 	ctx context.Context,
 	auctionInput AuctionInputDTO) *internal_error.InternalError {
 	auction, err := auction_entity.CreateAuction(
+		auctionInput.SellerId,
 		auctionInput.ProductName,
 		auctionInput.Category,
 		auctionInput.Description,
-		auction_entity.ProductCondition(auctionInput.Condition))
+		auction_entity.ProductCondition(auctionInput.Condition),
+		auction_entity.AuctionType(auctionInput.Type),
+		auctionInput.EndTime,
+		auctionInput.bidRules(),
+		auctionInput.softClose())
 	if err != nil {
 		return err
 	}
@@ -85,3 +152,22 @@ func (au *AuctionUseCase) CreateAuction(
 
 	return nil
 }
+
+func (ai AuctionInputDTO) bidRules() auction_entity.BidRules {
+	return auction_entity.BidRules{
+		StartingPrice:       ai.StartingPrice,
+		ReservePrice:        ai.ReservePrice,
+		MinIncrement:        ai.MinIncrement,
+		MinIncrementPercent: ai.MinIncrementPercent,
+		PriceDrop:           ai.PriceDrop,
+		PriceDropInterval:   time.Duration(ai.PriceDropIntervalSeconds) * time.Second,
+	}
+}
+
+func (ai AuctionInputDTO) softClose() auction_entity.SoftClose {
+	return auction_entity.SoftClose{
+		Window:       time.Duration(ai.SoftCloseWindowSeconds) * time.Second,
+		Extension:    time.Duration(ai.SoftCloseExtensionSeconds) * time.Second,
+		MaxExtension: time.Duration(ai.SoftCloseMaxExtensionSeconds) * time.Second,
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/page_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"time"
 )
 
 func (au *AuctionUseCase) FindAuctionById(
@@ -15,41 +18,70 @@ func (au *AuctionUseCase) FindAuctionById(
 		return nil, err
 	}
 
-	return &AuctionOutputDTO{
-		Id:          auctionEntity.Id,
-		ProductName: auctionEntity.ProductName,
-		Category:    auctionEntity.Category,
-		Description: auctionEntity.Description,
-		Condition:   ProductCondition(auctionEntity.Condition),
-		Status:      AuctionStatus(auctionEntity.Status),
-		Timestamp:   auctionEntity.Timestamp,
-	}, nil
+	auctionOutput := toAuctionOutputDTO(auctionEntity)
+	return &auctionOutput, nil
+}
+
+// FindAuctionsInputDTO is the query string of an auction search. Status is
+// a pointer so that active auctions, whose status is 0, can be selected.
+type FindAuctionsInputDTO struct {
+	Status      *AuctionStatus `form:"status" binding:"omitempty,oneof=0 1 2"`
+	Category    string         `form:"category"`
+	ProductName string         `form:"productName"`
+	Search      string         `form:"q"`
+	Sort        string         `form:"sort" binding:"omitempty,oneof=newest ending_soonest highest_bid relevance"`
+	Page        int64          `form:"page" binding:"gte=0"`
+	PageSize    int64          `form:"page_size" binding:"gte=0"`
+}
+
+type AuctionPageOutputDTO struct {
+	Auctions   []AuctionOutputDTO `json:"auctions"`
+	Page       int64              `json:"page"`
+	PageSize   int64              `json:"page_size"`
+	Total      int64              `json:"total"`
+	TotalPages int64              `json:"total_pages"`
 }
 
 func (au *AuctionUseCase) FindAuctions(
 	ctx context.Context,
-	status AuctionStatus,
-	category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError) {
-	auctionEntities, err := au.auctionRepositoryInterface.FindAuctions(
-		ctx, auction_entity.AuctionStatus(status), category, productName)
+	findInput FindAuctionsInputDTO) (*AuctionPageOutputDTO, *internal_error.InternalError) {
+	page, err := page_entity.NewPage(findInput.Page, findInput.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	query := auction_entity.AuctionQuery{
+		Category:    findInput.Category,
+		ProductName: findInput.ProductName,
+		Search:      findInput.Search,
+		Sort:        auction_entity.AuctionSort(findInput.Sort),
+		Page:        page,
+	}
+	if findInput.Status != nil {
+		status := auction_entity.AuctionStatus(*findInput.Status)
+		query.Status = &status
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	auctionEntities, total, err := au.auctionRepositoryInterface.FindAuctions(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	var auctionOutputs []AuctionOutputDTO
-	for _, value := range auctionEntities {
-		auctionOutputs = append(auctionOutputs, AuctionOutputDTO{
-			Id:          value.Id,
-			ProductName: value.ProductName,
-			Category:    value.Category,
-			Description: value.Description,
-			Condition:   ProductCondition(value.Condition),
-			Status:      AuctionStatus(value.Status),
-			Timestamp:   value.Timestamp,
-		})
+	auctionOutputs := make([]AuctionOutputDTO, len(auctionEntities))
+	for i := range auctionEntities {
+		auctionOutputs[i] = toAuctionOutputDTO(&auctionEntities[i])
 	}
 
-	return auctionOutputs, nil
+	return &AuctionPageOutputDTO{
+		Auctions:   auctionOutputs,
+		Page:       page.Number,
+		PageSize:   page.Size,
+		Total:      total,
+		TotalPages: page.TotalPages(total),
+	}, nil
 }
 
 func (au *AuctionUseCase) FindWinningBidByAuctionId(
@@ -60,17 +92,9 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		return nil, err
 	}
 
-	auctionOutputDTO := AuctionOutputDTO{
-		Id:          auction.Id,
-		ProductName: auction.ProductName,
-		Category:    auction.Category,
-		Description: auction.Description,
-		Condition:   ProductCondition(auction.Condition),
-		Status:      AuctionStatus(auction.Status),
-		Timestamp:   auction.Timestamp,
-	}
+	auctionOutputDTO := toAuctionOutputDTO(auction)
 
-	bidWinning, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
+	bidWinning, price, err := au.winningBid(ctx, auction)
 	if err != nil {
 		logger.Error("", err)
 		return &WinningInfoOutputDTO{
@@ -79,16 +103,88 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		}, nil
 	}
 
+	if bidWinning == nil {
+		return &WinningInfoOutputDTO{
+			Auction: auctionOutputDTO,
+			Bid:     nil,
+		}, nil
+	}
+
 	bidOutputDTO := &bid_usecase.BidOutputDTO{
 		Id:        bidWinning.Id,
 		UserId:    bidWinning.UserId,
 		AuctionId: bidWinning.AuctionId,
 		Amount:    bidWinning.Amount,
 		Timestamp: bidWinning.Timestamp,
+		Automatic: bidWinning.Automatic,
 	}
 
 	return &WinningInfoOutputDTO{
-		Auction: auctionOutputDTO,
-		Bid:     bidOutputDTO,
+		Auction:    auctionOutputDTO,
+		Bid:        bidOutputDTO,
+		Price:      price,
+		ReserveMet: true,
 	}, nil
 }
+
+// winningBid returns the bid leading the auction and the price it pays, or
+// nil when no bid can be shown as the winner: the bids are still sealed, the
+// auction was cancelled, nobody bid or the reserve price was not met.
+func (au *AuctionUseCase) winningBid(
+	ctx context.Context,
+	auction *auction_entity.Auction) (*bid_entity.Bid, float64, *internal_error.InternalError) {
+	if auction.BidsHidden() || auction.Status == auction_entity.Cancelled {
+		return nil, 0, nil
+	}
+
+	rankedBids, err := au.bidRepositoryInterface.FindRankedBidsByAuctionId(ctx, auction.Id, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	bidWinning, price := auction.Winner(rankedBids)
+	if bidWinning == nil || !auction.ReserveMet(bidWinning.Amount) {
+		return nil, 0, nil
+	}
+
+	return bidWinning, price, nil
+}
+
+// toAuctionOutputDTO maps an auction to its API representation. The reserve
+// price is kept private; clients only learn whether it was met. Sealed
+// auctions show no high bid until they close.
+func toAuctionOutputDTO(auction *auction_entity.Auction) AuctionOutputDTO {
+	highestBid := auction.HighestBidAmount
+	if auction.BidsHidden() {
+		highestBid = 0
+	}
+
+	return AuctionOutputDTO{
+		Id:          auction.Id,
+		SellerId:    auction.SellerId,
+		ProductName: auction.ProductName,
+		Category:    auction.Category,
+		Description: auction.Description,
+		Condition:   ProductCondition(auction.Condition),
+		Type:        AuctionType(auction.Type),
+		Status:      AuctionStatus(auction.Status),
+		Timestamp:   auction.Timestamp,
+		EndTime:     auction.EndTime,
+
+		StartingPrice:       auction.BidRules.StartingPrice,
+		MinIncrement:        auction.BidRules.MinIncrement,
+		MinIncrementPercent: auction.BidRules.MinIncrementPercent,
+		PriceDrop:           auction.BidRules.PriceDrop,
+		HighestBid:          highestBid,
+		MinimumBid:          auction.MinimumBid(time.Now()),
+
+		PriceDropIntervalSeconds: int64(auction.BidRules.PriceDropInterval / time.Second),
+
+		SoftCloseWindowSeconds:       int64(auction.SoftClose.Window / time.Second),
+		SoftCloseExtensionSeconds:    int64(auction.SoftClose.Extension / time.Second),
+		SoftCloseMaxExtensionSeconds: int64(auction.SoftClose.MaxExtension / time.Second),
+		ExtendedSeconds:              int64(auction.ExtendedBy / time.Second),
+
+		RelistedFrom: auction.RelistedFrom,
+	}
+}
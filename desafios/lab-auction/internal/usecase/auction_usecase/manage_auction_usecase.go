@@ -0,0 +1,143 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+)
+
+type RelistInputDTO struct {
+	EndTime time.Time `json:"end_time"`
+}
+
+// UpdateAuction replaces the listing and rules of an auction. Only its
+// seller may do so, and only before the first bid.
+func (au *AuctionUseCase) UpdateAuction(
+	ctx context.Context,
+	auctionId string,
+	auctionInput AuctionInputDTO) (*AuctionOutputDTO, *internal_error.InternalError) {
+	auction, err := au.findSellerAuction(ctx, auctionInput.SellerId, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auction.Update(
+		auctionInput.ProductName,
+		auctionInput.Category,
+		auctionInput.Description,
+		auction_entity.ProductCondition(auctionInput.Condition),
+		auctionInput.EndTime,
+		auctionInput.bidRules(),
+		auctionInput.softClose(),
+		time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := au.auctionRepositoryInterface.UpdateAuction(ctx, auction); err != nil {
+		return nil, err
+	}
+
+	auctionOutput := toAuctionOutputDTO(auction)
+	return &auctionOutput, nil
+}
+
+// CancelAuction withdraws an active auction; none of its bids wins.
+func (au *AuctionUseCase) CancelAuction(
+	ctx context.Context, sellerId, auctionId string) *internal_error.InternalError {
+	auction, err := au.findSellerAuction(ctx, sellerId, auctionId)
+	if err != nil {
+		return err
+	}
+
+	if err := auction.Cancel(time.Now()); err != nil {
+		return err
+	}
+
+	return au.endAuction(ctx, auction, event_entity.AuctionCancelled)
+}
+
+// CloseAuction ends an active auction now, settling it with its current
+// bids.
+func (au *AuctionUseCase) CloseAuction(
+	ctx context.Context, sellerId, auctionId string) *internal_error.InternalError {
+	auction, err := au.findSellerAuction(ctx, sellerId, auctionId)
+	if err != nil {
+		return err
+	}
+
+	if err := auction.CloseEarly(time.Now()); err != nil {
+		return err
+	}
+
+	return au.endAuction(ctx, auction, event_entity.AuctionClosed)
+}
+
+// RelistAuction lists an ended auction that did not sell again, as a new
+// auction with the same product and rules.
+func (au *AuctionUseCase) RelistAuction(
+	ctx context.Context,
+	sellerId, auctionId string,
+	relistInput RelistInputDTO) (*AuctionOutputDTO, *internal_error.InternalError) {
+	auction, err := au.findSellerAuction(ctx, sellerId, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	var sold bool
+	if auction.Status == auction_entity.Completed {
+		bidWinning, _, err := au.winningBid(ctx, auction)
+		if err != nil {
+			return nil, err
+		}
+		sold = bidWinning != nil
+	}
+
+	relisted, err := auction.Relist(relistInput.EndTime, sold)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := au.auctionRepositoryInterface.CreateAuction(ctx, relisted); err != nil {
+		return nil, err
+	}
+
+	auctionOutput := toAuctionOutputDTO(relisted)
+	return &auctionOutput, nil
+}
+
+func (au *AuctionUseCase) findSellerAuction(
+	ctx context.Context,
+	sellerId, auctionId string) (*auction_entity.Auction, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auction.CheckSeller(sellerId); err != nil {
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+func (au *AuctionUseCase) endAuction(
+	ctx context.Context,
+	auction *auction_entity.Auction,
+	eventType event_entity.EventType) *internal_error.InternalError {
+	if err := au.auctionRepositoryInterface.EndAuction(ctx, auction); err != nil {
+		return err
+	}
+
+	if au.eventPublisher != nil {
+		au.eventPublisher.Publish(ctx, event_entity.AuctionEvent{
+			Type:      eventType,
+			AuctionId: auction.Id,
+			EndTime:   auction.EndTime,
+			Timestamp: auction.EndTime,
+		})
+	}
+
+	return nil
+}
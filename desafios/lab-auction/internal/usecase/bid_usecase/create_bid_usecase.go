@@ -3,15 +3,21 @@ package bid_usecase
 import (
 	"context"
 	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
 	"fullcycle-auction_go/internal/internal_error"
 	"os"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
+// BidInputDTO is the body of a bid request. UserId is not read from the
+// body: it is the authenticated user, set by the controller.
 type BidInputDTO struct {
-	UserId    string  `json:"user_id"`
+	UserId    string  `json:"-"`
 	AuctionId string  `json:"auction_id"`
 	Amount    float64 `json:"amount"`
 }
@@ -22,78 +28,109 @@ type BidOutputDTO struct {
 	AuctionId string    `json:"auction_id"`
 	Amount    float64   `json:"amount"`
 	Timestamp time.Time `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Automatic bool      `json:"automatic,omitempty"`
+}
+
+type BidResultOutputDTO struct {
+	Bid    BidOutputDTO         `json:"bid"`
+	Status bid_entity.BidStatus `json:"status"`
+	Reason string               `json:"reason,omitempty"`
 }
 
 type BidUseCase struct {
-	BidRepository bid_entity.BidEntityRepository
+	BidRepository     bid_entity.BidEntityRepository
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	EventPublisher    event_entity.EventPublisher
 
 	timer               *time.Timer
 	maxBatchSize        int
 	batchInsertInterval time.Duration
-	bidChannel          chan bid_entity.Bid
+	bidChannel          chan pendingBid
+
+	// mu guards closed and the sends on bidChannel, so Close never closes
+	// the channel under a sender.
+	mu     sync.RWMutex
+	closed bool
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
-func NewBidUseCase(bidRepository bid_entity.BidEntityRepository) BidUseCaseInterface {
+// pendingBid is a bid waiting for the next batch, with the channel its
+// result is delivered on.
+type pendingBid struct {
+	bid    bid_entity.Bid
+	result chan bid_entity.BidResult
+}
+
+// NewBidUseCase creates the bid use case. Accepted bids are announced to
+// eventPublisher, which may be nil; sealed bids never are.
+func NewBidUseCase(
+	bidRepository bid_entity.BidEntityRepository,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	eventPublisher event_entity.EventPublisher) BidUseCaseInterface {
 	maxSizeInterval := getMaxBatchSizeInterval()
 	maxBatchSize := getMaxBatchSize()
 
 	bidUseCase := &BidUseCase{
 		BidRepository:       bidRepository,
+		AuctionRepository:   auctionRepository,
+		EventPublisher:      eventPublisher,
 		maxBatchSize:        maxBatchSize,
 		batchInsertInterval: maxSizeInterval,
 		timer:               time.NewTimer(maxSizeInterval),
-		bidChannel:          make(chan bid_entity.Bid, maxBatchSize),
+		bidChannel:          make(chan pendingBid, maxBatchSize),
+		done:                make(chan struct{}),
 	}
 
-	bidUseCase.triggerCreateRoutine(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	bidUseCase.cancel = cancel
+	bidUseCase.triggerCreateRoutine(ctx)
 
 	return bidUseCase
 }
 
-var bidBatch []bid_entity.Bid
-
 type BidUseCaseInterface interface {
 	CreateBid(
 		ctx context.Context,
-		bidInputDTO BidInputDTO) *internal_error.InternalError
+		bidInputDTO BidInputDTO) (*BidResultOutputDTO, *internal_error.InternalError)
 
-	FindWinningBidByAuctionId(
-		ctx context.Context, auctionId string) (*BidOutputDTO, *internal_error.InternalError)
+	CreateProxyBid(
+		ctx context.Context,
+		proxyBidInputDTO ProxyBidInputDTO) (*ProxyBidOutputDTO, *internal_error.InternalError)
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError)
+		ctx context.Context,
+		auctionId string,
+		findInput FindBidsInputDTO) (*BidPageOutputDTO, *internal_error.InternalError)
+
+	Close(ctx context.Context) *internal_error.InternalError
 }
 
 func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 	go func() {
-		defer close(bu.bidChannel)
+		defer close(bu.done)
+
+		var bidBatch []pendingBid
 
 		for {
 			select {
-			case bidEntity, ok := <-bu.bidChannel:
+			case pending, ok := <-bu.bidChannel:
 				if !ok {
-					if len(bidBatch) > 0 {
-						if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-							logger.Error("error trying to process bid batch list", err)
-						}
-					}
+					bu.timer.Stop()
+					bu.processBatch(ctx, bidBatch)
 					return
 				}
 
-				bidBatch = append(bidBatch, bidEntity)
+				bidBatch = append(bidBatch, pending)
 
 				if len(bidBatch) >= bu.maxBatchSize {
-					if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-						logger.Error("error trying to process bid batch list", err)
-					}
+					bu.processBatch(ctx, bidBatch)
 
 					bidBatch = nil
 					bu.timer.Reset(bu.batchInsertInterval)
 				}
 			case <-bu.timer.C:
-				if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-					logger.Error("error trying to process bid batch list", err)
-				}
+				bu.processBatch(ctx, bidBatch)
 				bidBatch = nil
 				bu.timer.Reset(bu.batchInsertInterval)
 			}
@@ -101,25 +138,150 @@ func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 	}()
 }
 
+// processBatch stores the batch and hands each bid its result.
+func (bu *BidUseCase) processBatch(ctx context.Context, bidBatch []pendingBid) {
+	if len(bidBatch) == 0 {
+		return
+	}
+
+	bids := make([]bid_entity.Bid, len(bidBatch))
+	for i, pending := range bidBatch {
+		bids[i] = pending.bid
+	}
+
+	results, err := bu.BidRepository.CreateBid(ctx, bids)
+	if err != nil {
+		logger.Error("error trying to process bid batch list", err)
+	}
+
+	if err != nil {
+		for _, pending := range bidBatch {
+			pending.result <- bid_entity.BidResult{Err: err}
+		}
+		return
+	}
+
+	var placed []bid_entity.PlacedBid
+	for i, pending := range bidBatch {
+		if results[i].Err != nil {
+			logger.Error("error trying to place bid", results[i].Err)
+		}
+		if results[i].Status == bid_entity.BidAccepted && !results[i].Sealed {
+			placed = append(placed, bid_entity.PlacedBid{Bid: pending.bid, EndTime: results[i].EndTime})
+		}
+		placed = append(placed, results[i].AutoBids...)
+	}
+
+	// The bids of a batch are placed concurrently, so batch order is not the
+	// order they took the lead in. Each accepted bid beat the previous high
+	// bid of its auction, so ordering by amount restores that sequence.
+	sort.SliceStable(placed, func(i, j int) bool {
+		return placed[i].Bid.Amount < placed[j].Bid.Amount
+	})
+	for _, placedBid := range placed {
+		bu.publishHighBid(ctx, placedBid)
+	}
+
+	for i, pending := range bidBatch {
+		pending.result <- results[i]
+	}
+}
+
+func (bu *BidUseCase) publishHighBid(ctx context.Context, placed bid_entity.PlacedBid) {
+	if bu.EventPublisher == nil {
+		return
+	}
+
+	bu.EventPublisher.Publish(ctx, event_entity.AuctionEvent{
+		Type:      event_entity.HighBid,
+		AuctionId: placed.Bid.AuctionId,
+		BidId:     placed.Bid.Id,
+		UserId:    placed.Bid.UserId,
+		Amount:    placed.Bid.Amount,
+		EndTime:   placed.EndTime,
+		Timestamp: placed.Bid.Timestamp,
+	})
+}
+
+// CreateBid queues the bid for the next batch and waits for its result. If
+// ctx ends first the bid is still placed, but its result is discarded.
 func (bu *BidUseCase) CreateBid(
 	ctx context.Context,
-	bidInputDTO BidInputDTO) *internal_error.InternalError {
+	bidInputDTO BidInputDTO) (*BidResultOutputDTO, *internal_error.InternalError) {
 
 	bidEntity, err := bid_entity.CreateBid(bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	bu.bidChannel <- *bidEntity
+	pending := pendingBid{
+		bid:    *bidEntity,
+		result: make(chan bid_entity.BidResult, 1),
+	}
 
-	return nil
+	bu.mu.RLock()
+	if bu.closed {
+		bu.mu.RUnlock()
+		return nil, internal_error.NewInternalServerError("Bid was not placed: service is shutting down")
+	}
+	select {
+	case bu.bidChannel <- pending:
+		bu.mu.RUnlock()
+	case <-ctx.Done():
+		bu.mu.RUnlock()
+		return nil, internal_error.NewInternalServerError("Bid was not placed: request cancelled")
+	}
+
+	select {
+	case result := <-pending.result:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		return &BidResultOutputDTO{
+			Bid: BidOutputDTO{
+				Id:        bidEntity.Id,
+				UserId:    bidEntity.UserId,
+				AuctionId: bidEntity.AuctionId,
+				Amount:    bidEntity.Amount,
+				Timestamp: bidEntity.Timestamp,
+			},
+			Status: result.Status,
+			Reason: result.Reason,
+		}, nil
+	case <-ctx.Done():
+		return nil, internal_error.NewInternalServerError("Request cancelled before the bid result was known")
+	}
+}
+
+// Close stops accepting bids and waits for the pending batch to be stored.
+// If ctx ends first, the flush is cancelled and the bids still in it are lost.
+func (bu *BidUseCase) Close(ctx context.Context) *internal_error.InternalError {
+	bu.mu.Lock()
+	if !bu.closed {
+		bu.closed = true
+		close(bu.bidChannel)
+	}
+	bu.mu.Unlock()
+
+	select {
+	case <-bu.done:
+		bu.cancel()
+		return nil
+	case <-ctx.Done():
+		bu.cancel()
+		logger.Error("bid batch was not flushed before the shutdown deadline", ctx.Err())
+		return internal_error.NewInternalServerError("Bid batch was not flushed before the shutdown deadline")
+	}
 }
 
+// getMaxBatchSizeInterval defaults to 1s, not 3m: a bid request now waits
+// for its batch, so a lone bid must not wait minutes for a reply.
 func getMaxBatchSizeInterval() time.Duration {
 	batchInsertInterval := os.Getenv("BATCH_INSERT_INTERVAL")
 	duration, err := time.ParseDuration(batchInsertInterval)
 	if err != nil {
-		return 3 * time.Minute
+		return time.Second
 	}
 
 	return duration
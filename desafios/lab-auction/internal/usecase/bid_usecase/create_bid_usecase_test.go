@@ -0,0 +1,302 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/event_entity"
+	"fullcycle-auction_go/internal/entity/page_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type bidRepositoryFake struct {
+	mu      sync.Mutex
+	batches [][]bid_entity.Bid
+	result  func(bid bid_entity.Bid) bid_entity.BidResult
+}
+
+func (r *bidRepositoryFake) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) ([]bid_entity.BidResult, *internal_error.InternalError) {
+	r.mu.Lock()
+	r.batches = append(r.batches, bidEntities)
+	r.mu.Unlock()
+
+	results := make([]bid_entity.BidResult, len(bidEntities))
+	for i, bid := range bidEntities {
+		results[i] = r.result(bid)
+	}
+	return results, nil
+}
+
+func (r *bidRepositoryFake) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	page page_entity.Page) ([]bid_entity.Bid, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func (r *bidRepositoryFake) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (r *bidRepositoryFake) FindRankedBidsByAuctionId(
+	ctx context.Context, auctionId string, limit int) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (r *bidRepositoryFake) CreateProxyBid(
+	ctx context.Context, proxyBid *bid_entity.ProxyBid) bid_entity.ProxyBidResult {
+	return bid_entity.ProxyBidResult{}
+}
+
+type eventPublisherFake struct {
+	events chan event_entity.AuctionEvent
+}
+
+func (p *eventPublisherFake) Publish(ctx context.Context, event event_entity.AuctionEvent) {
+	p.events <- event
+}
+
+// acceptAbove aceita lances acima de 100 e rejeita os demais como outbid.
+func acceptAbove(bid bid_entity.Bid) bid_entity.BidResult {
+	if bid.Amount > 100 {
+		return bid_entity.BidResult{Status: bid_entity.BidAccepted}
+	}
+	return bid_entity.BidResult{Status: bid_entity.BidOutbid, Reason: "too low"}
+}
+
+func newBidInput(amount float64) BidInputDTO {
+	return BidInputDTO{
+		UserId:    uuid.New().String(),
+		AuctionId: uuid.New().String(),
+		Amount:    amount,
+	}
+}
+
+func TestCreateBid_ReturnsOutcomeOfEachBid(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "2")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	repository := &bidRepositoryFake{result: acceptAbove}
+	useCase := NewBidUseCase(repository, nil, nil)
+
+	type outcome struct {
+		amount float64
+		result *BidResultOutputDTO
+		err    *internal_error.InternalError
+	}
+	outcomes := make(chan outcome, 2)
+	for _, amount := range []float64{150, 50} {
+		go func(amount float64) {
+			result, err := useCase.CreateBid(context.Background(), newBidInput(amount))
+			outcomes <- outcome{amount, result, err}
+		}(amount)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case o := <-outcomes:
+			if o.err != nil {
+				t.Fatalf("Erro inesperado: %v", o.err)
+			}
+			expected := bid_entity.BidAccepted
+			if o.amount <= 100 {
+				expected = bid_entity.BidOutbid
+			}
+			if o.result.Status != expected || o.result.Bid.Amount != o.amount {
+				t.Errorf("Esperado %s para o lance %.2f, mas obteve %+v", expected, o.amount, o.result)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("O lote cheio deveria ser processado sem esperar o intervalo")
+		}
+	}
+
+	if len(repository.batches) != 1 {
+		t.Errorf("Esperado 1 lote, mas obteve %d", len(repository.batches))
+	}
+}
+
+func TestCreateBid_FlushesOnInterval(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "50ms")
+	useCase := NewBidUseCase(&bidRepositoryFake{result: acceptAbove}, nil, nil)
+
+	result, err := useCase.CreateBid(context.Background(), newBidInput(200))
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if result.Status != bid_entity.BidAccepted {
+		t.Errorf("Esperado status accepted, mas obteve %s", result.Status)
+	}
+}
+
+func TestGetMaxBatchSizeInterval(t *testing.T) {
+	t.Setenv("BATCH_INSERT_INTERVAL", "")
+	if interval := getMaxBatchSizeInterval(); interval != time.Second {
+		t.Errorf("Esperado intervalo padrão de 1s, mas obteve %v", interval)
+	}
+
+	t.Setenv("BATCH_INSERT_INTERVAL", "250ms")
+	if interval := getMaxBatchSizeInterval(); interval != 250*time.Millisecond {
+		t.Errorf("Esperado intervalo de 250ms, mas obteve %v", interval)
+	}
+}
+
+func TestCreateBid_InvalidInputIsRejectedBeforeBatching(t *testing.T) {
+	repository := &bidRepositoryFake{result: acceptAbove}
+	useCase := NewBidUseCase(repository, nil, nil)
+
+	_, err := useCase.CreateBid(context.Background(), BidInputDTO{UserId: "invalid", Amount: 10})
+	if err == nil || err.Err != "bad_request" {
+		t.Errorf("Esperado bad request, mas obteve %v", err)
+	}
+}
+
+func TestCreateBid_StopsWaitingWhenContextEnds(t *testing.T) {
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	useCase := NewBidUseCase(&bidRepositoryFake{result: acceptAbove}, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := useCase.CreateBid(ctx, newBidInput(200)); err == nil {
+		t.Error("Esperado erro quando o contexto termina antes do resultado")
+	}
+}
+
+func TestClose_FlushesPendingBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	repository := &bidRepositoryFake{result: acceptAbove}
+	useCase := NewBidUseCase(repository, nil, nil)
+
+	results := make(chan *BidResultOutputDTO, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			result, _ := useCase.CreateBid(context.Background(), newBidInput(200))
+			results <- result
+		}()
+	}
+
+	// Espera os lances entrarem no lote antes de desligar.
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := useCase.Close(ctx); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := <-results; result == nil || result.Status != bid_entity.BidAccepted {
+			t.Errorf("Esperado lance aceito no desligamento, mas obteve %+v", result)
+		}
+	}
+
+	if _, err := useCase.CreateBid(context.Background(), newBidInput(200)); err == nil {
+		t.Error("Esperado erro para lance após o Close")
+	}
+}
+
+func TestClose_GivesUpAtDeadline(t *testing.T) {
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	release := make(chan struct{})
+	defer close(release)
+	useCase := NewBidUseCase(&bidRepositoryFake{result: func(bid bid_entity.Bid) bid_entity.BidResult {
+		<-release
+		return bid_entity.BidResult{Status: bid_entity.BidAccepted}
+	}}, nil, nil)
+
+	go useCase.CreateBid(context.Background(), newBidInput(200))
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := useCase.Close(ctx); err == nil {
+		t.Error("Esperado erro quando o lote não é gravado dentro do prazo")
+	}
+}
+
+func TestCreateBid_PublishesAcceptedBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	publisher := &eventPublisherFake{events: make(chan event_entity.AuctionEvent, 2)}
+	useCase := NewBidUseCase(&bidRepositoryFake{result: acceptAbove}, nil, publisher)
+
+	accepted := newBidInput(200)
+	result, err := useCase.CreateBid(context.Background(), accepted)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, err := useCase.CreateBid(context.Background(), newBidInput(50)); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	event := <-publisher.events
+	if event.Type != event_entity.HighBid || event.BidId != result.Bid.Id ||
+		event.AuctionId != accepted.AuctionId || event.Amount != 200 {
+		t.Errorf("Evento inesperado para o lance aceito: %+v", event)
+	}
+	if len(publisher.events) != 0 {
+		t.Errorf("Lance recusado não deveria gerar evento, mas obteve %+v", <-publisher.events)
+	}
+}
+
+func TestCreateBid_PublishesAutomaticBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	publisher := &eventPublisherFake{events: make(chan event_entity.AuctionEvent, 2)}
+	autoBid := bid_entity.Bid{Id: uuid.New().String(), UserId: uuid.New().String(), Amount: 210, Automatic: true}
+	useCase := NewBidUseCase(&bidRepositoryFake{result: func(bid bid_entity.Bid) bid_entity.BidResult {
+		return bid_entity.BidResult{
+			Status:   bid_entity.BidAccepted,
+			AutoBids: []bid_entity.PlacedBid{{Bid: autoBid}},
+		}
+	}}, nil, publisher)
+
+	if _, err := useCase.CreateBid(context.Background(), newBidInput(200)); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	<-publisher.events
+	if event := <-publisher.events; event.BidId != autoBid.Id || event.Amount != 210 {
+		t.Errorf("Esperado evento do lance automático, mas obteve %+v", event)
+	}
+}
+
+func TestProcessBatch_PublishesHighBidsInOrderOfAmount(t *testing.T) {
+	auctionId := uuid.New().String()
+	autoBid := bid_entity.Bid{Id: uuid.New().String(), AuctionId: auctionId, Amount: 250, Automatic: true}
+	publisher := &eventPublisherFake{events: make(chan event_entity.AuctionEvent, 3)}
+	useCase := &BidUseCase{
+		BidRepository: &bidRepositoryFake{result: func(bid bid_entity.Bid) bid_entity.BidResult {
+			result := bid_entity.BidResult{Status: bid_entity.BidAccepted}
+			if bid.Amount == 200 {
+				result.AutoBids = []bid_entity.PlacedBid{{Bid: autoBid}}
+			}
+			return result
+		}},
+		EventPublisher: publisher,
+	}
+
+	// O lance de 300 chega primeiro no lote, mas só assumiu a liderança
+	// depois do de 200 e do lance automático de 250.
+	batch := []pendingBid{
+		{bid: bid_entity.Bid{Id: uuid.New().String(), AuctionId: auctionId, Amount: 300}, result: make(chan bid_entity.BidResult, 1)},
+		{bid: bid_entity.Bid{Id: uuid.New().String(), AuctionId: auctionId, Amount: 200}, result: make(chan bid_entity.BidResult, 1)},
+	}
+	useCase.processBatch(context.Background(), batch)
+
+	for _, amount := range []float64{200, 250, 300} {
+		if event := <-publisher.events; event.Amount != amount {
+			t.Errorf("Esperado evento do lance de %v, mas obteve %+v", amount, event)
+		}
+	}
+	for _, pending := range batch {
+		if result := <-pending.result; result.Status != bid_entity.BidAccepted {
+			t.Errorf("Esperado status accepted, mas obteve %s", result.Status)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+)
+
+// ProxyBidInputDTO is the body of a proxy bid request. Like BidInputDTO, its
+// UserId is the authenticated user.
+type ProxyBidInputDTO struct {
+	UserId    string  `json:"-"`
+	AuctionId string  `json:"auction_id"`
+	MaxAmount float64 `json:"max_amount"`
+}
+
+// ProxyBidOutputDTO reports a proxy bid without its maximum, which stays
+// private to the bidder's request.
+type ProxyBidOutputDTO struct {
+	Id         string               `json:"id"`
+	UserId     string               `json:"user_id"`
+	AuctionId  string               `json:"auction_id"`
+	Timestamp  time.Time            `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Status     bid_entity.BidStatus `json:"status"`
+	Reason     string               `json:"reason,omitempty"`
+	HighestBid float64              `json:"highest_bid"`
+}
+
+// CreateProxyBid registers a maximum the system bids up to on the user's
+// behalf. Unlike plain bids it is not batched: the automatic bids it
+// triggers are placed before it returns.
+func (bu *BidUseCase) CreateProxyBid(
+	ctx context.Context,
+	proxyBidInputDTO ProxyBidInputDTO) (*ProxyBidOutputDTO, *internal_error.InternalError) {
+	proxyBid, err := bid_entity.CreateProxyBid(
+		proxyBidInputDTO.UserId, proxyBidInputDTO.AuctionId, proxyBidInputDTO.MaxAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	bu.mu.RLock()
+	closed := bu.closed
+	bu.mu.RUnlock()
+	if closed {
+		return nil, internal_error.NewInternalServerError("Bid was not placed: service is shutting down")
+	}
+
+	result := bu.BidRepository.CreateProxyBid(ctx, proxyBid)
+	for _, autoBid := range result.AutoBids {
+		bu.publishHighBid(ctx, autoBid)
+	}
+	if result.Err != nil {
+		logger.Error("error trying to place proxy bid", result.Err)
+		return nil, result.Err
+	}
+
+	return &ProxyBidOutputDTO{
+		Id:         proxyBid.Id,
+		UserId:     proxyBid.UserId,
+		AuctionId:  proxyBid.AuctionId,
+		Timestamp:  proxyBid.Timestamp,
+		Status:     result.Status,
+		Reason:     result.Reason,
+		HighestBid: result.HighestBid,
+	}, nil
+}
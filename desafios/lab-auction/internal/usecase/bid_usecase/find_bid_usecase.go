@@ -2,44 +2,65 @@ package bid_usecase
 
 import (
 	"context"
+	"fullcycle-auction_go/internal/entity/page_entity"
 	"fullcycle-auction_go/internal/internal_error"
 )
 
+// FindBidsInputDTO is the query string of a bid listing.
+type FindBidsInputDTO struct {
+	Page     int64 `form:"page" binding:"gte=0"`
+	PageSize int64 `form:"page_size" binding:"gte=0"`
+}
+
+type BidPageOutputDTO struct {
+	Bids       []BidOutputDTO `json:"bids"`
+	Page       int64          `json:"page"`
+	PageSize   int64          `json:"page_size"`
+	Total      int64          `json:"total"`
+	TotalPages int64          `json:"total_pages"`
+}
+
+// FindBidByAuctionId lists a page of the bids of an auction, newest first.
+// The bids of a sealed auction are only listed once it has completed.
 func (bu *BidUseCase) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError) {
-	bidList, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId)
+	ctx context.Context,
+	auctionId string,
+	findInput FindBidsInputDTO) (*BidPageOutputDTO, *internal_error.InternalError) {
+	page, err := page_entity.NewPage(findInput.Page, findInput.PageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	var bidOutputList []BidOutputDTO
-	for _, bid := range bidList {
-		bidOutputList = append(bidOutputList, BidOutputDTO{
-			Id:        bid.Id,
-			UserId:    bid.UserId,
-			AuctionId: bid.AuctionId,
-			Amount:    bid.Amount,
-			Timestamp: bid.Timestamp,
-		})
+	auction, err := bu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.BidsHidden() {
+		return nil, internal_error.NewBadRequestError("Bids are sealed until the auction completes")
 	}
 
-	return bidOutputList, nil
-}
-
-func (bu *BidUseCase) FindWinningBidByAuctionId(
-	ctx context.Context, auctionId string) (*BidOutputDTO, *internal_error.InternalError) {
-	bidEntity, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, auctionId)
+	bidList, total, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId, page)
 	if err != nil {
 		return nil, err
 	}
 
-	bidOutput := &BidOutputDTO{
-		Id:        bidEntity.Id,
-		UserId:    bidEntity.UserId,
-		AuctionId: bidEntity.AuctionId,
-		Amount:    bidEntity.Amount,
-		Timestamp: bidEntity.Timestamp,
+	bidOutputList := make([]BidOutputDTO, len(bidList))
+	for i, bid := range bidList {
+		bidOutputList[i] = BidOutputDTO{
+			Id:        bid.Id,
+			UserId:    bid.UserId,
+			AuctionId: bid.AuctionId,
+			Amount:    bid.Amount,
+			Timestamp: bid.Timestamp,
+			Automatic: bid.Automatic,
+		}
 	}
 
-	return bidOutput, nil
+	return &BidPageOutputDTO{
+		Bids:       bidOutputList,
+		Page:       page.Number,
+		PageSize:   page.Size,
+		Total:      total,
+		TotalPages: page.TotalPages(total),
+	}, nil
 }
@@ -0,0 +1,56 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// auctionFinderFake só implementa FindAuctionById, o único método usado
+// pelas consultas de lances.
+type auctionFinderFake struct {
+	auction_entity.AuctionRepositoryInterface
+	auction *auction_entity.Auction
+}
+
+func (r auctionFinderFake) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	copied := *r.auction
+	return &copied, nil
+}
+
+func TestFindBidByAuctionId_SealedBidsOnlyShownOnceCompleted(t *testing.T) {
+	tests := []struct {
+		auctionType auction_entity.AuctionType
+		status      auction_entity.AuctionStatus
+		hidden      bool
+	}{
+		{auction_entity.SealedFirstPrice, auction_entity.Active, true},
+		{auction_entity.SealedFirstPrice, auction_entity.Cancelled, true},
+		{auction_entity.SealedFirstPrice, auction_entity.Completed, false},
+		{auction_entity.Vickrey, auction_entity.Cancelled, true},
+		{auction_entity.Vickrey, auction_entity.Completed, false},
+		{auction_entity.English, auction_entity.Cancelled, false},
+	}
+
+	for _, tt := range tests {
+		auction := &auction_entity.Auction{Id: uuid.New().String(), Type: tt.auctionType, Status: tt.status}
+		useCase := &BidUseCase{
+			BidRepository:     &bidRepositoryFake{},
+			AuctionRepository: auctionFinderFake{auction: auction},
+		}
+
+		_, err := useCase.FindBidByAuctionId(context.Background(), auction.Id, FindBidsInputDTO{})
+		if tt.hidden && (err == nil || err.Err != "bad_request") {
+			t.Errorf("Esperado bad request ao listar lances de leilão %s com status %d, mas obteve %v",
+				tt.auctionType, tt.status, err)
+		}
+		if !tt.hidden && err != nil {
+			t.Errorf("Erro inesperado ao listar lances de leilão %s com status %d: %v",
+				tt.auctionType, tt.status, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+)
+
+type UserInputDTO struct {
+	Name     string `json:"name" binding:"required,min=2"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// UserUpdateInputDTO holds the profile fields to change; empty fields are
+// left as they are.
+type UserUpdateInputDTO struct {
+	Name     string `json:"name" binding:"omitempty,min=2"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+}
+
+func (u *UserUseCase) CreateUser(
+	ctx context.Context,
+	userInput UserInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	userEntity, err := user_entity.CreateUser(userInput.Name, userInput.Email, userInput.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.UserRepository.CreateUser(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	return toUserOutputDTO(userEntity), nil
+}
+
+// UpdateUser changes the profile of user id, which must be the
+// authenticated user.
+func (u *UserUseCase) UpdateUser(
+	ctx context.Context,
+	authUserId, id string,
+	userInput UserUpdateInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	if authUserId != id {
+		return nil, internal_error.NewForbiddenError("Users can only update their own profile")
+	}
+
+	userEntity, err := u.UserRepository.FindUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := userEntity.Update(userInput.Name, userInput.Email, userInput.Password); err != nil {
+		return nil, err
+	}
+
+	if err := u.UserRepository.UpdateUser(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	return toUserOutputDTO(userEntity), nil
+}
+
+// DeleteUser soft deletes user id, which must be the authenticated user.
+func (u *UserUseCase) DeleteUser(
+	ctx context.Context, authUserId, id string) *internal_error.InternalError {
+	if authUserId != id {
+		return internal_error.NewForbiddenError("Users can only delete their own account")
+	}
+
+	return u.UserRepository.DeleteUser(ctx, id, time.Now())
+}
@@ -4,21 +4,28 @@ import (
 	"context"
 	"fullcycle-auction_go/internal/entity/user_entity"
 	"fullcycle-auction_go/internal/internal_error"
+	"time"
 )
 
-func NewUserUseCase(userRepository user_entity.UserRepositoryInterface) UserUseCaseInterface {
+func NewUserUseCase(
+	userRepository user_entity.UserRepositoryInterface,
+	tokenService user_entity.TokenService) UserUseCaseInterface {
 	return &UserUseCase{
-		userRepository,
+		UserRepository: userRepository,
+		TokenService:   tokenService,
 	}
 }
 
 type UserUseCase struct {
 	UserRepository user_entity.UserRepositoryInterface
+	TokenService   user_entity.TokenService
 }
 
 type UserOutputDTO struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty" time_format:"2006-01-02 15:04:05"`
 }
 
 type UserUseCaseInterface interface {
@@ -27,6 +34,22 @@ type UserUseCaseInterface interface {
 		id string) (*UserOutputDTO, *internal_error.InternalError)
 	FindAllUsers(
 		ctx context.Context) ([]UserOutputDTO, *internal_error.InternalError)
+
+	CreateUser(
+		ctx context.Context,
+		userInput UserInputDTO) (*UserOutputDTO, *internal_error.InternalError)
+	UpdateUser(
+		ctx context.Context,
+		authUserId, id string,
+		userInput UserUpdateInputDTO) (*UserOutputDTO, *internal_error.InternalError)
+	DeleteUser(
+		ctx context.Context, authUserId, id string) *internal_error.InternalError
+
+	Login(
+		ctx context.Context,
+		loginInput LoginInputDTO) (*LoginOutputDTO, *internal_error.InternalError)
+	Authenticate(
+		ctx context.Context, token string) (string, *internal_error.InternalError)
 }
 
 func (u *UserUseCase) FindUserById(
@@ -36,10 +59,7 @@ func (u *UserUseCase) FindUserById(
 		return nil, err
 	}
 
-	return &UserOutputDTO{
-		Id:   userEntity.Id,
-		Name: userEntity.Name,
-	}, nil
+	return toPublicUserOutputDTO(userEntity), nil
 }
 
 func (u *UserUseCase) FindAllUsers(
@@ -51,11 +71,29 @@ func (u *UserUseCase) FindAllUsers(
 
 	usersOutput := make([]UserOutputDTO, 0)
 	for _, user := range usersEntity {
-		usersOutput = append(usersOutput, UserOutputDTO{
-			Id:   user.Id,
-			Name: user.Name,
-		})
+		usersOutput = append(usersOutput, *toPublicUserOutputDTO(&user))
 	}
 
 	return usersOutput, nil
 }
+
+// toUserOutputDTO maps a user to the representation returned to the user
+// themself, which never includes the password hash.
+func toUserOutputDTO(user *user_entity.User) *UserOutputDTO {
+	return &UserOutputDTO{
+		Id:        user.Id,
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// toPublicUserOutputDTO maps a user to the representation anyone can read,
+// which leaves out the email.
+func toPublicUserOutputDTO(user *user_entity.User) *UserOutputDTO {
+	return &UserOutputDTO{
+		Id:        user.Id,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"strings"
+	"time"
+)
+
+type LoginInputDTO struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LoginOutputDTO struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at" time_format:"2006-01-02 15:04:05"`
+}
+
+// unknownUser is checked against the password when the email is not
+// registered. Its hash has the cost of real ones, so the answer takes as long
+// as for a wrong password.
+var unknownUser = &user_entity.User{
+	PasswordHash: "$2a$10$UeW3emxo8Agy/gNxwy/ecu.A6GiHCtCCzRAY/h8XAtu5.AcfRNcKa",
+}
+
+// Login exchanges valid credentials for an access token. Unknown emails and
+// wrong passwords get the same answer in the same time, so the endpoint does
+// not reveal which emails are registered.
+func (u *UserUseCase) Login(
+	ctx context.Context,
+	loginInput LoginInputDTO) (*LoginOutputDTO, *internal_error.InternalError) {
+	invalid := internal_error.NewUnauthorizedError("Invalid email or password")
+
+	userEntity, err := u.UserRepository.FindUserByEmail(ctx, strings.ToLower(strings.TrimSpace(loginInput.Email)))
+	if err != nil {
+		if err.Err == "not_found" {
+			unknownUser.CheckPassword(loginInput.Password)
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	if !userEntity.CheckPassword(loginInput.Password) {
+		return nil, invalid
+	}
+
+	token, expiresAt, err := u.TokenService.IssueToken(userEntity.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginOutputDTO{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Authenticate returns the id of the user the token was issued to. Tokens of
+// deleted users are rejected even before they expire.
+func (u *UserUseCase) Authenticate(
+	ctx context.Context, token string) (string, *internal_error.InternalError) {
+	userId, err := u.TokenService.VerifyToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := u.UserRepository.FindUserById(ctx, userId); err != nil {
+		if err.Err == "not_found" {
+			return "", internal_error.NewUnauthorizedError("Invalid or expired token")
+		}
+		return "", err
+	}
+
+	return userId, nil
+}
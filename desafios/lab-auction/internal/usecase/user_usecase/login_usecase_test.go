@@ -0,0 +1,166 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type userRepositoryFake struct {
+	users map[string]*user_entity.User
+}
+
+func newUserRepositoryFake() *userRepositoryFake {
+	return &userRepositoryFake{users: map[string]*user_entity.User{}}
+}
+
+func (r *userRepositoryFake) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	r.users[userEntity.Id] = userEntity
+	return nil
+}
+
+func (r *userRepositoryFake) UpdateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	r.users[userEntity.Id] = userEntity
+	return nil
+}
+
+func (r *userRepositoryFake) DeleteUser(
+	ctx context.Context, userId string, at time.Time) *internal_error.InternalError {
+	if user, ok := r.users[userId]; ok {
+		user.DeletedAt = at
+	}
+	return nil
+}
+
+func (r *userRepositoryFake) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	if user, ok := r.users[userId]; ok && !user.IsDeleted() {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, internal_error.NewNotFoundError("user not found")
+}
+
+func (r *userRepositoryFake) FindUserByEmail(
+	ctx context.Context, email string) (*user_entity.User, *internal_error.InternalError) {
+	for _, user := range r.users {
+		if user.Email == email && !user.IsDeleted() {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, internal_error.NewNotFoundError("user not found")
+}
+
+func (r *userRepositoryFake) FindAllUsers(
+	ctx context.Context) ([]user_entity.User, *internal_error.InternalError) {
+	return nil, nil
+}
+
+// tokenServiceFake emite o próprio id do usuário como token.
+type tokenServiceFake struct{}
+
+func (tokenServiceFake) IssueToken(userId string) (string, time.Time, *internal_error.InternalError) {
+	return "token-" + userId, time.Now().Add(time.Hour), nil
+}
+
+func (tokenServiceFake) VerifyToken(token string) (string, *internal_error.InternalError) {
+	if len(token) <= len("token-") {
+		return "", internal_error.NewUnauthorizedError("invalid token")
+	}
+	return token[len("token-"):], nil
+}
+
+func newRegisteredUser(t *testing.T) (UserUseCaseInterface, *UserOutputDTO) {
+	t.Helper()
+	useCase := NewUserUseCase(newUserRepositoryFake(), tokenServiceFake{})
+
+	user, err := useCase.CreateUser(context.Background(), UserInputDTO{
+		Name: "Maria", Email: "maria@example.com", Password: "senha-segura"})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	return useCase, user
+}
+
+func TestLogin(t *testing.T) {
+	useCase, user := newRegisteredUser(t)
+
+	login, err := useCase.Login(context.Background(), LoginInputDTO{Email: "Maria@Example.com", Password: "senha-segura"})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if userId, err := useCase.Authenticate(context.Background(), login.Token); err != nil || userId != user.Id {
+		t.Errorf("Esperado usuário %s autenticado, mas obteve %q com erro %v", user.Id, userId, err)
+	}
+
+	for _, input := range []LoginInputDTO{
+		{Email: "maria@example.com", Password: "senha-errada"},
+		{Email: "outra@example.com", Password: "senha-segura"},
+	} {
+		if _, err := useCase.Login(context.Background(), input); err == nil || err.Err != "unauthorized" {
+			t.Errorf("Esperado unauthorized para %s, mas obteve %v", input.Email, err)
+		}
+	}
+}
+
+func TestLogin_UnknownEmailChecksPasswordAtRealCost(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(unknownUser.PasswordHash))
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("Esperado hash de custo %d, mas obteve %d", bcrypt.DefaultCost, cost)
+	}
+}
+
+func TestFindUserById_HidesEmail(t *testing.T) {
+	useCase, user := newRegisteredUser(t)
+	if user.Email != "maria@example.com" {
+		t.Errorf("Esperado email no cadastro, mas obteve %q", user.Email)
+	}
+
+	found, err := useCase.FindUserById(context.Background(), user.Id)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if found.Email != "" || found.Name != "Maria" {
+		t.Errorf("Esperado perfil público sem email, mas obteve %+v", found)
+	}
+}
+
+func TestAuthenticate_RejectsDeletedUser(t *testing.T) {
+	useCase, user := newRegisteredUser(t)
+	login, _ := useCase.Login(context.Background(), LoginInputDTO{Email: "maria@example.com", Password: "senha-segura"})
+
+	if err := useCase.DeleteUser(context.Background(), user.Id, user.Id); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if _, err := useCase.Authenticate(context.Background(), login.Token); err == nil || err.Err != "unauthorized" {
+		t.Errorf("Esperado unauthorized para usuário removido, mas obteve %v", err)
+	}
+}
+
+func TestUpdateUser_OnlyOwnProfile(t *testing.T) {
+	useCase, user := newRegisteredUser(t)
+
+	if _, err := useCase.UpdateUser(context.Background(), "outro-usuario", user.Id,
+		UserUpdateInputDTO{Name: "Invasor"}); err == nil || err.Err != "forbidden" {
+		t.Errorf("Esperado forbidden, mas obteve %v", err)
+	}
+	if err := useCase.DeleteUser(context.Background(), "outro-usuario", user.Id); err == nil || err.Err != "forbidden" {
+		t.Errorf("Esperado forbidden, mas obteve %v", err)
+	}
+
+	updated, err := useCase.UpdateUser(context.Background(), user.Id, user.Id, UserUpdateInputDTO{Name: "Maria Silva"})
+	if err != nil || updated.Name != "Maria Silva" {
+		t.Errorf("Esperado nome atualizado, mas obteve %+v com erro %v", updated, err)
+	}
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+	DecisionBlocked = "blocked"
+)
+
+type Event struct {
+	Time           time.Time  `json:"time"`
+	Decision       string     `json:"decision"`
+	Rule           string     `json:"rule"`
+	Key            string     `json:"key"`
+	IP             string     `json:"ip,omitempty"`
+	TokenHash      string     `json:"token_hash,omitempty"`
+	Tenant         string     `json:"tenant,omitempty"`
+	Method         string     `json:"method,omitempty"`
+	Path           string     `json:"path,omitempty"`
+	Count          int64      `json:"count"`
+	Limit          int        `json:"limit"`
+	BlockExpiresAt *time.Time `json:"block_expires_at,omitempty"`
+}
+
+type Logger struct {
+	mu         sync.Mutex
+	encoder    *json.Encoder
+	sampleRate float64
+	random     func() float64
+	now        func() time.Time
+}
+
+// NewLogger grava um evento JSON por linha em out. Eventos de negação e
+// bloqueio são sempre gravados; eventos permitidos são amostrados por sampleRate
+// (0 desativa, 1 grava todos).
+func NewLogger(out io.Writer, sampleRate float64) *Logger {
+	return &Logger{
+		encoder:    json.NewEncoder(out),
+		sampleRate: sampleRate,
+		random:     rand.Float64,
+		now:        time.Now,
+	}
+}
+
+func (l *Logger) Log(event Event) error {
+	if event.Decision == DecisionAllowed && !l.sampled() {
+		return nil
+	}
+
+	if event.Time.IsZero() {
+		event.Time = l.now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.encoder.Encode(event)
+}
+
+func (l *Logger) sampled() bool {
+	if l.sampleRate <= 0 {
+		return false
+	}
+	if l.sampleRate >= 1 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.random() < l.sampleRate
+}
+
+// HashToken evita que tokens de API sejam gravados em texto puro no log,
+// mantendo-os pesquisáveis pelo comando de consulta.
+func HashToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_AlwaysLogsDeniedAndBlocked(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, 0)
+
+	logger.Log(Event{Decision: DecisionAllowed, Key: "ip:1.1.1.1"})
+	logger.Log(Event{Decision: DecisionDenied, Key: "ip:1.1.1.1"})
+	logger.Log(Event{Decision: DecisionBlocked, Key: "ip:1.1.1.1"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %s", len(lines), buf.String())
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Decision != DecisionDenied {
+		t.Errorf("Expected decision 'denied', got '%s'", event.Decision)
+	}
+	if event.Time.IsZero() {
+		t.Error("Event time should be set")
+	}
+}
+
+func TestLogger_SamplesAllowed(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, 0.5)
+
+	values := []float64{0.1, 0.9, 0.4, 0.6}
+	logger.random = func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+
+	for i := 0; i < 4; i++ {
+		logger.Log(Event{Decision: DecisionAllowed})
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 sampled events, got %d", len(lines))
+	}
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	rf, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:                "dddddddd\n",
+		BackupPath(path, 1): "cccccccc\n",
+		BackupPath(path, 2): "bbbbbbbb\n",
+	}
+	for file, content := range expected {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q", file, content, string(data))
+		}
+	}
+
+	if _, err := os.Stat(BackupPath(path, 3)); !os.IsNotExist(err) {
+		t.Error("Only 2 backups should be kept")
+	}
+}
+
+func TestRotatingFile_KeepsWritingAfterFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	rf, err := NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rf.Close()
+
+	if _, err := rf.Write([]byte("aaaaaaaa\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Um diretório não vazio no lugar do backup impede a rotação.
+	blocker := BackupPath(path, 1)
+	if err := os.MkdirAll(filepath.Join(blocker, "dir"), 0o755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := rf.Write([]byte("bbbbbbbb\n")); err == nil {
+		t.Fatal("Expected an error when the rotation fails")
+	}
+
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := rf.Write([]byte("cccccccc\n")); err != nil {
+		t.Fatalf("Expected writes to resume after the rotation failure, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "cccccccc\n" {
+		t.Errorf("Expected %s to contain %q, got %q", path, "cccccccc\n", string(data))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	now := time.Now().UTC()
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, 1)
+
+	logger.Log(Event{Time: now.Add(-2 * time.Hour), Decision: DecisionDenied, IP: "10.0.0.1"})
+	logger.Log(Event{Time: now, Decision: DecisionBlocked, IP: "10.0.0.1"})
+	logger.Log(Event{Time: now, Decision: DecisionDenied, IP: "10.0.0.2"})
+	logger.Log(Event{Time: now, Decision: DecisionDenied, TokenHash: HashToken("secret")})
+	buf.WriteString("not json\n")
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{name: "by IP", filter: Filter{IP: "10.0.0.1"}, expected: 2},
+		{name: "by IP and decision", filter: Filter{IP: "10.0.0.1", Decision: DecisionBlocked}, expected: 1},
+		{name: "by IP since", filter: Filter{IP: "10.0.0.1", Since: now.Add(-time.Hour)}, expected: 1},
+		{name: "by token", filter: Filter{Token: "secret"}, expected: 1},
+		{name: "by unknown token", filter: Filter{Token: "other"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := 0
+			err := Search(bytes.NewReader(buf.Bytes()), tt.filter, func(Event) error {
+				matches++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if matches != tt.expected {
+				t.Errorf("Expected %d matches, got %d", tt.expected, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type Filter struct {
+	IP       string
+	Token    string
+	Tenant   string
+	Decision string
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f Filter) Match(event Event) bool {
+	if f.IP != "" && event.IP != f.IP {
+		return false
+	}
+	if f.Token != "" && event.TokenHash != HashToken(f.Token) {
+		return false
+	}
+	if f.Tenant != "" && event.Tenant != f.Tenant {
+		return false
+	}
+	if f.Decision != "" && event.Decision != f.Decision {
+		return false
+	}
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Search lê eventos JSON (um por linha) de r e chama fn para cada evento
+// que satisfaz o filtro. Linhas inválidas são ignoradas.
+func Search(r io.Reader, filter Filter, fn func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if !filter.Match(event) {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate renomeia audit.log -> audit.log.1 -> audit.log.2 ..., descartando
+// o arquivo mais antigo além de maxBackups. Se a rotação falhar, o arquivo
+// atual é reaberto para que as próximas escritas não encontrem um arquivo
+// fechado.
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return rf.reopen(fmt.Errorf("failed to close audit log: %w", err))
+	}
+
+	if rf.maxBackups > 0 {
+		os.Remove(BackupPath(rf.path, rf.maxBackups))
+		for i := rf.maxBackups - 1; i >= 1; i-- {
+			os.Rename(BackupPath(rf.path, i), BackupPath(rf.path, i+1))
+		}
+		if err := os.Rename(rf.path, BackupPath(rf.path, 1)); err != nil {
+			return rf.reopen(fmt.Errorf("failed to rotate audit log: %w", err))
+		}
+	} else if err := os.Remove(rf.path); err != nil {
+		return rf.reopen(fmt.Errorf("failed to rotate audit log: %w", err))
+	}
+
+	return rf.open()
+}
+
+// reopen reabre rf.path em modo append após uma rotação que falhou e
+// devolve o erro da rotação.
+func (rf *RotatingFile) reopen(rotateErr error) error {
+	if err := rf.open(); err != nil {
+		return errors.Join(rotateErr, err)
+	}
+	return rotateErr
+}
+
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signals resume o que a resposta do upstream diz sobre o limite dele.
+type signals struct {
+	// pause é quanto tempo esperar antes da próxima requisição ao host.
+	pause time.Duration
+	// limit é o limite anunciado convertido para req/s (0 se ausente).
+	limit int
+}
+
+// parseSignals lê Retry-After e os headers de rate limit mais comuns:
+// RateLimit-Remaining/RateLimit-Reset e as variantes X-RateLimit-*, o header
+// combinado dos drafts da IETF (RateLimit: limit=100, remaining=0, reset=30
+// ou RateLimit: "default";r=0;t=30) e RateLimit-Policy (100;w=60 ou
+// "default";q=100;w=60).
+func parseSignals(resp *http.Response, now time.Time) signals {
+	var s signals
+
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		s.pause = retryAfter
+	}
+
+	remaining, hasRemaining := firstInt(resp.Header, "RateLimit-Remaining", "X-RateLimit-Remaining")
+	reset, hasReset := firstInt(resp.Header, "RateLimit-Reset", "X-RateLimit-Reset")
+
+	if params := parseParams(resp.Header.Get("RateLimit")); len(params) > 0 {
+		if value, ok := intParam(params, "remaining", "r"); ok {
+			remaining, hasRemaining = value, true
+		}
+		if value, ok := intParam(params, "reset", "t"); ok {
+			reset, hasReset = value, true
+		}
+	}
+
+	if hasRemaining && remaining <= 0 && hasReset {
+		if pause := resetDuration(reset, now); pause > s.pause {
+			s.pause = pause
+		}
+	}
+
+	s.limit = parsePolicy(resp.Header.Get("RateLimit-Policy"))
+	return s
+}
+
+// parseRetryAfter aceita segundos ou uma data HTTP.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if pause := date.Sub(now); pause > 0 {
+			return pause, true
+		}
+	}
+	return 0, false
+}
+
+// resetDuration interpreta o reset como segundos restantes ou, quando o
+// valor só faz sentido como instante (alguns provedores usam X-RateLimit-Reset
+// assim), como Unix epoch.
+func resetDuration(reset int64, now time.Time) time.Duration {
+	if reset > 1_000_000_000 {
+		return time.Unix(reset, 0).Sub(now)
+	}
+	return time.Duration(reset) * time.Second
+}
+
+// parsePolicy converte a cota anunciada para req/s, arredondando para baixo
+// com mínimo de 1.
+func parsePolicy(value string) int {
+	params := parseParams(value)
+	if len(params) == 0 {
+		return 0
+	}
+
+	quota, ok := intParam(params, "q", "")
+	if !ok || quota <= 0 {
+		return 0
+	}
+	window, ok := intParam(params, "w")
+	if !ok || window <= 0 {
+		window = 1
+	}
+
+	perSecond := int(quota / window)
+	if perSecond < 1 {
+		perSecond = 1
+	}
+	return perSecond
+}
+
+func firstInt(header http.Header, names ...string) (int64, bool) {
+	for _, name := range names {
+		if value, err := strconv.ParseInt(strings.TrimSpace(header.Get(name)), 10, 64); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// parseParams separa "a=1, b=2;c=3" em pares. Itens sem "=" (como um número
+// solto em "100;w=60" ou o nome da política) ficam na chave vazia.
+func parseParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		name, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			name, val = "", name
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, exists := params[name]; !exists {
+			params[name] = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return params
+}
+
+func intParam(params map[string]string, names ...string) (int64, bool) {
+	for _, name := range names {
+		if value, err := strconv.ParseInt(params[name], 10, 64); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,215 @@
+// Package client aplica o rate limiter às chamadas de saída: um
+// http.RoundTripper que limita as requisições por host de destino e respeita
+// os sinais de limite enviados pelo serviço chamado.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"rate-limiter/limiter"
+	"rate-limiter/storage"
+)
+
+var ErrRateLimited = errors.New("outbound rate limit exceeded")
+
+// RateLimitError é retornado quando a requisição não pode ser enviada sem
+// exceder o limite do host. errors.Is(err, ErrRateLimited) é verdadeiro.
+type RateLimitError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s (retry after %s)", ErrRateLimited, e.Host, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Transport limita as requisições por host usando um limiter.Limiter. Com um
+// storage compartilhado (Redis), o limite e as pausas pedidas pelo upstream
+// valem para todas as réplicas.
+type Transport struct {
+	base         http.RoundTripper
+	limiter      *limiter.Limiter
+	storage      storage.Storage
+	defaultLimit int
+	maxWait      time.Duration
+
+	mu     sync.Mutex
+	limits map[string]int
+	learnt map[string]int
+}
+
+type Option func(*Transport)
+
+// WithBase define o RoundTripper que envia as requisições permitidas
+// (padrão: http.DefaultTransport).
+func WithBase(base http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.base = base
+	}
+}
+
+// WithHostLimit define o limite (req/s) de um host, como aparece em
+// req.URL.Host (inclui a porta quando explícita).
+func WithHostLimit(host string, limit int) Option {
+	return func(t *Transport) {
+		t.limits[host] = limit
+	}
+}
+
+// WithDefaultLimit define o limite dos hosts sem WithHostLimit. 0 (padrão)
+// deixa esses hosts sem limite local, mas as pausas pedidas pelo upstream
+// continuam valendo.
+func WithDefaultLimit(limit int) Option {
+	return func(t *Transport) {
+		t.defaultLimit = limit
+	}
+}
+
+// WithMaxWait faz a requisição aguardar por capacidade por até maxWait
+// antes de falhar. Sem essa opção, a falha é imediata.
+func WithMaxWait(maxWait time.Duration) Option {
+	return func(t *Transport) {
+		t.maxWait = maxWait
+	}
+}
+
+// NewTransport cria o Transport. s deve ser o mesmo storage usado por l; é
+// nele que as pausas pedidas pelo upstream são gravadas como bloqueios.
+func NewTransport(l *limiter.Limiter, s storage.Storage, opts ...Option) *Transport {
+	t := &Transport{
+		base:    http.DefaultTransport,
+		limiter: l,
+		storage: s,
+		limits:  make(map[string]int),
+		learnt:  make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	key := hostKey(host)
+
+	if err := t.acquire(req.Context(), host, key); err != nil {
+		// O contrato de RoundTripper exige fechar o corpo mesmo quando a
+		// requisição não é enviada.
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.observe(host, key, resp)
+	return resp, nil
+}
+
+// acquire consulta o limiter até a requisição ser permitida. O bloqueio
+// gravado por observe é verificado pelo próprio limiter antes do contador.
+func (t *Transport) acquire(ctx context.Context, host string, key string) error {
+	deadline := time.Now().Add(t.maxWait)
+
+	for {
+		result, err := t.check(host, key)
+		if err != nil {
+			return err
+		}
+		if result.Allowed {
+			return nil
+		}
+
+		retryAfter := result.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = 10 * time.Millisecond
+		}
+		if time.Now().Add(retryAfter).After(deadline) {
+			return &RateLimitError{Host: host, RetryAfter: retryAfter}
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (t *Transport) check(host string, key string) (*limiter.Result, error) {
+	limit := t.limitFor(host)
+	if limit > 0 {
+		// Sem tempo de bloqueio: exceder o limite local só nega até o fim
+		// da janela.
+		return t.limiter.CheckLimit(key, limit, 0)
+	}
+
+	// Host sem limite local: só as pausas pedidas pelo upstream valem.
+	if inspector, ok := t.storage.(storage.BlockInspector); ok {
+		ttl, err := inspector.BlockTTL(key)
+		if err != nil {
+			return nil, err
+		}
+		return &limiter.Result{Allowed: ttl <= 0, Reason: "blocked", Key: key, RetryAfter: ttl}, nil
+	}
+
+	blocked, err := t.storage.IsBlocked(key)
+	if err != nil {
+		return nil, err
+	}
+	return &limiter.Result{Allowed: !blocked, Reason: "blocked", Key: key}, nil
+}
+
+// limitFor retorna o menor entre o limite configurado e o aprendido do
+// upstream (RateLimit-Policy).
+func (t *Transport) limitFor(host string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limit, exists := t.limits[host]
+	if !exists {
+		limit = t.defaultLimit
+	}
+	if learnt := t.learnt[host]; learnt > 0 && (limit == 0 || learnt < limit) {
+		limit = learnt
+	}
+	return limit
+}
+
+// observe ajusta o host conforme a resposta: pausa pelo Retry-After ou até o
+// reset quando a cota do upstream acabou, e adota o limite anunciado.
+func (t *Transport) observe(host string, key string, resp *http.Response) {
+	signals := parseSignals(resp, time.Now())
+
+	if signals.limit > 0 {
+		t.mu.Lock()
+		t.learnt[host] = signals.limit
+		t.mu.Unlock()
+	}
+
+	if signals.pause > 0 {
+		// Falhar aqui não invalida a resposta já recebida; a próxima
+		// requisição apenas não será pausada.
+		t.storage.SetBlock(key, signals.pause)
+	}
+}
+
+func hostKey(host string) string {
+	return fmt.Sprintf("client:%s", host)
+}
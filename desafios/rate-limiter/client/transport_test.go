@@ -0,0 +1,239 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"rate-limiter/config"
+	"rate-limiter/limiter"
+	"rate-limiter/storage"
+)
+
+func newUpstream(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func newClient(s storage.Storage, opts ...Option) *http.Client {
+	l := limiter.NewLimiter(s, &config.Config{})
+	return &http.Client{Transport: NewTransport(l, s, opts...)}
+}
+
+func get(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestTransport_FailsFastOverLimit(t *testing.T) {
+	server, hits := newUpstream(t, ok)
+	client := newClient(storage.NewMemoryStorage(), WithDefaultLimit(2))
+
+	for i := 0; i < 2; i++ {
+		if err := get(client, server.URL); err != nil {
+			t.Fatalf("Request %d should succeed: %v", i+1, err)
+		}
+	}
+
+	err := get(client, server.URL)
+	var rateLimitErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if rateLimitErr.RetryAfter <= 0 || rateLimitErr.RetryAfter > time.Second {
+		t.Errorf("Expected RetryAfter within the window, got %v", rateLimitErr.RetryAfter)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("Denied request should not reach the upstream, got %d hits", hits.Load())
+	}
+}
+
+type closeTracker struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestTransport_ClosesBodyWhenDenied(t *testing.T) {
+	server, _ := newUpstream(t, ok)
+	client := newClient(storage.NewMemoryStorage(), WithDefaultLimit(1))
+
+	if err := get(client, server.URL); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	body := &closeTracker{Reader: strings.NewReader("payload")}
+	req, err := http.NewRequest("POST", server.URL, body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = client.Transport.RoundTrip(req)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if !body.closed.Load() {
+		t.Error("Denied request body should be closed")
+	}
+}
+
+func TestTransport_WaitsForCapacity(t *testing.T) {
+	server, hits := newUpstream(t, ok)
+	client := newClient(storage.NewMemoryStorage(), WithDefaultLimit(1), WithMaxWait(2*time.Second))
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := get(client, server.URL); err != nil {
+			t.Fatalf("Request %d should succeed: %v", i+1, err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Second request should have waited for the next window, took %v", elapsed)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("Expected 2 hits, got %d", hits.Load())
+	}
+}
+
+func TestTransport_PerHostLimits(t *testing.T) {
+	strict, _ := newUpstream(t, ok)
+	relaxed, _ := newUpstream(t, ok)
+	client := newClient(storage.NewMemoryStorage(), WithHostLimit(strict.Listener.Addr().String(), 1))
+
+	get(client, strict.URL)
+	if err := get(client, strict.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Strict host should be limited, got %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := get(client, relaxed.URL); err != nil {
+			t.Fatalf("Host without a limit should not be limited: %v", err)
+		}
+	}
+}
+
+func TestTransport_HonoursRetryAfter(t *testing.T) {
+	var throttled atomic.Bool
+	throttled.Store(true)
+	server, hits := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if throttled.Swap(false) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	client := newClient(storage.NewMemoryStorage())
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Upstream 429 should be returned to the caller, got %d", resp.StatusCode)
+	}
+
+	err = get(client, server.URL)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected the host to be paused, got %v", err)
+	}
+	if rateLimitErr.RetryAfter <= 29*time.Second || rateLimitErr.RetryAfter > 30*time.Second {
+		t.Errorf("Expected a pause of about 30s, got %v", rateLimitErr.RetryAfter)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Paused request should not reach the upstream, got %d hits", hits.Load())
+	}
+}
+
+func TestTransport_SharesStateThroughRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	server, hits := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", "10")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newReplica := func() *http.Client {
+		s, err := storage.NewRedisStorageFromClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return newClient(s, WithDefaultLimit(100))
+	}
+	first, second := newReplica(), newReplica()
+
+	if err := get(first, server.URL); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := get(second, server.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Quota exhausted on one replica should pause the others, got %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Expected 1 hit, got %d", hits.Load())
+	}
+}
+
+func TestParseSignals(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		pause   time.Duration
+		limit   int
+	}{
+		{name: "retry-after seconds", headers: map[string]string{"Retry-After": "5"}, pause: 5 * time.Second},
+		{name: "retry-after date", headers: map[string]string{"Retry-After": "Fri, 10 Jan 2025 12:00:20 GMT"}, pause: 20 * time.Second},
+		{name: "quota left", headers: map[string]string{"RateLimit-Remaining": "3", "RateLimit-Reset": "10"}},
+		{name: "quota exhausted", headers: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "10"}, pause: 10 * time.Second},
+		{name: "x-ratelimit epoch", headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1736510430"}, pause: 30 * time.Second},
+		{name: "combined header", headers: map[string]string{"RateLimit": "limit=100, remaining=0, reset=7"}, pause: 7 * time.Second},
+		{name: "structured header", headers: map[string]string{"RateLimit": `"default";r=0;t=4`}, pause: 4 * time.Second},
+		{name: "policy", headers: map[string]string{"RateLimit-Policy": "600;w=60"}, limit: 10},
+		{name: "structured policy", headers: map[string]string{"RateLimit-Policy": `"default";q=30;w=60`}, limit: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			for name, value := range tt.headers {
+				resp.Header.Set(name, value)
+			}
+
+			s := parseSignals(resp, now)
+			if s.pause != tt.pause || s.limit != tt.limit {
+				t.Errorf("Expected pause %v and limit %d, got %v and %d", tt.pause, tt.limit, s.pause, s.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"rate-limiter/audit"
+)
+
+func main() {
+	file := flag.String("file", "audit.log", "Arquivo de auditoria (os backups .1, .2, ... também são lidos)")
+	ip := flag.String("ip", "", "Filtra por IP")
+	token := flag.String("token", "", "Filtra por token de API")
+	tenant := flag.String("tenant", "", "Filtra por tenant")
+	decision := flag.String("decision", "", "Filtra por decisão (allowed, denied, blocked)")
+	since := flag.Duration("since", 0, "Considera apenas eventos dos últimos N (ex.: 1h, 30m)")
+	backups := flag.Int("backups", 5, "Número máximo de arquivos rotacionados a ler")
+	flag.Parse()
+
+	if *ip == "" && *token == "" && *tenant == "" {
+		fmt.Fprintf(os.Stderr, "Uso: --ip=<ip>, --token=<token> ou --tenant=<tenant> [--file=audit.log] [--decision=denied] [--since=1h]\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	filter := audit.Filter{
+		IP:       *ip,
+		Token:    *token,
+		Tenant:   *tenant,
+		Decision: *decision,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	paths := make([]string, 0, *backups+1)
+	for i := *backups; i >= 1; i-- {
+		paths = append(paths, audit.BackupPath(*file, i))
+	}
+	paths = append(paths, *file)
+
+	encoder := json.NewEncoder(os.Stdout)
+	matches := 0
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao abrir %s: %v\n", path, err)
+			os.Exit(1)
+		}
+
+		err = audit.Search(f, filter, func(event audit.Event) error {
+			matches++
+			return encoder.Encode(event)
+		})
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao ler %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d evento(s) encontrado(s)\n", matches)
+}
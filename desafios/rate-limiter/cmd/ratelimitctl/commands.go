@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"rate-limiter/storage"
+)
+
+// ctl executa os comandos contra um storage já aberto e escreve o resultado
+// em out.
+type ctl struct {
+	storage storage.Storage
+	out     io.Writer
+}
+
+func (c *ctl) show(key string) error {
+	counters, ok := c.storage.(storage.CounterInspector)
+	if !ok {
+		return errUnsupported("leitura de contadores")
+	}
+	blocks, ok := c.storage.(storage.BlockInspector)
+	if !ok {
+		return errUnsupported("leitura de bloqueios")
+	}
+
+	value, windowTTL, err := counters.Counter(key)
+	if err != nil {
+		return err
+	}
+	blockTTL, err := blocks.BlockTTL(key)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Chave:\t%s\n", key)
+	fmt.Fprintf(w, "Contador:\t%d\n", value)
+	fmt.Fprintf(w, "Janela expira em:\t%s\n", formatTTL(windowTTL))
+	fmt.Fprintf(w, "Bloqueada:\t%t\n", blockTTL > 0)
+	fmt.Fprintf(w, "Bloqueio expira em:\t%s\n", formatTTL(blockTTL))
+	return w.Flush()
+}
+
+func (c *ctl) unblock(key string) error {
+	if err := c.storage.Reset(key); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Chave %s desbloqueada e contador zerado\n", key)
+	return nil
+}
+
+func (c *ctl) setLimit(token string, limit int) error {
+	store, err := c.tokenLimitStore()
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		return fmt.Errorf("o limite deve ser maior que zero")
+	}
+
+	if err := store.SetTokenLimit(token, limit); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Limite do token %s definido em %d req/s\n", token, limit)
+	return nil
+}
+
+func (c *ctl) unsetLimit(token string) error {
+	store, err := c.tokenLimitStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteTokenLimit(token); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Limite do token %s removido; vale RATE_LIMIT_TOKEN_DEFAULT\n", token)
+	return nil
+}
+
+func (c *ctl) listLimits() error {
+	store, err := c.tokenLimitStore()
+	if err != nil {
+		return err
+	}
+
+	limits, err := store.TokenLimits()
+	if err != nil {
+		return err
+	}
+
+	tokens := make([]string, 0, len(limits))
+	for token := range limits {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOKEN\tLIMITE")
+	for _, token := range tokens {
+		fmt.Fprintf(w, "%s\t%d\n", token, limits[token])
+	}
+	return w.Flush()
+}
+
+type offender struct {
+	key      string
+	count    int64
+	blockTTL time.Duration
+}
+
+// top lista as n chaves com maior contador na janela atual, seguidas das
+// chaves bloqueadas sem contador ativo.
+func (c *ctl) top(n int) error {
+	lister, ok := c.storage.(storage.KeyLister)
+	if !ok {
+		return errUnsupported("listagem de chaves")
+	}
+
+	counters, err := lister.Counters()
+	if err != nil {
+		return err
+	}
+	blocks, err := lister.Blocks()
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[string]*offender)
+	for key, count := range counters {
+		byKey[key] = &offender{key: key, count: count}
+	}
+	for key, ttl := range blocks {
+		if o, exists := byKey[key]; exists {
+			o.blockTTL = ttl
+			continue
+		}
+		byKey[key] = &offender{key: key, blockTTL: ttl}
+	}
+
+	offenders := make([]*offender, 0, len(byKey))
+	for _, o := range byKey {
+		offenders = append(offenders, o)
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].count != offenders[j].count {
+			return offenders[i].count > offenders[j].count
+		}
+		if offenders[i].blockTTL != offenders[j].blockTTL {
+			return offenders[i].blockTTL > offenders[j].blockTTL
+		}
+		return offenders[i].key < offenders[j].key
+	})
+	if n > 0 && len(offenders) > n {
+		offenders = offenders[:n]
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAVE\tCONTADOR\tBLOQUEIO")
+	for _, o := range offenders {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", o.key, o.count, formatTTL(o.blockTTL))
+	}
+	return w.Flush()
+}
+
+func (c *ctl) exportLimits(out io.Writer) error {
+	store, err := c.tokenLimitStore()
+	if err != nil {
+		return err
+	}
+
+	limits, err := store.TokenLimits()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(limits)
+}
+
+// importLimits lê um objeto JSON {"token": limite}. Com replace, os tokens
+// que não estão no arquivo voltam ao limite padrão.
+func (c *ctl) importLimits(in io.Reader, replace bool) error {
+	store, err := c.tokenLimitStore()
+	if err != nil {
+		return err
+	}
+
+	var limits map[string]int
+	if err := json.NewDecoder(in).Decode(&limits); err != nil {
+		return fmt.Errorf("JSON inválido: %w", err)
+	}
+	for token, limit := range limits {
+		if limit <= 0 {
+			return fmt.Errorf("limite inválido para o token %s: %d", token, limit)
+		}
+	}
+
+	removed := 0
+	if replace {
+		current, err := store.TokenLimits()
+		if err != nil {
+			return err
+		}
+		for token := range current {
+			if _, keep := limits[token]; keep {
+				continue
+			}
+			if err := store.DeleteTokenLimit(token); err != nil {
+				return err
+			}
+			removed++
+		}
+	}
+
+	for token, limit := range limits {
+		if err := store.SetTokenLimit(token, limit); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(c.out, "%d limite(s) importado(s), %d removido(s)\n", len(limits), removed)
+	return nil
+}
+
+func (c *ctl) tokenLimitStore() (storage.TokenLimitStore, error) {
+	store, ok := c.storage.(storage.TokenLimitStore)
+	if !ok {
+		return nil, errUnsupported("limites de token")
+	}
+	return store, nil
+}
+
+func errUnsupported(feature string) error {
+	return fmt.Errorf("o backend de storage não suporta %s", feature)
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl <= 0 {
+		return "-"
+	}
+	return ttl.Round(time.Millisecond).String()
+}
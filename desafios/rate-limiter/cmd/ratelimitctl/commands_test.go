@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"rate-limiter/storage"
+)
+
+func newTestCtl() (*ctl, *storage.MemoryStorage, *bytes.Buffer) {
+	s := storage.NewMemoryStorage()
+	out := &bytes.Buffer{}
+	return &ctl{storage: s, out: out}, s, out
+}
+
+func TestCtl_ShowAndUnblock(t *testing.T) {
+	c, s, out := newTestCtl()
+
+	for i := 0; i < 3; i++ {
+		s.Increment("ip:10.0.0.1", time.Minute)
+	}
+	s.SetBlock("ip:10.0.0.1", 5*time.Minute)
+
+	if err := c.show("ip:10.0.0.1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"Contador:", "3", "Bloqueada:", "true"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, out.String())
+		}
+	}
+
+	if err := c.unblock("ip:10.0.0.1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if blocked, _ := s.IsBlocked("ip:10.0.0.1"); blocked {
+		t.Error("Key should be unblocked")
+	}
+}
+
+func TestCtl_Top(t *testing.T) {
+	c, s, out := newTestCtl()
+
+	for key, count := range map[string]int{"ip:10.0.0.1": 2, "ip:10.0.0.2": 7, "token:abc": 4} {
+		for i := 0; i < count; i++ {
+			s.Increment(key, time.Minute)
+		}
+	}
+	s.SetBlock("ip:10.0.0.9", time.Minute)
+
+	if err := c.top(3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header and 3 rows, got:\n%s", out.String())
+	}
+	for i, key := range []string{"ip:10.0.0.2", "token:abc", "ip:10.0.0.1"} {
+		if !strings.HasPrefix(lines[i+1], key) {
+			t.Errorf("Row %d should be %s, got %q", i+1, key, lines[i+1])
+		}
+	}
+}
+
+func TestCtl_TokenLimits(t *testing.T) {
+	c, s, out := newTestCtl()
+
+	if err := c.setLimit("abc", 50); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.setLimit("abc", 0); err == nil {
+		t.Error("Expected error for a non-positive limit")
+	}
+	if limit, _ := s.GetTokenLimit("abc"); limit != 50 {
+		t.Errorf("Expected limit 50, got %d", limit)
+	}
+
+	if err := c.unsetLimit("abc"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if limit, _ := s.GetTokenLimit("abc"); limit != 0 {
+		t.Errorf("Expected limit removed, got %d", limit)
+	}
+
+	out.Reset()
+	s.SetTokenLimit("xyz", 10)
+	if err := c.listLimits(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "xyz") || strings.Contains(out.String(), "abc") {
+		t.Errorf("Unexpected list output:\n%s", out.String())
+	}
+}
+
+func TestCtl_ExportImport(t *testing.T) {
+	c, s, _ := newTestCtl()
+	s.SetTokenLimit("abc", 100)
+	s.SetTokenLimit("xyz", 10)
+
+	exported := &bytes.Buffer{}
+	if err := c.exportLimits(exported); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	target, targetStorage, _ := newTestCtl()
+	targetStorage.SetTokenLimit("old", 5)
+
+	if err := target.importLimits(exported, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	limits, _ := targetStorage.TokenLimits()
+	if len(limits) != 2 || limits["abc"] != 100 || limits["xyz"] != 10 {
+		t.Errorf("Expected imported limits to replace the existing ones, got %v", limits)
+	}
+
+	if err := target.importLimits(strings.NewReader(`{"bad": -1}`), false); err == nil {
+		t.Error("Expected error importing a negative limit")
+	}
+	if err := target.importLimits(strings.NewReader(`[1, 2]`), false); err == nil {
+		t.Error("Expected error importing malformed JSON")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"rate-limiter/config"
+	"rate-limiter/storage"
+)
+
+const usage = `Uso: ratelimitctl <comando> [argumentos]
+
+Usa o mesmo storage do servidor (STORAGE_BACKEND, REDIS_*, POSTGRES_DSN,
+BOLT_PATH, lidos do ambiente e do .env).
+
+Comandos:
+  show <chave>                 Contador, janela e bloqueio de uma chave (ex.: ip:10.0.0.1, token:abc)
+  unblock <chave>              Remove o bloqueio e zera o contador da chave
+  top [-n 10]                  Chaves com maior contador e chaves bloqueadas
+  limit set <token> <limite>   Define o limite customizado de um token
+  limit unset <token>          Remove o limite customizado de um token
+  limit list                   Lista os limites customizados
+  export [-o arquivo]          Exporta os limites de token em JSON
+  import [-replace] <arquivo>  Importa limites de token em JSON ("-" lê da entrada padrão)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string) error {
+	switch command {
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	s, err := storage.New(cfg)
+	if err != nil {
+		return err
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	c := &ctl{storage: s, out: os.Stdout}
+
+	switch command {
+	case "show":
+		if len(args) != 1 {
+			return fmt.Errorf("uso: ratelimitctl show <chave>")
+		}
+		return c.show(args[0])
+
+	case "unblock":
+		if len(args) != 1 {
+			return fmt.Errorf("uso: ratelimitctl unblock <chave>")
+		}
+		return c.unblock(args[0])
+
+	case "top":
+		flags := flag.NewFlagSet("top", flag.ContinueOnError)
+		n := flags.Int("n", 10, "Número de chaves")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		return c.top(*n)
+
+	case "limit":
+		return runLimit(c, args)
+
+	case "export":
+		flags := flag.NewFlagSet("export", flag.ContinueOnError)
+		output := flags.String("o", "", "Arquivo de saída (padrão: saída padrão)")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *output == "" {
+			return c.exportLimits(os.Stdout)
+		}
+
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		if err := c.exportLimits(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+
+	case "import":
+		flags := flag.NewFlagSet("import", flag.ContinueOnError)
+		replace := flags.Bool("replace", false, "Remove os limites que não estão no arquivo")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("uso: ratelimitctl import [-replace] <arquivo>")
+		}
+		if flags.Arg(0) == "-" {
+			return c.importLimits(os.Stdin, *replace)
+		}
+
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return c.importLimits(f, *replace)
+
+	default:
+		return fmt.Errorf("comando desconhecido %q\n\n%s", command, usage)
+	}
+}
+
+func runLimit(c *ctl, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: ratelimitctl limit set|unset|list")
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) != 3 {
+			return fmt.Errorf("uso: ratelimitctl limit set <token> <limite>")
+		}
+		limit, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("limite inválido %q", args[2])
+		}
+		return c.setLimit(args[1], limit)
+
+	case "unset":
+		if len(args) != 2 {
+			return fmt.Errorf("uso: ratelimitctl limit unset <token>")
+		}
+		return c.unsetLimit(args[1])
+
+	case "list":
+		return c.listLimits()
+
+	default:
+		return fmt.Errorf("subcomando desconhecido %q: use set, unset ou list", args[0])
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"rate-limiter/storage"
+)
+
+type healthHandler struct {
+	storage      storage.Storage
+	pingTimeout  time.Duration
+	shuttingDown atomic.Bool
+}
+
+func newHealthHandler(s storage.Storage) *healthHandler {
+	return &healthHandler{
+		storage:     s,
+		pingTimeout: 2 * time.Second,
+	}
+}
+
+func (h *healthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, `{"status": "ok"}`)
+}
+
+// Readyz retorna 503 enquanto o servidor drena conexões ou quando o storage
+// não responde, para que o balanceador pare de enviar tráfego.
+func (h *healthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, `{"status": "shutting down"}`)
+		return
+	}
+
+	if pinger, ok := h.storage.(storage.Pinger); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), h.pingTimeout)
+		defer cancel()
+
+		if err := pinger.Ping(ctx); err != nil {
+			writeStatus(w, http.StatusServiceUnavailable, `{"status": "storage unavailable"}`)
+			return
+		}
+	}
+
+	writeStatus(w, http.StatusOK, `{"status": "ready"}`)
+}
+
+func (h *healthHandler) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func writeStatus(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"rate-limiter/storage"
+)
+
+func TestReadyz_ReflectsRedisConnectivity(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	redisStorage, err := storage.NewRedisStorageFromClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer redisStorage.Close()
+
+	health := newHealthHandler(redisStorage)
+	health.pingTimeout = 200 * time.Millisecond
+
+	rec := httptest.NewRecorder()
+	health.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with Redis up, got %d", rec.Code)
+	}
+
+	mr.Close()
+
+	rec = httptest.NewRecorder()
+	health.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 with Redis down, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	health.Healthz(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz to stay 200, got %d", rec.Code)
+	}
+}
+
+func TestReadyz_ShuttingDown(t *testing.T) {
+	health := newHealthHandler(storage.NewMemoryStorage())
+
+	rec := httptest.NewRecorder()
+	health.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+
+	health.MarkShuttingDown()
+
+	rec = httptest.NewRecorder()
+	health.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while shutting down, got %d", rec.Code)
+	}
+}
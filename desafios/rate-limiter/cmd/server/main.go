@@ -1,50 +1,186 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"rate-limiter/audit"
 	"rate-limiter/config"
+	"rate-limiter/geoip"
 	"rate-limiter/limiter"
 	"rate-limiter/middleware"
 	"rate-limiter/storage"
 )
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration (secrets redacted) and exit")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	redisStorage, err := storage.NewRedisStorage(
-		cfg.RedisHost,
-		cfg.RedisPort,
-		cfg.RedisPassword,
-		cfg.RedisDB,
-	)
+	if *printConfig {
+		return cfg.Print(os.Stdout)
+	}
+
+	limiterStorage, err := storage.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize Redis storage: %v", err)
+		return fmt.Errorf("failed to initialize %s storage: %w", cfg.StorageBackend, err)
+	}
+	if closer, ok := limiterStorage.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	renderer := middleware.ResponseRenderer{
+		Status:       cfg.ResponseStatus,
+		JSONTemplate: cfg.ResponseJSONTemplate,
+		HTMLTemplate: cfg.ResponseHTMLTemplate,
+		TextTemplate: cfg.ResponseTextTemplate,
+	}
+	middlewareOpts := []middleware.Option{
+		middleware.WithTokenTenants(cfg.TenantTokens),
+		middleware.WithDeniedHandler(renderer.Render),
+	}
+	if cfg.TenantHeader != "" {
+		middlewareOpts = append(middlewareOpts, middleware.WithTenantHeader(cfg.TenantHeader))
+	}
+	if cfg.AuditLogEnabled {
+		auditOutput, err := openAuditOutput(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize audit log: %w", err)
+		}
+		defer auditOutput.Close()
+
+		auditLogger := audit.NewLogger(auditOutput, cfg.AuditLogSampleRate)
+		middlewareOpts = append(middlewareOpts, middleware.WithAuditLogger(auditLogger))
+	}
+	if cfg.ThrottleEnabled() {
+		middlewareOpts = append(middlewareOpts, middleware.WithThrottle(cfg.ThrottleMaxWait, cfg.ThrottleQueueSize))
 	}
-	defer redisStorage.Close()
 
-	rateLimiter := limiter.NewLimiter(redisStorage, cfg)
-	rateLimiterMiddleware := middleware.NewRateLimiterMiddleware(rateLimiter)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var limiterOpts []limiter.Option
+	var adaptive *limiter.AdaptiveController
+	if cfg.AdaptiveEnabled {
+		adaptive = limiter.NewAdaptiveController(limiter.AdaptiveConfigFrom(cfg))
+		limiterOpts = append(limiterOpts, limiter.WithAdaptiveController(adaptive))
+		go adaptive.Run(ctx)
+	}
+	if cfg.GeoIPEnabled() {
+		resolver, err := geoip.Open(cfg.GeoIPDatabases()...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize geoip: %w", err)
+		}
+		limiterOpts = append(limiterOpts, limiter.WithGeoResolver(resolver))
+		go resolver.Run(ctx, cfg.GeoIPReloadInterval)
+	}
+
+	if sweeper, ok := limiterStorage.(storage.Sweeper); ok {
+		go storage.RunSweeper(ctx, sweeper, cfg.StorageSweepInterval)
+	}
+
+	rateLimiter := limiter.NewLimiter(limiterStorage, cfg, limiterOpts...)
+	rateLimiterMiddleware := middleware.NewRateLimiterMiddleware(rateLimiter, middlewareOpts...)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"message": "Request successful"}`))
 	})
 
+	health := newHealthHandler(limiterStorage)
+
 	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", health.Healthz)
+	mux.HandleFunc("/readyz", health.Readyz)
+	mux.HandleFunc("/metrics", metricsHandler(adaptive))
+	mux.Handle("/ws", rateLimiterMiddleware.ConnectionHandler(echoHandler(ctx, rateLimiter)))
+	mux.Handle("/events", rateLimiterMiddleware.ConnectionHandler(eventsHandler(ctx)))
 	mux.Handle("/", rateLimiterMiddleware.Handler(handler))
 
-	port := ":8080"
-	fmt.Printf("Server starting on port %s\n", port)
+	server := &http.Server{
+		Addr:              cfg.ServerAddr,
+		Handler:           mux,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+		MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+	}
+
+	fmt.Printf("Server starting on %s (TLS: %t)\n", cfg.ServerAddr, cfg.TLSEnabled())
+	fmt.Printf("Storage backend: %s\n", cfg.StorageBackend)
 	fmt.Printf("Rate Limit IP: %d req/s\n", cfg.RateLimitIP)
 	fmt.Printf("Rate Limit Token Default: %d req/s\n", cfg.RateLimitTokenDefault)
+	fmt.Printf("Rate limit mode: %s\n", cfg.RateLimitMode)
+	fmt.Printf("GeoIP rules: %d\n", len(cfg.GeoIPRules))
+	fmt.Printf("Adaptive limits: %t\n", cfg.AdaptiveEnabled)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if cfg.TLSEnabled() {
+			serverErr <- server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+			return
+		}
+		serverErr <- server.ListenAndServe()
+	}()
 
-	if err := http.ListenAndServe(port, mux); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
 	}
+
+	fmt.Println("Shutting down server...")
+	health.MarkShuttingDown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server gracefully: %w", err)
+	}
+
+	fmt.Println("Server stopped")
+	return nil
+}
+
+func openAuditOutput(cfg *config.Config) (io.WriteCloser, error) {
+	if cfg.AuditLogOutput == "" || cfg.AuditLogOutput == "stdout" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	return audit.NewRotatingFile(
+		cfg.AuditLogOutput,
+		int64(cfg.AuditLogMaxSizeMB)*1024*1024,
+		cfg.AuditLogMaxBackups,
+	)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
 }
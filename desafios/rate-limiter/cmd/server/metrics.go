@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"rate-limiter/limiter"
+)
+
+// metricsHandler expõe o estado do limitador adaptativo no formato texto do
+// Prometheus.
+func metricsHandler(adaptive *limiter.AdaptiveController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		stats := limiter.AdaptiveStats{Multiplier: 1}
+		if adaptive != nil {
+			stats = adaptive.Stats()
+		}
+
+		degraded := 0
+		if stats.Degraded {
+			degraded = 1
+		}
+
+		fmt.Fprintln(w, "# HELP rate_limiter_adaptive_multiplier Multiplier currently applied to the configured limits.")
+		fmt.Fprintln(w, "# TYPE rate_limiter_adaptive_multiplier gauge")
+		fmt.Fprintf(w, "rate_limiter_adaptive_multiplier %g\n", stats.Multiplier)
+		fmt.Fprintln(w, "# HELP rate_limiter_adaptive_degraded Whether the last evaluation window was considered degraded.")
+		fmt.Fprintln(w, "# TYPE rate_limiter_adaptive_degraded gauge")
+		fmt.Fprintf(w, "rate_limiter_adaptive_degraded %d\n", degraded)
+		fmt.Fprintln(w, "# HELP rate_limiter_adaptive_error_rate Upstream error rate observed in the last window.")
+		fmt.Fprintln(w, "# TYPE rate_limiter_adaptive_error_rate gauge")
+		fmt.Fprintf(w, "rate_limiter_adaptive_error_rate %g\n", stats.ErrorRate)
+		fmt.Fprintln(w, "# HELP rate_limiter_adaptive_latency_seconds Average upstream latency observed in the last window.")
+		fmt.Fprintln(w, "# TYPE rate_limiter_adaptive_latency_seconds gauge")
+		fmt.Fprintf(w, "rate_limiter_adaptive_latency_seconds %g\n", stats.AvgLatency.Seconds())
+	}
+}
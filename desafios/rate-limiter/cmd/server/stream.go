@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"rate-limiter/limiter"
+	"rate-limiter/stream"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// echoHandler é o endpoint WebSocket de exemplo: devolve cada mensagem
+// recebida, limitada por RATE_LIMIT_MESSAGES. No desligamento, as conexões
+// recebem close 1001 (going away).
+func echoHandler(ctx context.Context, rateLimiter *limiter.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn := stream.NewConn(ws, rateLimiter)
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				conn.CloseWithReason(websocket.CloseGoingAway, "server shutting down")
+			case <-done:
+			}
+		}()
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				if !errors.Is(err, stream.ErrMessageRateExceeded) {
+					conn.Close()
+				}
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// eventsHandler é o endpoint SSE de exemplo: envia a hora atual a cada
+// segundo até o cliente desconectar ou o servidor desligar.
+func eventsHandler(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		// O WriteTimeout do servidor encerraria o stream no meio.
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("failed to clear write deadline: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ctx.Done():
+				fmt.Fprint(w, "event: close\ndata: server shutting down\n\n")
+				flusher.Flush()
+				return
+			case now := <-ticker.C:
+				fmt.Fprintf(w, "data: %s\n\n", now.UTC().Format(time.RFC3339))
+				flusher.Flush()
+			}
+		}
+	}
+}
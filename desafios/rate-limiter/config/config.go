@@ -4,62 +4,464 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"rate-limiter/geoip"
+)
+
+// Modos de RATE_LIMIT_MODE: reject responde 429 imediatamente; throttle
+// segura a requisição em fila até haver capacidade.
+const (
+	ModeReject   = "reject"
+	ModeThrottle = "throttle"
 )
 
 type Config struct {
-	RateLimitIP             int
-	RateLimitIPBlockTime    time.Duration
-	RateLimitTokenDefault   int
-	RateLimitTokenBlockTime time.Duration
-	RedisHost               string
-	RedisPort               string
-	RedisPassword           string
-	RedisDB                 int
+	RateLimitIP                int
+	RateLimitIPBlockTime       time.Duration
+	RateLimitTokenDefault      int
+	RateLimitTokenBlockTime    time.Duration
+	RateLimitGlobal            int
+	RateLimitGlobalBlockTime   time.Duration
+	RateLimitTenantDefault     int
+	RateLimitTenantBlockTime   time.Duration
+	RateLimitTenantLimits      map[string]int
+	RateLimitIPWithToken       bool
+	TenantHeader               string
+	TenantTokens               map[string]string
+	RateLimitConnectionIP      int
+	RateLimitConnectionToken   int
+	RateLimitConnectionBlock   time.Duration
+	RateLimitMessages          int
+	AdaptiveEnabled            bool
+	AdaptiveInterval           time.Duration
+	AdaptiveLatencyThreshold   time.Duration
+	AdaptiveErrorRateThreshold float64
+	AdaptiveMinSamples         int
+	AdaptiveDecreaseFactor     float64
+	AdaptiveIncreaseStep       float64
+	AdaptiveMinMultiplier      float64
+	GeoIPCountryDB             string
+	GeoIPASNDB                 string
+	GeoIPRules                 geoip.Rules
+	GeoIPBlockTime             time.Duration
+	GeoIPReloadInterval        time.Duration
+	RateLimitMode              string
+	ThrottleMaxWait            time.Duration
+	ThrottleQueueSize          int
+	ResponseStatus             int
+	ResponseJSONTemplate       string
+	ResponseHTMLTemplate       string
+	ResponseTextTemplate       string
+	StorageBackend             string
+	RedisHost                  string
+	RedisPort                  string
+	RedisPassword              string
+	RedisDB                    int
+	PostgresDSN                string
+	BoltPath                   string
+	StorageSweepInterval       time.Duration
+	AuditLogEnabled            bool
+	AuditLogOutput             string
+	AuditLogSampleRate         float64
+	AuditLogMaxSizeMB          int
+	AuditLogMaxBackups         int
+	ServerAddr                 string
+	ServerReadTimeout          time.Duration
+	ServerReadHeaderTimeout    time.Duration
+	ServerWriteTimeout         time.Duration
+	ServerIdleTimeout          time.Duration
+	ServerMaxHeaderBytes       int
+	ServerShutdownTimeout      time.Duration
+	TLSCertFile                string
+	TLSKeyFile                 string
+
+	settings []setting
 }
 
+// Load lê a configuração das variáveis de ambiente (e do .env, se existir).
+// Valores malformados ou inválidos não caem silenciosamente no padrão: todos
+// os problemas encontrados são retornados juntos em um *ValidationError.
 func Load() (*Config, error) {
 	_ = godotenv.Load()
 
+	l := &loader{}
 	cfg := &Config{}
 
-	cfg.RateLimitIP = getEnvAsInt("RATE_LIMIT_IP", 10)
-	cfg.RateLimitIPBlockTime = time.Duration(getEnvAsInt("RATE_LIMIT_IP_BLOCK_TIME", 300)) * time.Second
-	cfg.RateLimitTokenDefault = getEnvAsInt("RATE_LIMIT_TOKEN_DEFAULT", 100)
-	cfg.RateLimitTokenBlockTime = time.Duration(getEnvAsInt("RATE_LIMIT_TOKEN_BLOCK_TIME", 300)) * time.Second
+	cfg.RateLimitIP = l.getEnvAsInt("RATE_LIMIT_IP", 10)
+	cfg.RateLimitIPBlockTime = l.getEnvAsDuration("RATE_LIMIT_IP_BLOCK_TIME", 300*time.Second)
+	cfg.RateLimitTokenDefault = l.getEnvAsInt("RATE_LIMIT_TOKEN_DEFAULT", 100)
+	cfg.RateLimitTokenBlockTime = l.getEnvAsDuration("RATE_LIMIT_TOKEN_BLOCK_TIME", 300*time.Second)
+	cfg.RateLimitGlobal = l.getEnvAsInt("RATE_LIMIT_GLOBAL", 0)
+	cfg.RateLimitGlobalBlockTime = l.getEnvAsDuration("RATE_LIMIT_GLOBAL_BLOCK_TIME", 0)
+	cfg.RateLimitTenantDefault = l.getEnvAsInt("RATE_LIMIT_TENANT_DEFAULT", 0)
+	cfg.RateLimitTenantBlockTime = l.getEnvAsDuration("RATE_LIMIT_TENANT_BLOCK_TIME", 0)
+	cfg.RateLimitIPWithToken = l.getEnvAsBool("RATE_LIMIT_IP_WITH_TOKEN", false)
+	cfg.RateLimitTenantLimits = l.getEnvAsIntMap("RATE_LIMIT_TENANT_LIMITS")
+	cfg.TenantTokens = l.getEnvAsSecretMap("TENANT_TOKENS")
+	cfg.TenantHeader = l.getEnvAsString("TENANT_HEADER", "")
+
+	cfg.RateLimitConnectionIP = l.getEnvAsInt("RATE_LIMIT_CONNECTION_IP", 5)
+	cfg.RateLimitConnectionToken = l.getEnvAsInt("RATE_LIMIT_CONNECTION_TOKEN", 20)
+	cfg.RateLimitConnectionBlock = l.getEnvAsDuration("RATE_LIMIT_CONNECTION_BLOCK_TIME", time.Minute)
+	cfg.RateLimitMessages = l.getEnvAsInt("RATE_LIMIT_MESSAGES", 20)
+
+	cfg.AdaptiveEnabled = l.getEnvAsBool("ADAPTIVE_ENABLED", false)
+	cfg.AdaptiveInterval = l.getEnvAsDuration("ADAPTIVE_INTERVAL", 5*time.Second)
+	cfg.AdaptiveLatencyThreshold = l.getEnvAsDuration("ADAPTIVE_LATENCY_THRESHOLD", 500*time.Millisecond)
+	cfg.AdaptiveErrorRateThreshold = l.getEnvAsFloat("ADAPTIVE_ERROR_RATE_THRESHOLD", 0.1)
+	cfg.AdaptiveMinSamples = l.getEnvAsInt("ADAPTIVE_MIN_SAMPLES", 20)
+	cfg.AdaptiveDecreaseFactor = l.getEnvAsFloat("ADAPTIVE_DECREASE_FACTOR", 0.5)
+	cfg.AdaptiveIncreaseStep = l.getEnvAsFloat("ADAPTIVE_INCREASE_STEP", 0.1)
+	cfg.AdaptiveMinMultiplier = l.getEnvAsFloat("ADAPTIVE_MIN_MULTIPLIER", 0.1)
+
+	cfg.GeoIPCountryDB = l.getEnvAsString("GEOIP_COUNTRY_DB", "")
+	cfg.GeoIPASNDB = l.getEnvAsString("GEOIP_ASN_DB", "")
+	cfg.GeoIPRules = l.getEnvAsGeoIPRules("GEOIP_RULES")
+	cfg.GeoIPBlockTime = l.getEnvAsDuration("GEOIP_BLOCK_TIME", 0)
+	cfg.GeoIPReloadInterval = l.getEnvAsDuration("GEOIP_RELOAD_INTERVAL", time.Minute)
+
+	cfg.RateLimitMode = l.getEnvAsString("RATE_LIMIT_MODE", ModeReject)
+	cfg.ThrottleMaxWait = l.getEnvAsDuration("THROTTLE_MAX_WAIT", 2*time.Second)
+	cfg.ThrottleQueueSize = l.getEnvAsInt("THROTTLE_QUEUE_SIZE", 100)
 
-	cfg.RedisHost = getEnvAsString("REDIS_HOST", "localhost")
-	cfg.RedisPort = getEnvAsString("REDIS_PORT", "6379")
-	cfg.RedisPassword = getEnvAsString("REDIS_PASSWORD", "")
-	cfg.RedisDB = getEnvAsInt("REDIS_DB", 0)
+	cfg.ResponseStatus = l.getEnvAsInt("RATE_LIMIT_RESPONSE_STATUS", 429)
+	cfg.ResponseJSONTemplate = l.getEnvAsString("RATE_LIMIT_RESPONSE_JSON", "")
+	cfg.ResponseHTMLTemplate = l.getEnvAsString("RATE_LIMIT_RESPONSE_HTML", "")
+	cfg.ResponseTextTemplate = l.getEnvAsString("RATE_LIMIT_RESPONSE_TEXT", "")
+
+	cfg.StorageBackend = l.getEnvAsString("STORAGE_BACKEND", "redis")
+
+	cfg.RedisHost = l.getEnvAsString("REDIS_HOST", "localhost")
+	cfg.RedisPort = l.getEnvAsString("REDIS_PORT", "6379")
+	cfg.RedisPassword = l.getEnvAsSecret("REDIS_PASSWORD", "")
+	cfg.RedisDB = l.getEnvAsInt("REDIS_DB", 0)
+
+	cfg.PostgresDSN = l.getEnvAsSecret("POSTGRES_DSN", "")
+	cfg.BoltPath = l.getEnvAsString("BOLT_PATH", "rate-limiter.db")
+	cfg.StorageSweepInterval = l.getEnvAsDuration("STORAGE_SWEEP_INTERVAL", time.Minute)
+
+	cfg.AuditLogEnabled = l.getEnvAsBool("AUDIT_LOG_ENABLED", false)
+	cfg.AuditLogOutput = l.getEnvAsString("AUDIT_LOG_OUTPUT", "stdout")
+	cfg.AuditLogSampleRate = l.getEnvAsFloat("AUDIT_LOG_SAMPLE_RATE", 0)
+	cfg.AuditLogMaxSizeMB = l.getEnvAsInt("AUDIT_LOG_MAX_SIZE_MB", 100)
+	cfg.AuditLogMaxBackups = l.getEnvAsInt("AUDIT_LOG_MAX_BACKUPS", 5)
+
+	cfg.ServerAddr = l.getEnvAsString("SERVER_ADDR", ":8080")
+	cfg.ServerReadTimeout = l.getEnvAsDuration("SERVER_READ_TIMEOUT", 10*time.Second)
+	cfg.ServerReadHeaderTimeout = l.getEnvAsDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
+	cfg.ServerWriteTimeout = l.getEnvAsDuration("SERVER_WRITE_TIMEOUT", 10*time.Second)
+	cfg.ServerIdleTimeout = l.getEnvAsDuration("SERVER_IDLE_TIMEOUT", 60*time.Second)
+	cfg.ServerMaxHeaderBytes = l.getEnvAsInt("SERVER_MAX_HEADER_BYTES", 1<<20)
+	cfg.ServerShutdownTimeout = l.getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second)
+	cfg.TLSCertFile = l.getEnvAsString("TLS_CERT_FILE", "")
+	cfg.TLSKeyFile = l.getEnvAsString("TLS_KEY_FILE", "")
+
+	cfg.settings = l.settings
+	cfg.validate(l)
+
+	if len(l.errs) > 0 {
+		return nil, &ValidationError{Errors: l.errs}
+	}
 
 	return cfg, nil
 }
 
-func getEnvAsString(key string, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+func (c *Config) validate(l *loader) {
+	l.check(c.RateLimitIP > 0, "RATE_LIMIT_IP", "must be greater than zero")
+	l.check(c.RateLimitIPBlockTime > 0, "RATE_LIMIT_IP_BLOCK_TIME", "must be greater than zero")
+	l.check(c.RateLimitTokenDefault > 0, "RATE_LIMIT_TOKEN_DEFAULT", "must be greater than zero")
+	l.check(c.RateLimitTokenBlockTime > 0, "RATE_LIMIT_TOKEN_BLOCK_TIME", "must be greater than zero")
+	l.check(c.RateLimitGlobal >= 0, "RATE_LIMIT_GLOBAL", "must not be negative (0 disables the global limit)")
+	l.check(c.RateLimitGlobalBlockTime >= 0, "RATE_LIMIT_GLOBAL_BLOCK_TIME", "must not be negative")
+	l.check(c.RateLimitTenantDefault >= 0, "RATE_LIMIT_TENANT_DEFAULT", "must not be negative (0 disables the tenant limit)")
+	l.check(c.RateLimitTenantBlockTime >= 0, "RATE_LIMIT_TENANT_BLOCK_TIME", "must not be negative")
+	for _, limit := range c.RateLimitTenantLimits {
+		if limit <= 0 {
+			l.fail("RATE_LIMIT_TENANT_LIMITS", "limits must be greater than zero")
+			break
+		}
+	}
+
+	l.check(c.RateLimitConnectionIP >= 0, "RATE_LIMIT_CONNECTION_IP", "must not be negative (0 disables the limit)")
+	l.check(c.RateLimitConnectionToken >= 0, "RATE_LIMIT_CONNECTION_TOKEN", "must not be negative (0 disables the limit)")
+	l.check(c.RateLimitConnectionBlock >= 0, "RATE_LIMIT_CONNECTION_BLOCK_TIME", "must not be negative")
+	l.check(c.RateLimitMessages >= 0, "RATE_LIMIT_MESSAGES", "must not be negative (0 disables the limit)")
+
+	l.check(c.AdaptiveInterval > 0, "ADAPTIVE_INTERVAL", "must be greater than zero")
+	l.check(c.AdaptiveLatencyThreshold >= 0, "ADAPTIVE_LATENCY_THRESHOLD", "must not be negative (0 ignores latency)")
+	l.check(c.AdaptiveErrorRateThreshold >= 0 && c.AdaptiveErrorRateThreshold <= 1, "ADAPTIVE_ERROR_RATE_THRESHOLD", "must be between 0 and 1")
+	l.check(c.AdaptiveMinSamples >= 0, "ADAPTIVE_MIN_SAMPLES", "must not be negative")
+	l.check(c.AdaptiveDecreaseFactor > 0 && c.AdaptiveDecreaseFactor < 1, "ADAPTIVE_DECREASE_FACTOR", "must be between 0 and 1 (exclusive)")
+	l.check(c.AdaptiveIncreaseStep > 0 && c.AdaptiveIncreaseStep <= 1, "ADAPTIVE_INCREASE_STEP", "must be greater than 0 and at most 1")
+	l.check(c.AdaptiveMinMultiplier > 0 && c.AdaptiveMinMultiplier <= 1, "ADAPTIVE_MIN_MULTIPLIER", "must be greater than 0 and at most 1")
+
+	l.check(len(c.GeoIPRules) == 0 || c.GeoIPEnabled(), "GEOIP_RULES", "requires GEOIP_COUNTRY_DB or GEOIP_ASN_DB")
+	l.check(c.GeoIPBlockTime >= 0, "GEOIP_BLOCK_TIME", "must not be negative (0 uses RATE_LIMIT_IP_BLOCK_TIME)")
+	l.check(c.GeoIPReloadInterval > 0, "GEOIP_RELOAD_INTERVAL", "must be greater than zero")
+
+	switch c.RateLimitMode {
+	case ModeReject, ModeThrottle:
+	default:
+		l.fail("RATE_LIMIT_MODE", "must be one of reject, throttle")
+	}
+	l.check(c.ThrottleMaxWait > 0, "THROTTLE_MAX_WAIT", "must be greater than zero")
+	l.check(c.ThrottleQueueSize > 0, "THROTTLE_QUEUE_SIZE", "must be greater than zero")
+	l.check(!c.ThrottleEnabled() || c.ServerWriteTimeout == 0 || c.ThrottleMaxWait < c.ServerWriteTimeout,
+		"THROTTLE_MAX_WAIT", "must be shorter than SERVER_WRITE_TIMEOUT")
+	l.check(c.ResponseStatus >= 400 && c.ResponseStatus <= 599, "RATE_LIMIT_RESPONSE_STATUS", "must be an HTTP error status (400-599)")
+
+	switch c.StorageBackend {
+	case "redis", "postgres", "bolt", "memory":
+	default:
+		l.fail("STORAGE_BACKEND", "must be one of redis, postgres, bolt, memory")
+	}
+
+	if _, err := strconv.Atoi(c.RedisPort); err != nil {
+		l.fail("REDIS_PORT", "must be a port number")
+	}
+	l.check(c.RedisDB >= 0, "REDIS_DB", "must not be negative")
+	l.check(c.StorageBackend != "postgres" || c.PostgresDSN != "", "POSTGRES_DSN", "is required when STORAGE_BACKEND=postgres")
+	l.check(c.StorageBackend != "bolt" || c.BoltPath != "", "BOLT_PATH", "is required when STORAGE_BACKEND=bolt")
+	l.check(c.StorageSweepInterval > 0, "STORAGE_SWEEP_INTERVAL", "must be greater than zero")
+
+	l.check(c.AuditLogSampleRate >= 0 && c.AuditLogSampleRate <= 1, "AUDIT_LOG_SAMPLE_RATE", "must be between 0 and 1")
+	l.check(c.AuditLogMaxSizeMB > 0, "AUDIT_LOG_MAX_SIZE_MB", "must be greater than zero")
+	l.check(c.AuditLogMaxBackups >= 0, "AUDIT_LOG_MAX_BACKUPS", "must not be negative")
+
+	l.check(c.ServerReadTimeout >= 0, "SERVER_READ_TIMEOUT", "must not be negative")
+	l.check(c.ServerReadHeaderTimeout >= 0, "SERVER_READ_HEADER_TIMEOUT", "must not be negative")
+	l.check(c.ServerWriteTimeout >= 0, "SERVER_WRITE_TIMEOUT", "must not be negative")
+	l.check(c.ServerIdleTimeout >= 0, "SERVER_IDLE_TIMEOUT", "must not be negative")
+	l.check(c.ServerMaxHeaderBytes > 0, "SERVER_MAX_HEADER_BYTES", "must be greater than zero")
+	l.check(c.ServerShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT", "must be greater than zero")
+	l.check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE", "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+}
+
+func (c *Config) RedisAddr() string {
+	return fmt.Sprintf("%s:%s", c.RedisHost, c.RedisPort)
+}
+
+// GeoIPDatabases retorna os caminhos dos bancos MMDB configurados.
+func (c *Config) GeoIPDatabases() []string {
+	var paths []string
+	for _, path := range []string{c.GeoIPCountryDB, c.GeoIPASNDB} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func (c *Config) GeoIPEnabled() bool {
+	return len(c.GeoIPDatabases()) > 0
+}
+
+func (c *Config) ThrottleEnabled() bool {
+	return c.RateLimitMode == ModeThrottle
+}
+
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+type setting struct {
+	key    string
+	value  string
+	secret bool
+}
+
+type loader struct {
+	errs     []*FieldError
+	settings []setting
+	values   map[string]string
+}
+
+func (l *loader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	value = strings.TrimSpace(value)
+	if l.values == nil {
+		l.values = make(map[string]string)
+	}
+	l.values[key] = value
+	return value, ok && value != ""
+}
+
+func (l *loader) record(key string, value string, secret bool) {
+	l.settings = append(l.settings, setting{key: key, value: value, secret: secret})
+}
+
+func (l *loader) fail(key string, message string) {
+	l.errs = append(l.errs, &FieldError{Key: key, Value: l.values[key], Message: message})
+}
+
+func (l *loader) check(ok bool, key string, message string) {
+	if !ok {
+		l.fail(key, message)
+	}
+}
+
+func (l *loader) getEnvAsString(key string, defaultValue string) string {
+	value, ok := l.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	l.record(key, value, false)
+	return value
+}
+
+func (l *loader) getEnvAsSecret(key string, defaultValue string) string {
+	value, ok := l.lookup(key)
+	if !ok {
+		value = defaultValue
 	}
+	l.record(key, value, true)
 	return value
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
-	if valueStr == "" {
+func (l *loader) getEnvAsInt(key string, defaultValue int) int {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.Itoa(defaultValue), false)
 		return defaultValue
 	}
 
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
+		l.fail(key, "must be an integer")
 		return defaultValue
 	}
 
+	l.record(key, valueStr, false)
 	return value
 }
 
-func (c *Config) RedisAddr() string {
-	return fmt.Sprintf("%s:%s", c.RedisHost, c.RedisPort)
+func (l *loader) getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.FormatBool(defaultValue), false)
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		l.fail(key, "must be a boolean (true/false)")
+		return defaultValue
+	}
+
+	l.record(key, strconv.FormatBool(value), false)
+	return value
+}
+
+func (l *loader) getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.FormatFloat(defaultValue, 'g', -1, 64), false)
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		l.fail(key, "must be a number")
+		return defaultValue
+	}
+
+	l.record(key, valueStr, false)
+	return value
+}
+
+// getEnvAsDuration aceita a sintaxe de duração do Go ("300s", "5m") e, por
+// compatibilidade, um inteiro interpretado como segundos ("300").
+func (l *loader) getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		l.record(key, defaultValue.String(), false)
+		return defaultValue
+	}
+
+	value, err := parseDuration(valueStr)
+	if err != nil {
+		l.fail(key, `must be a duration such as "300s" or "5m", or an integer number of seconds`)
+		return defaultValue
+	}
+
+	l.record(key, value.String(), false)
+	return value
+}
+
+// getEnvAsIntMap lê uma lista "chave=valor,chave=valor" de valores inteiros.
+func (l *loader) getEnvAsIntMap(key string) map[string]int {
+	pairs, ok := l.getEnvAsPairs(key, false)
+	if !ok {
+		return nil
+	}
+
+	values := make(map[string]int, len(pairs))
+	for name, valueStr := range pairs {
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			l.fail(key, fmt.Sprintf("value of %q must be an integer", name))
+			return nil
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// getEnvAsSecretMap lê uma lista "chave=valor,chave=valor" cujas chaves são
+// credenciais, ocultadas no --print-config.
+func (l *loader) getEnvAsSecretMap(key string) map[string]string {
+	pairs, _ := l.getEnvAsPairs(key, true)
+	return pairs
+}
+
+func (l *loader) getEnvAsPairs(key string, secret bool) (map[string]string, bool) {
+	valueStr, ok := l.lookup(key)
+	l.record(key, valueStr, secret)
+	if !ok {
+		return nil, false
+	}
+
+	pairs := make(map[string]string)
+	for _, entry := range strings.Split(valueStr, ",") {
+		name, value, found := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !found || name == "" || value == "" {
+			l.fail(key, `must be a list such as "name=value,other=value"`)
+			return nil, false
+		}
+		if _, exists := pairs[name]; exists {
+			l.fail(key, fmt.Sprintf("%q is listed more than once", name))
+			return nil, false
+		}
+		pairs[name] = value
+	}
+	return pairs, true
+}
+
+func (l *loader) getEnvAsGeoIPRules(key string) geoip.Rules {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		l.record(key, "", false)
+		return nil
+	}
+
+	rules, err := geoip.ParseRules(valueStr)
+	if err != nil {
+		l.fail(key, err.Error())
+		return nil
+	}
+
+	l.record(key, valueStr, false)
+	return rules
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
 }
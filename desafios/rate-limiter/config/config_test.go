@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.RateLimitIP != 10 {
+		t.Errorf("Expected RateLimitIP 10, got %d", cfg.RateLimitIP)
+	}
+	if cfg.RateLimitIPBlockTime != 300*time.Second {
+		t.Errorf("Expected RateLimitIPBlockTime 300s, got %v", cfg.RateLimitIPBlockTime)
+	}
+	if cfg.StorageBackend != "redis" {
+		t.Errorf("Expected StorageBackend 'redis', got '%s'", cfg.StorageBackend)
+	}
+}
+
+func TestLoad_Durations(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "300", expected: 300 * time.Second},
+		{value: "300s", expected: 300 * time.Second},
+		{value: "5m", expected: 5 * time.Minute},
+		{value: "1h30m", expected: 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_IP_BLOCK_TIME", tt.value)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.RateLimitIPBlockTime != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, cfg.RateLimitIPBlockTime)
+			}
+		})
+	}
+}
+
+func TestLoad_AggregatesErrors(t *testing.T) {
+	t.Setenv("RATE_LIMIT_IP", "1O")
+	t.Setenv("RATE_LIMIT_TOKEN_DEFAULT", "0")
+	t.Setenv("RATE_LIMIT_TOKEN_BLOCK_TIME", "-5m")
+	t.Setenv("AUDIT_LOG_SAMPLE_RATE", "1.5")
+	t.Setenv("STORAGE_BACKEND", "postgres")
+
+	cfg, err := Load()
+	if cfg != nil {
+		t.Error("Config should be nil when validation fails")
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+	}
+
+	keys := make(map[string]bool)
+	for _, fieldErr := range validationErr.Errors {
+		keys[fieldErr.Key] = true
+	}
+
+	for _, key := range []string{"RATE_LIMIT_IP", "RATE_LIMIT_TOKEN_DEFAULT", "RATE_LIMIT_TOKEN_BLOCK_TIME", "AUDIT_LOG_SAMPLE_RATE", "POSTGRES_DSN"} {
+		if !keys[key] {
+			t.Errorf("Expected an error for %s, got: %v", key, err)
+		}
+	}
+	if len(validationErr.Errors) != 5 {
+		t.Errorf("Expected 5 errors, got %d: %v", len(validationErr.Errors), err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "RATE_LIMIT_IP" || fieldErr.Value != "1O" {
+		t.Errorf("Expected first FieldError for RATE_LIMIT_IP=\"1O\", got %v", fieldErr)
+	}
+}
+
+func TestLoad_GeoIPRules(t *testing.T) {
+	t.Setenv("GEOIP_ASN_DB", "/var/lib/geoip/GeoLite2-ASN.mmdb")
+	t.Setenv("GEOIP_RULES", "asn:16509=2,country:CN=5")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.GeoIPRules) != 2 || cfg.GeoIPRules[0].ASN != 16509 || cfg.GeoIPRules[1].Country != "CN" {
+		t.Errorf("Unexpected rules: %v", cfg.GeoIPRules)
+	}
+
+	t.Setenv("GEOIP_ASN_DB", "")
+	t.Setenv("GEOIP_RULES", "asn:16509")
+
+	_, err = Load()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Errors[0].Key != "GEOIP_RULES" {
+		t.Errorf("Expected a GEOIP_RULES error, got %v", err)
+	}
+}
+
+func TestLoad_TenantLimits(t *testing.T) {
+	t.Setenv("RATE_LIMIT_TENANT_LIMITS", "acme=500, globex=200")
+	t.Setenv("TENANT_TOKENS", "key-a=acme,key-b=globex")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.RateLimitTenantLimits) != 2 || cfg.RateLimitTenantLimits["acme"] != 500 || cfg.RateLimitTenantLimits["globex"] != 200 {
+		t.Errorf("Unexpected tenant limits: %v", cfg.RateLimitTenantLimits)
+	}
+	if cfg.TenantTokens["key-a"] != "acme" || cfg.TenantTokens["key-b"] != "globex" {
+		t.Errorf("Unexpected tenant tokens: %v", cfg.TenantTokens)
+	}
+
+	for _, value := range []string{"acme", "acme=0", "acme=ten", "acme=1,acme=2"} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_TENANT_LIMITS", value)
+
+			_, err := Load()
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Errors[0].Key != "RATE_LIMIT_TENANT_LIMITS" {
+				t.Errorf("Expected a RATE_LIMIT_TENANT_LIMITS error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_PrintRedactsSecrets(t *testing.T) {
+	t.Setenv("REDIS_PASSWORD", "super-secret")
+	t.Setenv("POSTGRES_DSN", "postgres://app:db-secret@db:5432/limiter?sslmode=disable")
+	t.Setenv("TENANT_TOKENS", "token-secret=acme")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := buf.String()
+
+	if strings.Contains(output, "super-secret") || strings.Contains(output, "db-secret") || strings.Contains(output, "token-secret") {
+		t.Errorf("Secrets should be redacted:\n%s", output)
+	}
+	if !strings.Contains(output, "REDIS_PASSWORD=[REDACTED]\n") {
+		t.Errorf("Expected redacted Redis password:\n%s", output)
+	}
+	if !strings.Contains(output, "POSTGRES_DSN=postgres://app:REDACTED@db:5432/limiter?sslmode=disable\n") {
+		t.Errorf("Expected DSN with redacted password:\n%s", output)
+	}
+	if !strings.Contains(output, "RATE_LIMIT_IP_BLOCK_TIME=5m0s\n") {
+		t.Errorf("Expected effective block time:\n%s", output)
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+const redacted = "[REDACTED]"
+
+// Print escreve a configuração efetiva (após aplicar padrões) no formato
+// CHAVE=valor, ocultando senhas e credenciais.
+func (c *Config) Print(w io.Writer) error {
+	for _, s := range c.settings {
+		value := s.value
+		if s.secret {
+			value = redact(value)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	// Para DSNs no formato URL, mantém host e banco visíveis e oculta apenas a senha.
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		if u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				u.User = url.UserPassword(u.User.Username(), "REDACTED")
+			}
+		}
+		if query := u.Query(); query.Has("password") {
+			query.Set("password", "REDACTED")
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+
+	return redacted
+}
@@ -0,0 +1,159 @@
+// Package geoip resolve o país e o ASN de um IP a partir de bancos MMDB da
+// MaxMind (GeoLite2/GeoIP2 Country, City e ASN) e define as regras de limite
+// aplicadas por país ou ASN.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Info é o resultado de uma consulta. Campos vazios indicam que nenhum banco
+// carregado tem a informação para o IP.
+type Info struct {
+	Country      string
+	ASN          uint
+	Organization string
+}
+
+// record cobre os campos dos bancos Country/City e ASN; campos ausentes no
+// banco consultado ficam com o valor zero.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+type database struct {
+	path    string
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+// Resolver consulta um ou mais bancos MMDB e recarrega cada um quando o
+// arquivo muda, sem interromper as consultas em andamento.
+type Resolver struct {
+	mu        sync.RWMutex
+	databases []*database
+}
+
+func Open(paths ...string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, path := range paths {
+		db, err := openDatabase(path)
+		if err != nil {
+			return nil, err
+		}
+		r.databases = append(r.databases, db)
+	}
+	return r, nil
+}
+
+// openDatabase carrega o arquivo inteiro em memória em vez de usar mmap, para
+// que a atualização do arquivo no disco não altere um banco em uso.
+func openDatabase(path string) (*database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat geoip database: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geoip database: %w", err)
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database %s: %w", path, err)
+	}
+
+	return &database{
+		path:    path,
+		reader:  reader,
+		modTime: info.ModTime(),
+	}, nil
+}
+
+func (r *Resolver) Lookup(ip net.IP) (Info, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var info Info
+	for _, db := range r.databases {
+		var rec record
+		if err := db.reader.Lookup(ip, &rec); err != nil {
+			return Info{}, fmt.Errorf("failed to look up %s in %s: %w", ip, db.path, err)
+		}
+
+		if info.Country == "" {
+			info.Country = rec.Country.ISOCode
+		}
+		if info.ASN == 0 {
+			info.ASN = rec.ASN
+			info.Organization = rec.Organization
+		}
+	}
+	return info, nil
+}
+
+// Reload reabre os bancos cujo arquivo mudou desde a última carga. Se um
+// arquivo não puder ser lido, o banco anterior continua em uso.
+func (r *Resolver) Reload() error {
+	r.mu.RLock()
+	current := make([]*database, len(r.databases))
+	copy(current, r.databases)
+	r.mu.RUnlock()
+
+	var firstErr error
+	for i, db := range current {
+		info, err := os.Stat(db.path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to stat geoip database: %w", err)
+			}
+			continue
+		}
+		if info.ModTime().Equal(db.modTime) {
+			continue
+		}
+
+		reloaded, err := openDatabase(db.path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		r.databases[i] = reloaded
+		r.mu.Unlock()
+	}
+	return firstErr
+}
+
+// Run verifica os arquivos a cada interval até ctx ser cancelado.
+func (r *Resolver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				log.Printf("failed to reload geoip database: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+func writeDatabase(t *testing.T, path string, databaseType string, networks map[string]mmdbtype.Map) {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: databaseType, RecordSize: 24})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for cidr, data := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := tree.Insert(network, data); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Escreve em um arquivo temporário e renomeia, como fazem as ferramentas
+	// de atualização da MaxMind.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := tree.WriteTo(f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Close()
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func asnRecord(asn uint32, organization string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"autonomous_system_number":       mmdbtype.Uint32(asn),
+		"autonomous_system_organization": mmdbtype.String(organization),
+	}
+}
+
+func countryRecord(isoCode string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"country": mmdbtype.Map{"iso_code": mmdbtype.String(isoCode)},
+	}
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	countryDB := filepath.Join(dir, "country.mmdb")
+	asnDB := filepath.Join(dir, "asn.mmdb")
+
+	writeDatabase(t, countryDB, "GeoLite2-Country", map[string]mmdbtype.Map{
+		"3.0.0.0/8":     countryRecord("US"),
+		"177.0.0.0/8":   countryRecord("BR"),
+		"2804:14c::/32": countryRecord("BR"),
+	})
+	writeDatabase(t, asnDB, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"3.0.0.0/8": asnRecord(16509, "AMAZON-02"),
+	})
+
+	resolver, err := Open(countryDB, asnDB)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		expected Info
+	}{
+		{ip: "3.5.140.2", expected: Info{Country: "US", ASN: 16509, Organization: "AMAZON-02"}},
+		{ip: "177.10.20.30", expected: Info{Country: "BR"}},
+		{ip: "2804:14c:1::1", expected: Info{Country: "BR"}},
+		{ip: "8.8.8.8", expected: Info{}},
+	}
+
+	for _, tt := range tests {
+		info, err := resolver.Lookup(net.ParseIP(tt.ip))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if info != tt.expected {
+			t.Errorf("%s: expected %+v, got %+v", tt.ip, tt.expected, info)
+		}
+	}
+}
+
+func TestResolver_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.mmdb")
+	writeDatabase(t, path, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"3.0.0.0/8": asnRecord(16509, "AMAZON-02"),
+	})
+
+	resolver, err := Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writeDatabase(t, path, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"3.0.0.0/8": asnRecord(14618, "AMAZON-AES"),
+	})
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := resolver.Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := resolver.Lookup(net.ParseIP("3.5.140.2"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.ASN != 14618 {
+		t.Errorf("Expected ASN 14618 after reload, got %d", info.ASN)
+	}
+}
+
+func TestResolver_KeepsDatabaseWhenReloadFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.mmdb")
+	writeDatabase(t, path, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"3.0.0.0/8": asnRecord(16509, "AMAZON-02"),
+	})
+
+	resolver, err := Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a database"), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(path, future, future)
+
+	if err := resolver.Reload(); err == nil {
+		t.Error("Expected error reloading a corrupt database")
+	}
+
+	info, err := resolver.Lookup(net.ParseIP("3.5.140.2"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.ASN != 16509 {
+		t.Errorf("Previous database should stay in use, got ASN %d", info.ASN)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("asn:16509=2, asn:AS14618=3,country:cn=5")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := Rules{
+		{ASN: 16509, Limit: 2},
+		{ASN: 14618, Limit: 3},
+		{Country: "CN", Limit: 5},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %v", len(expected), rules)
+	}
+	for i := range expected {
+		if rules[i] != expected[i] {
+			t.Errorf("Rule %d: expected %v, got %v", i, expected[i], rules[i])
+		}
+	}
+
+	for _, invalid := range []string{"asn:16509", "asn:x=2", "country:CHN=2", "city:SP=1", "asn:16509=0"} {
+		if _, err := ParseRules(invalid); err == nil {
+			t.Errorf("Expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestRules_MatchPrefersASN(t *testing.T) {
+	rules := Rules{
+		{Country: "US", Limit: 5},
+		{ASN: 16509, Limit: 2},
+	}
+
+	if rule, ok := rules.Match(Info{Country: "US", ASN: 16509}); !ok || rule.Limit != 2 {
+		t.Errorf("Expected the ASN rule, got %v (matched %t)", rule, ok)
+	}
+	if rule, ok := rules.Match(Info{Country: "US", ASN: 7922}); !ok || rule.Limit != 5 {
+		t.Errorf("Expected the country rule, got %v (matched %t)", rule, ok)
+	}
+	if _, ok := rules.Match(Info{Country: "BR"}); ok {
+		t.Error("No rule should match")
+	}
+}
@@ -0,0 +1,87 @@
+package geoip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule define o limite por IP aplicado aos IPs de um país ou de um ASN.
+// Exatamente um entre Country e ASN é preenchido.
+type Rule struct {
+	Country string
+	ASN     uint
+	Limit   int
+}
+
+func (r Rule) String() string {
+	if r.ASN != 0 {
+		return fmt.Sprintf("asn:%d=%d", r.ASN, r.Limit)
+	}
+	return fmt.Sprintf("country:%s=%d", r.Country, r.Limit)
+}
+
+type Rules []Rule
+
+// ParseRules lê regras no formato "asn:16509=2,asn:14618=2,country:CN=5".
+func ParseRules(value string) (Rules, error) {
+	var rules Rules
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		selector, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: expected <selector>=<limit>", entry)
+		}
+
+		var rule Rule
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("rule %q: limit must be a positive integer", entry)
+		}
+		rule.Limit = n
+
+		kind, id, _ := strings.Cut(strings.TrimSpace(selector), ":")
+		switch strings.ToLower(kind) {
+		case "asn":
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(id), "AS"), 10, 32)
+			if err != nil || asn == 0 {
+				return nil, fmt.Errorf("rule %q: invalid ASN", entry)
+			}
+			rule.ASN = uint(asn)
+		case "country":
+			if len(id) != 2 {
+				return nil, fmt.Errorf("rule %q: country must be an ISO 3166-1 alpha-2 code", entry)
+			}
+			rule.Country = strings.ToUpper(id)
+		default:
+			return nil, fmt.Errorf("rule %q: selector must be asn:<number> or country:<code>", entry)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Match retorna a regra aplicável a info. Regras de ASN têm precedência sobre
+// as de país, por serem mais específicas.
+func (rs Rules) Match(info Info) (Rule, bool) {
+	if info.ASN != 0 {
+		for _, rule := range rs {
+			if rule.ASN == info.ASN {
+				return rule, true
+			}
+		}
+	}
+	if info.Country != "" {
+		for _, rule := range rs {
+			if rule.ASN == 0 && rule.Country == info.Country {
+				return rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,67 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"rate-limiter/storage"
+	"rate-limiter/storage/storagetest"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestPostgresStorage_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN não definido")
+	}
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		clock := &fakeClock{now: time.Now()}
+
+		pgStorage, err := storage.NewPostgresStorageWithClock(dsn, clock.Now)
+		if err != nil {
+			t.Skipf("Postgres não disponível: %v", err)
+		}
+		t.Cleanup(func() { pgStorage.Close() })
+
+		conn, err := pgx.Connect(context.Background(), dsn)
+		if err != nil {
+			t.Fatalf("Erro ao conectar: %v", err)
+		}
+		defer conn.Close(context.Background())
+
+		_, err = conn.Exec(context.Background(), `TRUNCATE rate_limit_counters, rate_limit_blocks, rate_limit_token_limits`)
+		if err != nil {
+			t.Fatalf("Erro ao limpar tabelas: %v", err)
+		}
+
+		return storagetest.Backend{
+			Storage: pgStorage,
+			Advance: clock.Advance,
+		}
+	})
+}
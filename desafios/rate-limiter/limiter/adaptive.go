@@ -0,0 +1,140 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"rate-limiter/config"
+)
+
+type AdaptiveConfig struct {
+	Interval           time.Duration
+	LatencyThreshold   time.Duration
+	ErrorRateThreshold float64
+	MinSamples         int
+	DecreaseFactor     float64
+	IncreaseStep       float64
+	MinMultiplier      float64
+}
+
+func AdaptiveConfigFrom(cfg *config.Config) AdaptiveConfig {
+	return AdaptiveConfig{
+		Interval:           cfg.AdaptiveInterval,
+		LatencyThreshold:   cfg.AdaptiveLatencyThreshold,
+		ErrorRateThreshold: cfg.AdaptiveErrorRateThreshold,
+		MinSamples:         cfg.AdaptiveMinSamples,
+		DecreaseFactor:     cfg.AdaptiveDecreaseFactor,
+		IncreaseStep:       cfg.AdaptiveIncreaseStep,
+		MinMultiplier:      cfg.AdaptiveMinMultiplier,
+	}
+}
+
+type AdaptiveStats struct {
+	Multiplier float64
+	Samples    int64
+	ErrorRate  float64
+	AvgLatency time.Duration
+	Degraded   bool
+}
+
+// AdaptiveController ajusta um multiplicador aplicado aos limites configurados
+// a partir da saúde observada do backend (AIMD): a cada intervalo, se a taxa
+// de erro ou a latência média ultrapassarem os limiares, o multiplicador é
+// reduzido multiplicativamente; caso contrário, volta a crescer aditivamente
+// até 1.
+type AdaptiveController struct {
+	mu         sync.Mutex
+	config     AdaptiveConfig
+	multiplier float64
+	requests   int64
+	failures   int64
+	latency    time.Duration
+	last       AdaptiveStats
+}
+
+func NewAdaptiveController(config AdaptiveConfig) *AdaptiveController {
+	return &AdaptiveController{
+		config:     config,
+		multiplier: 1,
+		last:       AdaptiveStats{Multiplier: 1},
+	}
+}
+
+func (a *AdaptiveController) Observe(latency time.Duration, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.requests++
+	a.latency += latency
+	if failed {
+		a.failures++
+	}
+}
+
+// Adjust fecha a janela de observação atual e recalcula o multiplicador.
+// Janelas com menos de MinSamples observações são tratadas como saudáveis.
+func (a *AdaptiveController) Adjust() AdaptiveStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := AdaptiveStats{Samples: a.requests}
+	if a.requests > 0 {
+		stats.ErrorRate = float64(a.failures) / float64(a.requests)
+		stats.AvgLatency = a.latency / time.Duration(a.requests)
+	}
+
+	stats.Degraded = a.requests >= int64(a.config.MinSamples) &&
+		(stats.ErrorRate > a.config.ErrorRateThreshold ||
+			(a.config.LatencyThreshold > 0 && stats.AvgLatency > a.config.LatencyThreshold))
+
+	if stats.Degraded {
+		a.multiplier = math.Max(a.config.MinMultiplier, a.multiplier*a.config.DecreaseFactor)
+	} else {
+		a.multiplier = math.Min(1, a.multiplier+a.config.IncreaseStep)
+	}
+
+	stats.Multiplier = a.multiplier
+	a.last = stats
+	a.requests, a.failures, a.latency = 0, 0, 0
+
+	return stats
+}
+
+func (a *AdaptiveController) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Adjust()
+		}
+	}
+}
+
+func (a *AdaptiveController) Multiplier() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.multiplier
+}
+
+// Stats retorna o resultado da última janela avaliada.
+func (a *AdaptiveController) Stats() AdaptiveStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.last
+}
+
+func (a *AdaptiveController) scale(limit int) int {
+	scaled := int(math.Floor(float64(limit) * a.Multiplier()))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
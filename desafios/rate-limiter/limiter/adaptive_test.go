@@ -0,0 +1,106 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"rate-limiter/config"
+	"rate-limiter/storage"
+)
+
+func newTestAdaptiveController() *AdaptiveController {
+	return NewAdaptiveController(AdaptiveConfig{
+		Interval:           time.Second,
+		LatencyThreshold:   100 * time.Millisecond,
+		ErrorRateThreshold: 0.2,
+		MinSamples:         5,
+		DecreaseFactor:     0.5,
+		IncreaseStep:       0.25,
+		MinMultiplier:      0.2,
+	})
+}
+
+func observe(a *AdaptiveController, n int, latency time.Duration, failures int) {
+	for i := 0; i < n; i++ {
+		a.Observe(latency, i < failures)
+	}
+}
+
+func TestAdaptiveController_AIMD(t *testing.T) {
+	a := newTestAdaptiveController()
+
+	observe(a, 10, 10*time.Millisecond, 5)
+	stats := a.Adjust()
+	if !stats.Degraded {
+		t.Error("Window with 50% errors should be degraded")
+	}
+	if stats.Multiplier != 0.5 {
+		t.Errorf("Expected multiplier 0.5, got %v", stats.Multiplier)
+	}
+
+	observe(a, 10, 300*time.Millisecond, 0)
+	if stats := a.Adjust(); stats.Multiplier != 0.25 {
+		t.Errorf("Expected multiplier 0.25 after slow window, got %v", stats.Multiplier)
+	}
+
+	observe(a, 10, 300*time.Millisecond, 0)
+	if stats := a.Adjust(); stats.Multiplier != 0.2 {
+		t.Errorf("Multiplier should not go below the floor, got %v", stats.Multiplier)
+	}
+
+	observe(a, 10, 10*time.Millisecond, 0)
+	if stats := a.Adjust(); stats.Degraded || stats.Multiplier != 0.45 {
+		t.Errorf("Expected healthy window and multiplier 0.45, got degraded=%t multiplier=%v", stats.Degraded, stats.Multiplier)
+	}
+
+	for i := 0; i < 5; i++ {
+		a.Adjust()
+	}
+	if a.Multiplier() != 1 {
+		t.Errorf("Multiplier should recover up to 1, got %v", a.Multiplier())
+	}
+}
+
+func TestAdaptiveController_IgnoresSmallWindows(t *testing.T) {
+	a := newTestAdaptiveController()
+
+	observe(a, 3, time.Second, 3)
+	if stats := a.Adjust(); stats.Degraded {
+		t.Error("Windows below MinSamples should not be considered degraded")
+	}
+}
+
+func TestLimiter_AdaptiveTightensLimits(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          10,
+		RateLimitIPBlockTime: 5 * time.Second,
+	}
+
+	adaptive := newTestAdaptiveController()
+	observe(adaptive, 10, 10*time.Millisecond, 10)
+	adaptive.Adjust()
+
+	limiter := NewLimiter(memStorage, cfg, WithAdaptiveController(adaptive))
+
+	for i := 0; i < 5; i++ {
+		result, err := limiter.CheckIPLimit("10.0.0.1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("Request %d should be allowed", i+1)
+		}
+	}
+
+	result, err := limiter.CheckIPLimit("10.0.0.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("6th request should be denied with the limit halved")
+	}
+	if result.Limit != 5 {
+		t.Errorf("Expected effective limit 5, got %d", result.Limit)
+	}
+}
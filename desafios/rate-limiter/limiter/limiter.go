@@ -2,95 +2,377 @@ package limiter
 
 import (
 	"fmt"
+	"net"
 	"time"
 
 	"rate-limiter/config"
+	"rate-limiter/geoip"
 	"rate-limiter/storage"
 )
 
+const window = time.Second
+
+const (
+	LevelGlobal = "global"
+	LevelTenant = "tenant"
+	LevelToken  = "token"
+	LevelIP     = "ip"
+	LevelGeo    = "geo"
+
+	LevelConnection = "connection"
+	LevelMessage    = "message"
+)
+
 type Limiter struct {
-	storage storage.Storage
-	config  *config.Config
+	storage  storage.Storage
+	config   *config.Config
+	adaptive *AdaptiveController
+	geo      GeoResolver
+}
+
+// GeoResolver resolve país e ASN de um IP; implementado por *geoip.Resolver.
+type GeoResolver interface {
+	Lookup(ip net.IP) (geoip.Info, error)
+}
+
+type Option func(*Limiter)
+
+func WithAdaptiveController(controller *AdaptiveController) Option {
+	return func(l *Limiter) {
+		l.adaptive = controller
+	}
+}
+
+// WithGeoResolver habilita as regras de config.GeoIPRules: IPs de um país ou
+// ASN com regra usam o limite dela no lugar de RateLimitIP.
+func WithGeoResolver(resolver GeoResolver) Option {
+	return func(l *Limiter) {
+		l.geo = resolver
+	}
 }
 
 type Result struct {
-	Allowed bool
-	Reason  string
+	Allowed      bool
+	Reason       string
+	Level        string
+	Key          string
+	Limit        int
+	Count        int64
+	BlockedUntil time.Time
+	RetryAfter   time.Duration
 }
 
-func NewLimiter(storage storage.Storage, config *config.Config) *Limiter {
-	return &Limiter{
+// Identity descreve quem fez a requisição. Campos vazios fazem o nível
+// correspondente ser ignorado em Check.
+type Identity struct {
+	IP     string
+	Token  string
+	Tenant string
+}
+
+type rule struct {
+	level     string
+	key       string
+	limit     int
+	blockTime time.Duration
+}
+
+func NewLimiter(storage storage.Storage, config *config.Config, opts ...Option) *Limiter {
+	l := &Limiter{
 		storage: storage,
 		config:  config,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Adaptive retorna o controlador adaptativo configurado, ou nil.
+func (l *Limiter) Adaptive() *AdaptiveController {
+	return l.adaptive
 }
 
 func (l *Limiter) CheckLimit(identifier string, limit int, blockTime time.Duration) (*Result, error) {
-	blocked, err := l.storage.IsBlocked(identifier)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check block status: %w", err)
+	return l.evaluate([]rule{{key: identifier, limit: limit, blockTime: blockTime}}, true)
+}
+
+func (l *Limiter) CheckIPLimit(ip string) (*Result, error) {
+	return l.evaluate([]rule{l.ipRule(ip)}, true)
+}
+
+func (l *Limiter) CheckTokenLimit(token string) (*Result, error) {
+	return l.evaluate([]rule{l.tokenRule(token)}, true)
+}
+
+// Check avalia, em uma única chamada, todos os níveis aplicáveis à identidade:
+// limite global do serviço, agregado do tenant, token de API e IP de origem.
+// A requisição só é permitida se passar em todos; Result.Level indica o nível
+// que negou. Sem RateLimitIPWithToken, o limite por IP continua sendo ignorado
+// quando há token.
+func (l *Limiter) Check(identity Identity) (*Result, error) {
+	return l.evaluate(l.rulesFor(identity), true)
+}
+
+// CheckWithoutBlock avalia os mesmos níveis de Check, mas nunca aplica o
+// bloqueio de penalidade ao exceder o limite: a negação vale só até o fim da
+// janela atual, informado em Result.RetryAfter. A tentativa negada também não
+// é contabilizada em nenhum nível, para que as novas tentativas de uma
+// requisição em espera não contem como requisições novas. Usado pelo modo
+// throttle, em que a requisição espera por capacidade em vez de ser rejeitada.
+func (l *Limiter) CheckWithoutBlock(identity Identity) (*Result, error) {
+	return l.evaluate(l.rulesFor(identity), false)
+}
+
+// CheckConnection limita o estabelecimento de conexões de longa duração
+// (WebSocket, SSE) por token, ou por IP quando não há token. Os contadores
+// são separados dos de requisições (prefixo conn:), para que abrir conexões
+// não consuma a cota de requisições e vice-versa.
+func (l *Limiter) CheckConnection(identity Identity) (*Result, error) {
+	key, limit := fmt.Sprintf("conn:ip:%s", identity.IP), l.config.RateLimitConnectionIP
+	if identity.Token != "" {
+		key, limit = fmt.Sprintf("conn:token:%s", identity.Token), l.config.RateLimitConnectionToken
 	}
-	if blocked {
-		return &Result{
-			Allowed: false,
-			Reason:  "blocked",
-		}, nil
+	if limit <= 0 {
+		return &Result{Allowed: true, Reason: "allowed", Level: LevelConnection, Key: key}, nil
 	}
 
-	count, err := l.storage.Increment(identifier, time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment counter: %w", err)
+	return l.evaluate([]rule{{
+		level:     LevelConnection,
+		key:       key,
+		limit:     limit,
+		blockTime: l.config.RateLimitConnectionBlock,
+	}}, true)
+}
+
+// CheckMessage limita as mensagens de uma conexão. Exceder o limite nega
+// só até o fim da janela; cabe a quem chama decidir se encerra a conexão.
+func (l *Limiter) CheckMessage(connectionID string) (*Result, error) {
+	key := fmt.Sprintf("msg:%s", connectionID)
+	if l.config.RateLimitMessages <= 0 {
+		return &Result{Allowed: true, Reason: "allowed", Level: LevelMessage, Key: key}, nil
 	}
 
-	if count == -1 {
-		return &Result{
-			Allowed: false,
-			Reason:  "blocked",
-		}, nil
+	return l.evaluate([]rule{{
+		level: LevelMessage,
+		key:   key,
+		limit: l.config.RateLimitMessages,
+	}}, false)
+}
+
+func (l *Limiter) rulesFor(identity Identity) []rule {
+	var rules []rule
+
+	if l.config.RateLimitGlobal > 0 {
+		rules = append(rules, rule{
+			level:     LevelGlobal,
+			key:       "global",
+			limit:     l.config.RateLimitGlobal,
+			blockTime: l.config.RateLimitGlobalBlockTime,
+		})
 	}
 
-	if count > int64(limit) {
-		err = l.storage.SetBlock(identifier, blockTime)
-		if err != nil {
-			return nil, fmt.Errorf("failed to set block: %w", err)
+	if identity.Tenant != "" {
+		if limit := l.tenantLimit(identity.Tenant); limit > 0 {
+			rules = append(rules, rule{
+				level:     LevelTenant,
+				key:       fmt.Sprintf("tenant:%s", identity.Tenant),
+				limit:     limit,
+				blockTime: l.config.RateLimitTenantBlockTime,
+			})
 		}
+	}
 
-		return &Result{
-			Allowed: false,
-			Reason:  "limit_exceeded",
-		}, nil
+	if identity.Token != "" {
+		rules = append(rules, l.tokenRule(identity.Token))
 	}
 
-	return &Result{
-		Allowed: true,
-		Reason:  "allowed",
-	}, nil
+	if identity.IP != "" && (identity.Token == "" || l.config.RateLimitIPWithToken) {
+		rules = append(rules, l.ipRule(identity.IP))
+	}
+
+	return rules
 }
 
-func (l *Limiter) CheckIPLimit(ip string) (*Result, error) {
-	return l.CheckLimit(
-		fmt.Sprintf("ip:%s", ip),
-		l.config.RateLimitIP,
-		l.config.RateLimitIPBlockTime,
-	)
+// tenantLimit retorna o limite de RateLimitTenantLimits para o tenant, ou
+// RateLimitTenantDefault se ele não tiver um próprio.
+func (l *Limiter) tenantLimit(tenant string) int {
+	if limit, ok := l.config.RateLimitTenantLimits[tenant]; ok {
+		return limit
+	}
+	return l.config.RateLimitTenantDefault
 }
 
-func (l *Limiter) CheckTokenLimit(token string) (*Result, error) {
-	var tokenLimit int
+func (l *Limiter) ipRule(ip string) rule {
+	r := rule{
+		level:     LevelIP,
+		key:       fmt.Sprintf("ip:%s", ip),
+		limit:     l.config.RateLimitIP,
+		blockTime: l.config.RateLimitIPBlockTime,
+	}
+
+	if geoRule, ok := l.geoRule(ip); ok {
+		// A chave continua sendo a do IP: só o limite muda.
+		r.level = LevelGeo
+		r.limit = geoRule.Limit
+		if l.config.GeoIPBlockTime > 0 {
+			r.blockTime = l.config.GeoIPBlockTime
+		}
+	}
+
+	return r
+}
+
+// geoRule procura a regra de país/ASN do IP. Falhas na consulta mantêm o
+// limite padrão, como acontece com os limites customizados de token.
+func (l *Limiter) geoRule(ip string) (geoip.Rule, bool) {
+	if l.geo == nil || len(l.config.GeoIPRules) == 0 {
+		return geoip.Rule{}, false
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return geoip.Rule{}, false
+	}
+
+	info, err := l.geo.Lookup(parsed)
+	if err != nil {
+		return geoip.Rule{}, false
+	}
+	return l.config.GeoIPRules.Match(info)
+}
+
+func (l *Limiter) tokenRule(token string) rule {
+	tokenLimit := l.config.RateLimitTokenDefault
 	if tokenLimiter, ok := l.storage.(storage.TokenLimiter); ok {
 		customLimit, err := tokenLimiter.GetTokenLimit(token)
 		if err == nil && customLimit > 0 {
 			tokenLimit = customLimit
-		} else {
-			tokenLimit = l.config.RateLimitTokenDefault
 		}
-	} else {
-		tokenLimit = l.config.RateLimitTokenDefault
 	}
 
-	return l.CheckLimit(
-		fmt.Sprintf("token:%s", token),
-		tokenLimit,
-		l.config.RateLimitTokenBlockTime,
-	)
+	return rule{
+		level:     LevelToken,
+		key:       fmt.Sprintf("token:%s", token),
+		limit:     tokenLimit,
+		blockTime: l.config.RateLimitTokenBlockTime,
+	}
+}
+
+// evaluate verifica primeiro os bloqueios de todos os níveis, para que um
+// nível bloqueado não consuma a cota dos demais, e depois incrementa os
+// contadores em ordem. Se um nível posterior negar, os incrementos dos
+// anteriores são desfeitos, para que requisições negadas (por exemplo, de um
+// IP abusivo) não consumam a cota global e a do tenant.
+func (l *Limiter) evaluate(rules []rule, penalize bool) (*Result, error) {
+	if l.adaptive != nil {
+		for i := range rules {
+			rules[i].limit = l.adaptive.scale(rules[i].limit)
+		}
+	}
+
+	for _, r := range rules {
+		blocked, err := l.storage.IsBlocked(r.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check block status: %w", err)
+		}
+		if blocked {
+			return l.blockedResult(r), nil
+		}
+	}
+
+	result := &Result{
+		Allowed: true,
+		Reason:  "allowed",
+	}
+
+	for i, r := range rules {
+		count, err := l.storage.Increment(r.key, window)
+		if err != nil {
+			l.rollback(rules[:i])
+			return nil, fmt.Errorf("failed to increment counter: %w", err)
+		}
+
+		if count == -1 {
+			l.rollback(rules[:i])
+			return l.blockedResult(r), nil
+		}
+
+		if count > int64(r.limit) {
+			if penalize {
+				l.rollback(rules[:i])
+			} else {
+				l.rollback(rules[:i+1])
+			}
+
+			exceeded := &Result{
+				Allowed: false,
+				Reason:  "limit_exceeded",
+				Level:   r.level,
+				Key:     r.key,
+				Limit:   r.limit,
+				Count:   count,
+			}
+
+			if penalize && r.blockTime > 0 {
+				err = l.storage.SetBlock(r.key, r.blockTime)
+				if err != nil {
+					return nil, fmt.Errorf("failed to set block: %w", err)
+				}
+				exceeded.BlockedUntil = time.Now().Add(r.blockTime)
+				exceeded.RetryAfter = r.blockTime
+			} else {
+				exceeded.RetryAfter = l.windowRemaining(r.key)
+			}
+
+			return exceeded, nil
+		}
+
+		result.Level = r.level
+		result.Key = r.key
+		result.Limit = r.limit
+		result.Count = count
+	}
+
+	return result, nil
+}
+
+// rollback desfaz os incrementos dos níveis já contabilizados. Falhas apenas
+// deixam a requisição contada, como antes do rollback existir.
+func (l *Limiter) rollback(rules []rule) {
+	decrementer, ok := l.storage.(storage.Decrementer)
+	if !ok {
+		return
+	}
+	for _, r := range rules {
+		decrementer.Decrement(r.key)
+	}
+}
+
+func (l *Limiter) blockedResult(r rule) *Result {
+	result := &Result{
+		Allowed: false,
+		Reason:  "blocked",
+		Level:   r.level,
+		Key:     r.key,
+		Limit:   r.limit,
+	}
+
+	if inspector, ok := l.storage.(storage.BlockInspector); ok {
+		if ttl, err := inspector.BlockTTL(r.key); err == nil && ttl > 0 {
+			result.BlockedUntil = time.Now().Add(ttl)
+			result.RetryAfter = ttl
+		}
+	}
+
+	return result
+}
+
+func (l *Limiter) windowRemaining(key string) time.Duration {
+	if inspector, ok := l.storage.(storage.CounterInspector); ok {
+		if _, ttl, err := inspector.Counter(key); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return window
 }
@@ -1,10 +1,13 @@
 package limiter
 
 import (
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"rate-limiter/config"
+	"rate-limiter/geoip"
 	"rate-limiter/storage"
 )
 
@@ -161,3 +164,284 @@ func TestDifferentIPs(t *testing.T) {
 		t.Error("IP3 should be allowed (different IP)")
 	}
 }
+
+func TestCheck_CompositeLevels(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:             100,
+		RateLimitIPBlockTime:    5 * time.Second,
+		RateLimitTokenDefault:   100,
+		RateLimitTokenBlockTime: 5 * time.Second,
+		RateLimitTenantDefault:  3,
+		RateLimitGlobal:         5,
+	}
+
+	limiter := NewLimiter(memStorage, cfg)
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Check(Identity{IP: "10.0.0.1", Token: fmt.Sprintf("key-%d", i), Tenant: "acme"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("Request %d should be allowed, denied by %s", i+1, result.Level)
+		}
+	}
+
+	result, err := limiter.Check(Identity{IP: "10.0.0.1", Token: "key-3", Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("4th request for tenant should be denied")
+	}
+	if result.Level != LevelTenant {
+		t.Errorf("Expected level '%s', got '%s'", LevelTenant, result.Level)
+	}
+
+	result, err = limiter.Check(Identity{IP: "10.0.0.2", Tenant: "other"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Allowed {
+		t.Errorf("Another tenant should be allowed, denied by %s", result.Level)
+	}
+
+	// A requisição negada pelo tenant não conta no global: esta é a 5ª.
+	result, err = limiter.Check(Identity{IP: "10.0.0.3", Tenant: "third"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Allowed {
+		t.Errorf("5th allowed request should fit the global limit, denied by %s", result.Level)
+	}
+
+	result, err = limiter.Check(Identity{IP: "10.0.0.4", Tenant: "fourth"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("Global limit should deny the 6th request")
+	}
+	if result.Level != LevelGlobal {
+		t.Errorf("Expected level '%s', got '%s'", LevelGlobal, result.Level)
+	}
+	if !result.BlockedUntil.IsZero() {
+		t.Error("Global level without block time should not block")
+	}
+}
+
+func TestCheck_DeniedRequestsDoNotConsumeEarlierLevels(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:            2,
+		RateLimitIPBlockTime:   5 * time.Second,
+		RateLimitTenantDefault: 5,
+		RateLimitGlobal:        5,
+	}
+
+	limiter := NewLimiter(memStorage, cfg)
+
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.Check(Identity{IP: "10.0.0.1", Tenant: "acme"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	for _, key := range []string{"global", "tenant:acme"} {
+		count, _, err := memStorage.Counter(key)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected %s to count only the 2 allowed requests, got %d", key, count)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Check(Identity{IP: fmt.Sprintf("10.0.1.%d", i), Tenant: "acme"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("Request from another IP should be allowed, denied by %s", result.Level)
+		}
+	}
+}
+
+func TestCheckWithoutBlock_DeniedAttemptsAreNotCounted(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          1,
+		RateLimitIPBlockTime: 5 * time.Second,
+		RateLimitGlobal:      10,
+	}
+
+	limiter := NewLimiter(memStorage, cfg)
+	identity := Identity{IP: "10.0.0.1"}
+
+	result, err := limiter.CheckWithoutBlock(identity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Allowed {
+		t.Fatalf("First request should be allowed, denied by %s", result.Level)
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.CheckWithoutBlock(identity)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Allowed {
+			t.Fatalf("Retry %d should be denied", i+1)
+		}
+		if result.RetryAfter <= 0 {
+			t.Errorf("Retry %d should report the time left in the window, got %v", i+1, result.RetryAfter)
+		}
+	}
+
+	for _, key := range []string{"global", "ip:10.0.0.1"} {
+		count, _, err := memStorage.Counter(key)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected %s to count only the allowed request, got %d", key, count)
+		}
+	}
+
+	blocked, err := memStorage.IsBlocked("ip:10.0.0.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if blocked {
+		t.Error("CheckWithoutBlock should not block the key")
+	}
+}
+
+func TestCheck_PerTenantLimits(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:            100,
+		RateLimitIPBlockTime:   5 * time.Second,
+		RateLimitTenantDefault: 1,
+		RateLimitTenantLimits:  map[string]int{"acme": 3},
+	}
+
+	limiter := NewLimiter(memStorage, cfg)
+
+	allowed := func(tenant string) int {
+		count := 0
+		for i := 0; i < 5; i++ {
+			result, err := limiter.Check(Identity{IP: fmt.Sprintf("10.0.0.%d", i), Tenant: tenant})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Allowed {
+				count++
+			}
+		}
+		return count
+	}
+
+	if count := allowed("acme"); count != 3 {
+		t.Errorf("Expected 3 requests allowed for acme, got %d", count)
+	}
+	if count := allowed("globex"); count != 1 {
+		t.Errorf("Expected the default of 1 request allowed for globex, got %d", count)
+	}
+}
+
+func TestCheck_IPWithToken(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:             2,
+		RateLimitIPBlockTime:    5 * time.Second,
+		RateLimitTokenDefault:   10,
+		RateLimitTokenBlockTime: 5 * time.Second,
+		RateLimitIPWithToken:    true,
+	}
+
+	limiter := NewLimiter(memStorage, cfg)
+	identity := Identity{IP: "10.0.0.1", Token: "shared-ip-token"}
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Check(identity)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("Request %d should be allowed", i+1)
+		}
+	}
+
+	result, err := limiter.Check(identity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed || result.Level != LevelIP {
+		t.Errorf("Expected denial by IP level, got allowed=%t level='%s'", result.Allowed, result.Level)
+	}
+
+	result, err = limiter.Check(identity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Reason != "blocked" || result.Level != LevelIP {
+		t.Errorf("Expected IP block, got reason='%s' level='%s'", result.Reason, result.Level)
+	}
+}
+
+type fakeGeoResolver map[string]geoip.Info
+
+func (f fakeGeoResolver) Lookup(ip net.IP) (geoip.Info, error) {
+	return f[ip.String()], nil
+}
+
+func TestCheck_GeoRules(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          5,
+		RateLimitIPBlockTime: 5 * time.Second,
+		GeoIPRules:           geoip.Rules{{ASN: 16509, Limit: 2}},
+		GeoIPBlockTime:       time.Minute,
+	}
+	resolver := fakeGeoResolver{
+		"3.5.140.2": {Country: "US", ASN: 16509},
+		"177.1.2.3": {Country: "BR", ASN: 28573},
+	}
+
+	limiter := NewLimiter(memStorage, cfg, WithGeoResolver(resolver))
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Check(Identity{IP: "3.5.140.2"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("Request %d from the datacenter ASN should be allowed", i+1)
+		}
+	}
+
+	result, err := limiter.Check(Identity{IP: "3.5.140.2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed || result.Level != LevelGeo || result.Key != "ip:3.5.140.2" {
+		t.Errorf("3rd request should be denied by the geo rule, got %+v", result)
+	}
+	if result.RetryAfter != time.Minute {
+		t.Errorf("Expected GeoIPBlockTime as block, got %v", result.RetryAfter)
+	}
+
+	for i := 0; i < 5; i++ {
+		result, err := limiter.Check(Identity{IP: "177.1.2.3"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed || result.Level != LevelIP {
+			t.Errorf("Request %d without a geo rule should use RateLimitIP, got %+v", i+1, result)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"rate-limiter/audit"
 	"rate-limiter/config"
 	"rate-limiter/limiter"
 	"rate-limiter/storage"
@@ -171,3 +177,274 @@ func TestExtractToken(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimiterMiddleware_AuditLog(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          1,
+		RateLimitIPBlockTime: 5 * time.Second,
+	}
+
+	var buf bytes.Buffer
+	rateLimiter := limiter.NewLimiter(memStorage, cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter, WithAuditLogger(audit.NewLogger(&buf, 0)))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/resource", nil)
+		req.Header.Set("X-Real-Ip", "10.1.1.1")
+		rec := httptest.NewRecorder()
+		middleware.Handler(handler).ServeHTTP(rec, req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 audit events (denied, blocked), got %d: %s", len(lines), buf.String())
+	}
+
+	var denied audit.Event
+	if err := json.Unmarshal([]byte(lines[0]), &denied); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if denied.Decision != audit.DecisionDenied || denied.Rule != "ip" || denied.IP != "10.1.1.1" {
+		t.Errorf("Unexpected denied event: %+v", denied)
+	}
+	if denied.Count != 2 || denied.Limit != 1 {
+		t.Errorf("Expected count 2 and limit 1, got %d and %d", denied.Count, denied.Limit)
+	}
+	if denied.BlockExpiresAt == nil {
+		t.Error("Denied event should include block expiry")
+	}
+
+	var blocked audit.Event
+	if err := json.Unmarshal([]byte(lines[1]), &blocked); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if blocked.Decision != audit.DecisionBlocked || blocked.BlockExpiresAt == nil {
+		t.Errorf("Unexpected blocked event: %+v", blocked)
+	}
+}
+
+func TestRateLimiterMiddleware_TenantLimit(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitTokenDefault:   10,
+		RateLimitTokenBlockTime: 5 * time.Second,
+		RateLimitTenantDefault:  2,
+	}
+
+	rateLimiter := limiter.NewLimiter(memStorage, cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter, WithTenantHeader("X-Org"))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	codes := make([]int, 0, 3)
+	for _, token := range []string{"key-a", "key-b", "key-c"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("API_KEY", token)
+		req.Header.Set("X-Org", "acme")
+		rec := httptest.NewRecorder()
+
+		middleware.Handler(handler).ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Errorf("First two requests should return 200, got %v", codes)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Third request from the same tenant should return 429, got %d", codes[2])
+	}
+}
+
+func TestRateLimiterMiddleware_TenantFromToken(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitTokenDefault:   10,
+		RateLimitTokenBlockTime: 5 * time.Second,
+		RateLimitTenantDefault:  2,
+	}
+
+	rateLimiter := limiter.NewLimiter(memStorage, cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter, WithTokenTenants(map[string]string{
+		"key-a": "acme",
+		"key-b": "acme",
+		"key-c": "acme",
+	}))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	codes := make([]int, 0, 3)
+	for _, token := range []string{"key-a", "key-b", "key-c"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("API_KEY", token)
+		// Sem WithTenantHeader, o header enviado pelo cliente é ignorado.
+		req.Header.Set("X-Tenant-ID", "spoofed-"+token)
+		rec := httptest.NewRecorder()
+
+		middleware.Handler(handler).ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Errorf("First two requests should return 200, got %v", codes)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Third request from the same tenant should return 429, got %d", codes[2])
+	}
+}
+
+func TestRateLimiterMiddleware_ObservesUpstreamHealth(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          100,
+		RateLimitIPBlockTime: 5 * time.Second,
+	}
+
+	adaptive := limiter.NewAdaptiveController(limiter.AdaptiveConfig{
+		Interval:           time.Second,
+		ErrorRateThreshold: 0.5,
+		MinSamples:         4,
+		DecreaseFactor:     0.5,
+		IncreaseStep:       0.1,
+		MinMultiplier:      0.1,
+	})
+	rateLimiter := limiter.NewLimiter(memStorage, cfg, limiter.WithAdaptiveController(adaptive))
+	middleware := NewRateLimiterMiddleware(rateLimiter)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for i := 0; i < 4; i++ {
+		rec := httptest.NewRecorder()
+		middleware.Handler(handler).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	}
+
+	stats := adaptive.Adjust()
+	if stats.Samples != 4 || stats.ErrorRate != 1 {
+		t.Errorf("Expected 4 failed samples, got %d samples with error rate %v", stats.Samples, stats.ErrorRate)
+	}
+	if stats.Multiplier != 0.5 {
+		t.Errorf("Expected multiplier 0.5, got %v", stats.Multiplier)
+	}
+}
+
+func TestRateLimiterMiddleware_AdaptivePassesThroughFlushAndHijack(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:          100,
+		RateLimitIPBlockTime: 5 * time.Second,
+	}
+
+	adaptive := limiter.NewAdaptiveController(limiter.AdaptiveConfig{
+		Interval:       time.Second,
+		MinSamples:     1,
+		DecreaseFactor: 0.5,
+		IncreaseStep:   0.1,
+		MinMultiplier:  0.1,
+	})
+	rateLimiter := limiter.NewLimiter(memStorage, cfg, limiter.WithAdaptiveController(adaptive))
+	middleware := NewRateLimiterMiddleware(rateLimiter)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			hijacker, ok := w.(http.Hijacker)
+			if !ok {
+				t.Error("Expected the response writer to implement http.Hijacker")
+				return
+			}
+			conn, rw, err := hijacker.Hijack()
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\nhijacked")
+			rw.Flush()
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Error("Expected the response writer to implement http.Flusher")
+			return
+		}
+		w.Write([]byte("data: ping\n\n"))
+		flusher.Flush()
+	})
+
+	rec := httptest.NewRecorder()
+	middleware.Handler(handler).ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	if !rec.Flushed {
+		t.Error("Expected the flush to reach the underlying response writer")
+	}
+
+	server := httptest.NewServer(middleware.Handler(handler))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: example\r\n\r\n"))
+
+	body, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(body), "hijacked") {
+		t.Errorf("Expected the hijacked connection response, got %q", body)
+	}
+
+	stats := adaptive.Adjust()
+	if stats.Samples != 2 || stats.ErrorRate != 0 {
+		t.Errorf("Expected 2 successful samples, got %d samples with error rate %v", stats.Samples, stats.ErrorRate)
+	}
+}
+
+func TestRateLimiterMiddleware_ConnectionLimit(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	cfg := &config.Config{
+		RateLimitIP:              1,
+		RateLimitIPBlockTime:     5 * time.Second,
+		RateLimitConnectionIP:    2,
+		RateLimitConnectionBlock: time.Minute,
+	}
+
+	rateLimiter := limiter.NewLimiter(memStorage, cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		middleware.ConnectionHandler(handler).ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Errorf("First two connections should be accepted, got %v", codes)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Third connection should return 429, got %d", codes[2])
+	}
+
+	rec := httptest.NewRecorder()
+	middleware.Handler(handler).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Connection limit should not consume the request quota, got %d", rec.Code)
+	}
+}
@@ -1,61 +1,248 @@
 package middleware
 
 import (
+	"bufio"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"rate-limiter/audit"
 	"rate-limiter/limiter"
 )
 
 type RateLimiterMiddleware struct {
-	limiter *limiter.Limiter
+	limiter        *limiter.Limiter
+	auditLogger    *audit.Logger
+	tokenTenants   map[string]string
+	tenantResolver func(r *http.Request) string
+	throttle       *throttle
+	denied         DeniedHandler
 }
 
-func NewRateLimiterMiddleware(limiter *limiter.Limiter) *RateLimiterMiddleware {
-	return &RateLimiterMiddleware{
+type Option func(*RateLimiterMiddleware)
+
+func WithAuditLogger(logger *audit.Logger) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.auditLogger = logger
+	}
+}
+
+// WithTokenTenants associa tokens de API aos seus tenants. É a forma padrão
+// de identificar o tenant: derivado da credencial, não de um valor que o
+// cliente escolhe.
+func WithTokenTenants(tenants map[string]string) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.tokenTenants = tenants
+	}
+}
+
+// WithTenantHeader passa a confiar no header para identificar o tenant de
+// requisições cujo token não está em WithTokenTenants. Só deve ser usado
+// quando um componente confiável (API gateway) define o header e descarta o
+// valor enviado pelo cliente; do contrário, o cliente escapa do limite do
+// próprio tenant ou consome a cota de outro.
+func WithTenantHeader(header string) Option {
+	return WithTenantResolver(func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(header))
+	})
+}
+
+func WithTenantResolver(resolver func(r *http.Request) string) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.tenantResolver = resolver
+	}
+}
+
+// WithDeniedHandler substitui a resposta padrão das requisições negadas,
+// por exemplo por um ResponseRenderer configurado ou um handler próprio.
+func WithDeniedHandler(handler DeniedHandler) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.denied = handler
+	}
+}
+
+// WithThrottle troca a rejeição imediata por espera: requisições acima do
+// limite aguardam em uma fila por chave (até queueSize por chave) por no
+// máximo maxWait antes de receber 429. Nesse modo não há bloqueio punitivo.
+func WithThrottle(maxWait time.Duration, queueSize int) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.throttle = newThrottle(maxWait, queueSize)
+	}
+}
+
+func NewRateLimiterMiddleware(limiter *limiter.Limiter, opts ...Option) *RateLimiterMiddleware {
+	m := &RateLimiterMiddleware{
 		limiter: limiter,
+		denied:  ResponseRenderer{}.Render,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *RateLimiterMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := extractToken(r)
+		identity := m.identify(r)
 
-		if token != "" {
-			result, err := m.limiter.CheckTokenLimit(token)
-			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+		result, err := m.check(r, identity)
+		if err != nil {
+			if r.Context().Err() != nil {
+				// O cliente desistiu enquanto aguardava na fila.
 				return
 			}
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
 
-			if !result.Allowed {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"error": "you have reached the maximum number of requests or actions allowed within a certain time frame"}`))
-				return
-			}
+		m.audit(r, identity, result)
+
+		if !result.Allowed {
+			m.denied(w, r, result)
+			return
 		}
 
-		if token == "" {
-			ip := getClientIP(r)
-			result, err := m.limiter.CheckIPLimit(ip)
-			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
-				return
-			}
+		adaptive := m.limiter.Adaptive()
+		if adaptive == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			if !result.Allowed {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"error": "you have reached the maximum number of requests or actions allowed within a certain time frame"}`))
-				return
-			}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		adaptive.Observe(time.Since(start), recorder.status >= http.StatusInternalServerError)
+	})
+}
+
+// ConnectionHandler protege endpoints de conexões de longa duração
+// (WebSocket, SSE) com Limiter.CheckConnection no lugar do limite por
+// requisição. A negação acontece antes do upgrade, com a mesma resposta das
+// requisições negadas.
+func (m *RateLimiterMiddleware) ConnectionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := m.identify(r)
+
+		result, err := m.limiter.CheckConnection(identity)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		m.audit(r, identity, result)
+
+		if !result.Allowed {
+			m.denied(w, r, result)
+			return
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+func (m *RateLimiterMiddleware) identify(r *http.Request) limiter.Identity {
+	identity := limiter.Identity{
+		IP:    getClientIP(r),
+		Token: extractToken(r),
+	}
+
+	if identity.Token != "" {
+		identity.Tenant = m.tokenTenants[identity.Token]
+	}
+	if identity.Tenant == "" && m.tenantResolver != nil {
+		identity.Tenant = m.tenantResolver(r)
+	}
+
+	return identity
+}
+
+func (m *RateLimiterMiddleware) check(r *http.Request, identity limiter.Identity) (*limiter.Result, error) {
+	if m.throttle == nil {
+		return m.limiter.Check(identity)
+	}
+
+	check := func() (*limiter.Result, error) {
+		return m.limiter.CheckWithoutBlock(identity)
+	}
+	return m.throttle.wait(r.Context(), throttleKey(identity), check)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush repassa o flush ao ResponseWriter original para que respostas em
+// streaming (SSE) continuem funcionando com o limitador adaptativo ativo.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack repassa o hijack ao ResponseWriter original, necessário para o
+// upgrade de WebSocket. A conexão tomada é registrada como 101.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (m *RateLimiterMiddleware) audit(r *http.Request, identity limiter.Identity, result *limiter.Result) {
+	if m.auditLogger == nil {
+		return
+	}
+
+	event := audit.Event{
+		Decision:  decisionFor(result),
+		Rule:      result.Level,
+		Key:       result.Key,
+		IP:        identity.IP,
+		TokenHash: audit.HashToken(identity.Token),
+		Tenant:    identity.Tenant,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Count:     result.Count,
+		Limit:     result.Limit,
+	}
+	if !result.BlockedUntil.IsZero() {
+		blockedUntil := result.BlockedUntil.UTC()
+		event.BlockExpiresAt = &blockedUntil
+	}
+
+	if err := m.auditLogger.Log(event); err != nil {
+		log.Printf("failed to write audit event: %v", err)
+	}
+}
+
+func decisionFor(result *limiter.Result) string {
+	switch {
+	case result.Allowed:
+		return audit.DecisionAllowed
+	case result.Reason == "blocked":
+		return audit.DecisionBlocked
+	default:
+		return audit.DecisionDenied
+	}
+}
+
 func extractToken(r *http.Request) string {
 	token := r.Header.Get("API_KEY")
 	if token != "" {
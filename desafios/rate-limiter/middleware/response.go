@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rate-limiter/limiter"
+)
+
+const (
+	DefaultJSONTemplate = `{"error": "you have reached the maximum number of requests or actions allowed within a certain time frame"}`
+	DefaultHTMLTemplate = `<!DOCTYPE html><html><head><title>Too Many Requests</title></head><body><h1>Too Many Requests</h1><p>you have reached the maximum number of requests or actions allowed within a certain time frame</p></body></html>`
+	DefaultTextTemplate = "you have reached the maximum number of requests or actions allowed within a certain time frame\n"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeHTML = "text/html; charset=utf-8"
+	contentTypeText = "text/plain; charset=utf-8"
+)
+
+// DeniedHandler escreve a resposta de uma requisição negada pelo limiter.
+type DeniedHandler func(w http.ResponseWriter, r *http.Request, result *limiter.Result)
+
+// ResponseRenderer é o DeniedHandler padrão. O formato (JSON, HTML ou texto)
+// é escolhido pelo header Accept, com JSON quando nada é informado ou
+// aceito. Os templates aceitam os placeholders {limit}, {retry_after} (em
+// segundos) e {reason}, escapados conforme o formato. Campos vazios usam os
+// padrões.
+type ResponseRenderer struct {
+	Status       int
+	JSONTemplate string
+	HTMLTemplate string
+	TextTemplate string
+}
+
+func (rr ResponseRenderer) Render(w http.ResponseWriter, r *http.Request, result *limiter.Result) {
+	status := rr.Status
+	if status == 0 {
+		status = http.StatusTooManyRequests
+	}
+
+	retryAfter := retryAfterSeconds(result)
+	contentType := negotiate(r.Header.Get("Accept"), contentTypeJSON, contentTypeHTML, contentTypeText)
+
+	var template string
+	var escape func(string) string
+	switch contentType {
+	case contentTypeHTML:
+		template, escape = orDefault(rr.HTMLTemplate, DefaultHTMLTemplate), html.EscapeString
+	case contentTypeText:
+		template, escape = orDefault(rr.TextTemplate, DefaultTextTemplate), func(s string) string { return s }
+	default:
+		template, escape = orDefault(rr.JSONTemplate, DefaultJSONTemplate), escapeJSON
+	}
+
+	body := strings.NewReplacer(
+		"{limit}", strconv.Itoa(result.Limit),
+		"{retry_after}", strconv.Itoa(retryAfter),
+		"{reason}", escape(result.Reason),
+	).Replace(template)
+
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func orDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func retryAfterSeconds(result *limiter.Result) int {
+	if result.RetryAfter <= 0 {
+		return 0
+	}
+	return int(math.Ceil(result.RetryAfter.Seconds()))
+}
+
+// escapeJSON escapa s para uso dentro de uma string JSON já delimitada por
+// aspas no template.
+func escapeJSON(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// negotiate escolhe entre offers (em ordem de preferência) o tipo com maior
+// qualidade no header Accept. Sem Accept, ou sem nenhum tipo aceitável,
+// retorna o primeiro.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, mediaType(offer)); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality retorna o q do media range mais específico de accept que
+// casa com mediaType, ou 0 se nenhum casar.
+func acceptQuality(accept string, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		accepted := strings.ToLower(strings.TrimSpace(params[0]))
+
+		var s int
+		switch accepted {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
+
+func mediaType(contentType string) string {
+	value, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rate-limiter/config"
+	"rate-limiter/limiter"
+	"rate-limiter/storage"
+)
+
+func TestResponseRenderer_NegotiatesFormat(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{accept: "", contentType: contentTypeJSON, body: DefaultJSONTemplate},
+		{accept: "*/*", contentType: contentTypeJSON, body: DefaultJSONTemplate},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", contentType: contentTypeHTML, body: DefaultHTMLTemplate},
+		{accept: "text/plain", contentType: contentTypeText, body: DefaultTextTemplate},
+		{accept: "text/*", contentType: contentTypeHTML, body: DefaultHTMLTemplate},
+		{accept: "application/json;q=0.5, text/plain", contentType: contentTypeText, body: DefaultTextTemplate},
+		{accept: "text/*;q=0.9, text/html;q=0", contentType: contentTypeText, body: DefaultTextTemplate},
+		{accept: "image/png", contentType: contentTypeJSON, body: DefaultJSONTemplate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+
+			ResponseRenderer{}.Render(rec, req, &limiter.Result{Reason: "limit_exceeded"})
+
+			if rec.Code != http.StatusTooManyRequests {
+				t.Errorf("Expected 429, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.contentType, got)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestResponseRenderer_Placeholders(t *testing.T) {
+	renderer := ResponseRenderer{
+		Status:       http.StatusServiceUnavailable,
+		JSONTemplate: `{"limit": {limit}, "retry_after": {retry_after}, "reason": "{reason}"}`,
+		HTMLTemplate: `<p>{reason}</p>`,
+	}
+	result := &limiter.Result{
+		Limit:      10,
+		Reason:     `<"quoted">`,
+		RetryAfter: 1500 * time.Millisecond,
+	}
+
+	rec := httptest.NewRecorder()
+	renderer.Render(rec, httptest.NewRequest("GET", "/", nil), result)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	expected := `{"limit": 10, "retry_after": 2, "reason": "\u003c\"quoted\"\u003e"}`
+	if rec.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	renderer.Render(rec, req, result)
+
+	if expected := `<p>&lt;&#34;quoted&#34;&gt;</p>`; rec.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, rec.Body.String())
+	}
+}
+
+func TestRateLimiterMiddleware_CustomDeniedHandler(t *testing.T) {
+	cfg := &config.Config{
+		RateLimitIP:          1,
+		RateLimitIPBlockTime: 5 * time.Second,
+	}
+
+	var denied *limiter.Result
+	rateLimiter := limiter.NewLimiter(storage.NewMemoryStorage(), cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter, WithDeniedHandler(func(w http.ResponseWriter, r *http.Request, result *limiter.Result) {
+		denied = result
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if i == 1 && rec.Code != http.StatusTeapot {
+			t.Errorf("Expected custom status 418, got %d", rec.Code)
+		}
+	}
+
+	if denied == nil || denied.Level != limiter.LevelIP || denied.RetryAfter != 5*time.Second {
+		t.Errorf("Custom handler should receive the denial result, got %+v", denied)
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rate-limiter/limiter"
+)
+
+const ReasonQueueFull = "queue_full"
+
+// throttle segura requisições acima do limite em uma fila por chave (token ou
+// IP) até que haja capacidade ou maxWait se esgote. Toda requisição entra na
+// fila da sua chave, e só quem está na vez consulta o limiter; os demais
+// aguardam na ordem de chegada. Assim quem chega com a fila ocupada não passa
+// à frente de quem já está esperando.
+type throttle struct {
+	maxWait   time.Duration
+	queueSize int
+
+	mu     sync.Mutex
+	queues map[string]*keyQueue
+}
+
+// keyQueue é a fila de uma chave. waiting e denied são protegidos por
+// throttle.mu e turn guarda a vez de consultar o limiter, passada adiante por
+// quem a libera. denied é a última negação do limiter na fila, usada para
+// dizer qual nível e limite as requisições rejeitadas sem consultá-lo
+// (fila cheia ou sem vez a tempo) encontraram.
+type keyQueue struct {
+	turn    chan struct{}
+	waiting int
+	denied  *limiter.Result
+}
+
+func newThrottle(maxWait time.Duration, queueSize int) *throttle {
+	return &throttle{
+		maxWait:   maxWait,
+		queueSize: queueSize,
+		queues:    make(map[string]*keyQueue),
+	}
+}
+
+// join coloca a requisição na fila da chave, criando-a se necessário. Retorna
+// false com a fila cheia, junto da fila para que a rejeição use sua última
+// negação.
+func (t *throttle) join(key string) (*keyQueue, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q, exists := t.queues[key]
+	if !exists {
+		q = &keyQueue{turn: make(chan struct{}, 1)}
+		q.turn <- struct{}{}
+		t.queues[key] = q
+	}
+
+	if q.waiting >= t.queueSize {
+		return q, false
+	}
+
+	q.waiting++
+	return q, true
+}
+
+func (t *throttle) leave(key string, q *keyQueue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q.waiting--
+	if q.waiting == 0 {
+		delete(t.queues, key)
+	}
+}
+
+// deny registra result como a última negação da fila.
+func (t *throttle) deny(q *keyQueue, result *limiter.Result) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q.denied = result
+}
+
+// queueFull é a rejeição de quem não chegou a consultar o limiter, com o nível
+// e o limite da última negação da fila.
+func (t *throttle) queueFull(q *keyQueue) *limiter.Result {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := &limiter.Result{Reason: ReasonQueueFull}
+	if q.denied != nil {
+		result.Level = q.denied.Level
+		result.Limit = q.denied.Limit
+	}
+	return result
+}
+
+// waiting retorna quantas requisições estão na fila da chave, incluindo a que
+// está na vez.
+func (t *throttle) waiting(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if q, exists := t.queues[key]; exists {
+		return q.waiting
+	}
+	return 0
+}
+
+// wait entra na fila da chave e, na sua vez, consulta check até ser permitido,
+// retornando o último resultado negado se maxWait se esgotar antes. check não
+// deve contabilizar tentativas negadas (Limiter.CheckWithoutBlock), para que
+// as novas tentativas não contem como requisições novas.
+func (t *throttle) wait(ctx context.Context, key string, check func() (*limiter.Result, error)) (*limiter.Result, error) {
+	deadline := time.Now().Add(t.maxWait)
+
+	q, ok := t.join(key)
+	if !ok {
+		return t.queueFull(q), nil
+	}
+	defer t.leave(key, q)
+
+	timer := time.NewTimer(t.maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-q.turn:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return t.queueFull(q), nil
+	}
+	defer func() { q.turn <- struct{}{} }()
+
+	for {
+		result, err := check()
+		if err != nil {
+			return nil, err
+		}
+		if result.Allowed {
+			return result, nil
+		}
+		t.deny(q, result)
+
+		delay := result.RetryAfter
+		if delay <= 0 {
+			delay = 10 * time.Millisecond
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return result, nil
+		}
+
+		sleep := time.NewTimer(delay)
+		select {
+		case <-sleep.C:
+		case <-ctx.Done():
+			sleep.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func throttleKey(identity limiter.Identity) string {
+	if identity.Token != "" {
+		return "token:" + identity.Token
+	}
+	return "ip:" + identity.IP
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rate-limiter/config"
+	"rate-limiter/limiter"
+	"rate-limiter/storage"
+)
+
+func newThrottledHandler(limit int, maxWait time.Duration, queueSize int, served *atomic.Int32) (http.Handler, *RateLimiterMiddleware) {
+	cfg := &config.Config{
+		RateLimitIP:          limit,
+		RateLimitIPBlockTime: time.Minute,
+	}
+
+	rateLimiter := limiter.NewLimiter(storage.NewMemoryStorage(), cfg)
+	middleware := NewRateLimiterMiddleware(rateLimiter, WithThrottle(maxWait, queueSize))
+
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	return handler, middleware
+}
+
+func TestThrottle_WaitsForNextWindow(t *testing.T) {
+	var served atomic.Int32
+	handler, _ := newThrottledHandler(2, 2*time.Second, 10, &served)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d should return 200, got %d", i+1, rec.Code)
+		}
+	}
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Throttled request should eventually return 200, got %d", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Throttled request should have waited for the next window, took %v", elapsed)
+	}
+	if served.Load() != 3 {
+		t.Errorf("Expected 3 requests served, got %d", served.Load())
+	}
+}
+
+func TestThrottle_RejectsWhenWaitExceedsMax(t *testing.T) {
+	var served atomic.Int32
+	handler, _ := newThrottledHandler(1, 50*time.Millisecond, 10, &served)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Request should fail fast when the wait exceeds the maximum, took %v", elapsed)
+	}
+}
+
+func TestThrottle_RejectsWhenQueueIsFull(t *testing.T) {
+	var served atomic.Int32
+	handler, middleware := newThrottledHandler(1, 2*time.Second, 1, &served)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	queued := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		queued <- rec.Code
+	}()
+
+	// Espera a primeira requisição entrar na fila.
+	deadline := time.Now().Add(time.Second)
+	for middleware.throttle.waiting("ip:192.0.2.1") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 with the queue full, got %d", rec.Code)
+	}
+
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("Queued request should eventually return 200, got %d", code)
+	}
+}
+
+func TestThrottle_QueueFullRejectionCarriesLevelAndLimit(t *testing.T) {
+	cfg := &config.Config{
+		RateLimitIP:          1,
+		RateLimitIPBlockTime: time.Minute,
+	}
+	denied := make(chan *limiter.Result, 1)
+	middleware := NewRateLimiterMiddleware(
+		limiter.NewLimiter(storage.NewMemoryStorage(), cfg),
+		WithThrottle(2*time.Second, 1),
+		WithDeniedHandler(func(w http.ResponseWriter, r *http.Request, result *limiter.Result) {
+			denied <- result
+			w.WriteHeader(http.StatusTooManyRequests)
+		}),
+	)
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	queued := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		queued <- rec.Code
+	}()
+
+	// Espera a requisição na fila ser negada pelo limiter ao menos uma vez.
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		middleware.throttle.mu.Lock()
+		q := middleware.throttle.queues["ip:192.0.2.1"]
+		ready := q != nil && q.denied != nil
+		middleware.throttle.mu.Unlock()
+		if ready {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	result := <-denied
+	if result.Reason != ReasonQueueFull || result.Level != limiter.LevelIP || result.Limit != 1 {
+		t.Errorf("Expected queue_full rejection at level ip with limit 1, got %+v", result)
+	}
+
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("Queued request should eventually return 200, got %d", code)
+	}
+}
+
+func TestThrottle_StopsWaitingWhenClientGoesAway(t *testing.T) {
+	var served atomic.Int32
+	handler, _ := newThrottledHandler(1, 2*time.Second, 10, &served)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Request should stop waiting when its context is done, took %v", elapsed)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Nothing should be written for a cancelled request, got %q", rec.Body.String())
+	}
+	if served.Load() != 1 {
+		t.Errorf("Cancelled request should not reach the handler, served %d", served.Load())
+	}
+}
+
+func TestThrottle_ConcurrentRequestsShareOneQueue(t *testing.T) {
+	var served atomic.Int32
+	handler, middleware := newThrottledHandler(2, 3*time.Second, 10, &served)
+
+	const requests = 4
+	codes := make(chan int, requests)
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			codes <- rec.Code
+		}()
+	}
+
+	for i := 0; i < requests; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("Every queued request should eventually return 200, got %d", code)
+		}
+	}
+	if served.Load() != requests {
+		t.Errorf("Expected %d requests served, got %d", requests, served.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Requests above the limit should have waited for the next window, took %v", elapsed)
+	}
+	if waiting := middleware.throttle.waiting("ip:192.0.2.1"); waiting != 0 {
+		t.Errorf("Expected the queue to be empty, got %d waiting", waiting)
+	}
+}
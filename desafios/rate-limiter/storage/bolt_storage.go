@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltCountersBucket    = []byte("counters")
+	boltBlocksBucket      = []byte("blocks")
+	boltTokenLimitsBucket = []byte("token_limits")
+)
+
+// BoltStorage persiste contadores, bloqueios e limites de token em um arquivo
+// local, para instalações de um único nó que precisam sobreviver a reinícios.
+type BoltStorage struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	return NewBoltStorageWithClock(path, time.Now)
+}
+
+func NewBoltStorageWithClock(path string, now func() time.Time) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltCountersBucket, boltBlocksBucket, boltTokenLimitsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create bolt buckets: %w", err)
+	}
+
+	return &BoltStorage{
+		db:  db,
+		now: now,
+	}, nil
+}
+
+func (b *BoltStorage) Increment(key string, expiration time.Duration) (int64, error) {
+	var count int64
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		now := b.now()
+
+		blocks := tx.Bucket(boltBlocksBucket)
+		if blockedUntil, ok := decodeTime(blocks.Get([]byte(key))); ok {
+			if now.Before(blockedUntil) {
+				count = -1
+				return nil
+			}
+			if err := blocks.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+
+		counters := tx.Bucket(boltCountersBucket)
+		value, expiresAt := decodeCounter(counters.Get([]byte(key)))
+		if value == 0 || (!expiresAt.IsZero() && !now.Before(expiresAt)) {
+			value = 0
+			expiresAt = time.Time{}
+			if expiration > 0 {
+				expiresAt = now.Add(expiration)
+			}
+		}
+
+		value++
+		count = value
+		return counters.Put([]byte(key), encodeCounter(value, expiresAt))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (b *BoltStorage) Decrement(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		counters := tx.Bucket(boltCountersBucket)
+		value, expiresAt := decodeCounter(counters.Get([]byte(key)))
+		if value == 0 || (!expiresAt.IsZero() && !b.now().Before(expiresAt)) {
+			return nil
+		}
+		return counters.Put([]byte(key), encodeCounter(value-1, expiresAt))
+	})
+}
+
+func (b *BoltStorage) SetBlock(key string, duration time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBlocksBucket).Put([]byte(key), encodeTime(b.now().Add(duration)))
+	})
+}
+
+func (b *BoltStorage) IsBlocked(key string) (bool, error) {
+	ttl, err := b.BlockTTL(key)
+	if err != nil {
+		return false, err
+	}
+	return ttl > 0, nil
+}
+
+func (b *BoltStorage) BlockTTL(key string) (time.Duration, error) {
+	var ttl time.Duration
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		blockedUntil, ok := decodeTime(tx.Bucket(boltBlocksBucket).Get([]byte(key)))
+		if ok {
+			ttl = blockedUntil.Sub(b.now())
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (b *BoltStorage) Counter(key string) (int64, time.Duration, error) {
+	var (
+		value     int64
+		expiresAt time.Time
+	)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		value, expiresAt = decodeCounter(tx.Bucket(boltCountersBucket).Get([]byte(key)))
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if value == 0 || expiresAt.IsZero() {
+		return value, 0, nil
+	}
+
+	ttl := expiresAt.Sub(b.now())
+	if ttl <= 0 {
+		return 0, 0, nil
+	}
+	return value, ttl, nil
+}
+
+func (b *BoltStorage) Reset(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltCountersBucket).Delete([]byte(key)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltBlocksBucket).Delete([]byte(key))
+	})
+}
+
+func (b *BoltStorage) DeleteExpired() (int64, error) {
+	var deleted int64
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		now := b.now()
+
+		// O bbolt não permite remover chaves durante o ForEach.
+		var expired [][]byte
+		err := tx.Bucket(boltCountersBucket).ForEach(func(k, v []byte) error {
+			if _, expiresAt := decodeCounter(v); !expiresAt.IsZero() && !now.Before(expiresAt) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(tx.Bucket(boltCountersBucket), expired); err != nil {
+			return err
+		}
+		deleted += int64(len(expired))
+
+		expired = nil
+		err = tx.Bucket(boltBlocksBucket).ForEach(func(k, v []byte) error {
+			if blockedUntil, ok := decodeTime(v); !ok || !now.Before(blockedUntil) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(tx.Bucket(boltBlocksBucket), expired); err != nil {
+			return err
+		}
+		deleted += int64(len(expired))
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+func deleteKeys(bucket *bolt.Bucket, keys [][]byte) error {
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BoltStorage) GetTokenLimit(token string) (int, error) {
+	var limit int
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(boltTokenLimitsBucket).Get([]byte(token)); len(data) == 8 {
+			limit = int(binary.BigEndian.Uint64(data))
+		}
+		return nil
+	})
+
+	return limit, err
+}
+
+func (b *BoltStorage) SetTokenLimit(token string, limit int) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(limit))
+		return tx.Bucket(boltTokenLimitsBucket).Put([]byte(token), data)
+	})
+}
+
+func (b *BoltStorage) DeleteTokenLimit(token string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTokenLimitsBucket).Delete([]byte(token))
+	})
+}
+
+func (b *BoltStorage) TokenLimits() (map[string]int, error) {
+	limits := make(map[string]int)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTokenLimitsBucket).ForEach(func(k, v []byte) error {
+			if len(v) == 8 {
+				limits[string(k)] = int(binary.BigEndian.Uint64(v))
+			}
+			return nil
+		})
+	})
+
+	return limits, err
+}
+
+func (b *BoltStorage) Counters() (map[string]int64, error) {
+	now := b.now()
+	counters := make(map[string]int64)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCountersBucket).ForEach(func(k, v []byte) error {
+			value, expiresAt := decodeCounter(v)
+			if value > 0 && (expiresAt.IsZero() || now.Before(expiresAt)) {
+				counters[string(k)] = value
+			}
+			return nil
+		})
+	})
+
+	return counters, err
+}
+
+func (b *BoltStorage) Blocks() (map[string]time.Duration, error) {
+	now := b.now()
+	blocks := make(map[string]time.Duration)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBlocksBucket).ForEach(func(k, v []byte) error {
+			if blockedUntil, ok := decodeTime(v); ok && now.Before(blockedUntil) {
+				blocks[string(k)] = blockedUntil.Sub(now)
+			}
+			return nil
+		})
+	})
+
+	return blocks, err
+}
+
+func (b *BoltStorage) Close() error {
+	return b.db.Close()
+}
+
+func encodeTime(t time.Time) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(t.UnixNano()))
+	return data
+}
+
+func decodeTime(data []byte) (time.Time, bool) {
+	if len(data) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))), true
+}
+
+func encodeCounter(value int64, expiresAt time.Time) []byte {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data[:8], uint64(value))
+	if !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(data[8:], uint64(expiresAt.UnixNano()))
+	}
+	return data
+}
+
+func decodeCounter(data []byte) (int64, time.Time) {
+	if len(data) != 16 {
+		return 0, time.Time{}
+	}
+
+	value := int64(binary.BigEndian.Uint64(data[:8]))
+	var expiresAt time.Time
+	if nanos := binary.BigEndian.Uint64(data[8:]); nanos != 0 {
+		expiresAt = time.Unix(0, int64(nanos))
+	}
+	return value, expiresAt
+}
@@ -0,0 +1,142 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"rate-limiter/storage"
+	"rate-limiter/storage/storagetest"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		clock := &fakeClock{now: time.Now()}
+		return storagetest.Backend{
+			Storage: storage.NewMemoryStorageWithClock(clock.Now),
+			Advance: clock.Advance,
+		}
+	})
+}
+
+func TestRedisStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		mr := miniredis.RunT(t)
+
+		redisStorage, err := storage.NewRedisStorageFromClient(
+			redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+			storage.WithCounterPatterns("conformance:*"),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		t.Cleanup(func() { redisStorage.Close() })
+
+		return storagetest.Backend{
+			Storage: redisStorage,
+			Advance: mr.FastForward,
+		}
+	})
+}
+
+func TestRedisStorage_CountersOnlyScansLimiterKeys(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	redisStorage, err := storage.NewRedisStorageFromClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { redisStorage.Close() })
+
+	for _, key := range []string{"ip:10.0.0.1", "global"} {
+		if _, err := redisStorage.Increment(key, time.Minute); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	mr.Set("session:abc", "7")
+	mr.HSet("ip:metadata", "country", "BR")
+	mr.Lpush("token:queue", "job")
+
+	counters, err := redisStorage.Counters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(counters) != 2 || counters["ip:10.0.0.1"] != 1 || counters["global"] != 1 {
+		t.Errorf("Expected only the limiter counters, got %v", counters)
+	}
+}
+
+func TestBoltStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		clock := &fakeClock{now: time.Now()}
+
+		boltStorage, err := storage.NewBoltStorageWithClock(filepath.Join(t.TempDir(), "limiter.db"), clock.Now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		t.Cleanup(func() { boltStorage.Close() })
+
+		return storagetest.Backend{
+			Storage: boltStorage,
+			Advance: clock.Advance,
+		}
+	})
+}
+
+func TestBoltStorage_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiter.db")
+
+	first, err := storage.NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first.Increment("ip:10.0.0.1", time.Minute)
+	first.SetBlock("ip:10.0.0.2", time.Minute)
+	first.SetTokenLimit("persisted-token", 7)
+	first.Close()
+
+	second, err := storage.NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer second.Close()
+
+	count, err := second.Increment("ip:10.0.0.1", time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected count 2 after restart, got %d", count)
+	}
+
+	blocked, _ := second.IsBlocked("ip:10.0.0.2")
+	if !blocked {
+		t.Error("Block should survive restart")
+	}
+
+	limit, _ := second.GetTokenLimit("persisted-token")
+	if limit != 7 {
+		t.Errorf("Expected token limit 7 after restart, got %d", limit)
+	}
+}
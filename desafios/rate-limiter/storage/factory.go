@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"fmt"
+
+	"rate-limiter/config"
+)
+
+const (
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+	BackendBolt     = "bolt"
+	BackendMemory   = "memory"
+)
+
+// New cria o storage selecionado por STORAGE_BACKEND. Todos os backends
+// também implementam TokenLimitStore, BlockInspector, CounterInspector e
+// KeyLister; os persistentes implementam io.Closer.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "", BackendRedis:
+		s, err := NewRedisStorage(cfg.RedisHost, cfg.RedisPort, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BackendPostgres:
+		s, err := NewPostgresStorage(cfg.PostgresDSN)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BackendBolt:
+		s, err := NewBoltStorage(cfg.BoltPath)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BackendMemory:
+		return NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
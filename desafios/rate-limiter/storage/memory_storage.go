@@ -5,18 +5,29 @@ import (
 	"time"
 )
 
+type counter struct {
+	value     int64
+	expiresAt time.Time
+}
+
 type MemoryStorage struct {
 	mu          sync.RWMutex
-	counters    map[string]int64
+	counters    map[string]*counter
 	blocks      map[string]time.Time
 	tokenLimits map[string]int
+	now         func() time.Time
 }
 
 func NewMemoryStorage() *MemoryStorage {
+	return NewMemoryStorageWithClock(time.Now)
+}
+
+func NewMemoryStorageWithClock(now func() time.Time) *MemoryStorage {
 	return &MemoryStorage{
-		counters:    make(map[string]int64),
+		counters:    make(map[string]*counter),
 		blocks:      make(map[string]time.Time),
 		tokenLimits: make(map[string]int),
+		now:         now,
 	}
 }
 
@@ -24,22 +35,44 @@ func (m *MemoryStorage) Increment(key string, expiration time.Duration) (int64,
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	now := m.now()
+
 	if blockTime, exists := m.blocks[key]; exists {
-		if time.Now().Before(blockTime) {
+		if now.Before(blockTime) {
 			return -1, nil
 		}
 		delete(m.blocks, key)
 	}
 
-	m.counters[key]++
-	return m.counters[key], nil
+	c, exists := m.counters[key]
+	if !exists || (!c.expiresAt.IsZero() && !now.Before(c.expiresAt)) {
+		c = &counter{}
+		if expiration > 0 {
+			c.expiresAt = now.Add(expiration)
+		}
+		m.counters[key] = c
+	}
+
+	c.value++
+	return c.value, nil
+}
+
+func (m *MemoryStorage) Decrement(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, exists := m.counters[key]
+	if exists && c.value > 0 && (c.expiresAt.IsZero() || m.now().Before(c.expiresAt)) {
+		c.value--
+	}
+	return nil
 }
 
 func (m *MemoryStorage) SetBlock(key string, duration time.Duration) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.blocks[key] = time.Now().Add(duration)
+	m.blocks[key] = m.now().Add(duration)
 	return nil
 }
 
@@ -52,13 +85,49 @@ func (m *MemoryStorage) IsBlocked(key string) (bool, error) {
 		return false, nil
 	}
 
-	if time.Now().Before(blockTime) {
+	if m.now().Before(blockTime) {
 		return true, nil
 	}
 
 	return false, nil
 }
 
+func (m *MemoryStorage) BlockTTL(key string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blockTime, exists := m.blocks[key]
+	if !exists {
+		return 0, nil
+	}
+
+	ttl := blockTime.Sub(m.now())
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (m *MemoryStorage) Counter(key string) (int64, time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, exists := m.counters[key]
+	if !exists {
+		return 0, 0, nil
+	}
+	if c.expiresAt.IsZero() {
+		return c.value, 0, nil
+	}
+
+	ttl := c.expiresAt.Sub(m.now())
+	if ttl <= 0 {
+		return 0, 0, nil
+	}
+	return c.value, ttl, nil
+}
+
 func (m *MemoryStorage) Reset(key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -68,6 +137,27 @@ func (m *MemoryStorage) Reset(key string) error {
 	return nil
 }
 
+func (m *MemoryStorage) DeleteExpired() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var deleted int64
+	for key, c := range m.counters {
+		if !c.expiresAt.IsZero() && !now.Before(c.expiresAt) {
+			delete(m.counters, key)
+			deleted++
+		}
+	}
+	for key, blockTime := range m.blocks {
+		if !now.Before(blockTime) {
+			delete(m.blocks, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
 func (m *MemoryStorage) GetTokenLimit(token string) (int, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -85,3 +175,50 @@ func (m *MemoryStorage) SetTokenLimit(token string, limit int) error {
 	m.tokenLimits[token] = limit
 	return nil
 }
+
+func (m *MemoryStorage) DeleteTokenLimit(token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tokenLimits, token)
+	return nil
+}
+
+func (m *MemoryStorage) TokenLimits() (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	limits := make(map[string]int, len(m.tokenLimits))
+	for token, limit := range m.tokenLimits {
+		limits[token] = limit
+	}
+	return limits, nil
+}
+
+func (m *MemoryStorage) Counters() (map[string]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	counters := make(map[string]int64)
+	for key, c := range m.counters {
+		if c.expiresAt.IsZero() || now.Before(c.expiresAt) {
+			counters[key] = c.value
+		}
+	}
+	return counters, nil
+}
+
+func (m *MemoryStorage) Blocks() (map[string]time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	blocks := make(map[string]time.Duration)
+	for key, blockTime := range m.blocks {
+		if ttl := blockTime.Sub(now); ttl > 0 {
+			blocks[key] = ttl
+		}
+	}
+	return blocks, nil
+}
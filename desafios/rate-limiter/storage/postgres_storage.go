@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+	key        TEXT PRIMARY KEY,
+	value      BIGINT NOT NULL,
+	expires_at TIMESTAMPTZ
+);
+CREATE TABLE IF NOT EXISTS rate_limit_blocks (
+	key        TEXT PRIMARY KEY,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limit_counters_expires_at ON rate_limit_counters (expires_at);
+CREATE INDEX IF NOT EXISTS rate_limit_blocks_expires_at ON rate_limit_blocks (expires_at);
+CREATE TABLE IF NOT EXISTS rate_limit_token_limits (
+	token       TEXT PRIMARY KEY,
+	limit_value INTEGER NOT NULL
+);
+`
+
+// O contador é reiniciado dentro do próprio upsert quando a janela anterior
+// expirou, então incrementos concorrentes na mesma chave são atômicos.
+const postgresIncrement = `
+INSERT INTO rate_limit_counters (key, value, expires_at)
+VALUES ($1, 1, $3)
+ON CONFLICT (key) DO UPDATE SET
+	value = CASE
+		WHEN rate_limit_counters.expires_at IS NOT NULL AND rate_limit_counters.expires_at <= $2 THEN 1
+		ELSE rate_limit_counters.value + 1
+	END,
+	expires_at = CASE
+		WHEN rate_limit_counters.expires_at IS NOT NULL AND rate_limit_counters.expires_at <= $2 THEN EXCLUDED.expires_at
+		ELSE rate_limit_counters.expires_at
+	END
+RETURNING value
+`
+
+type PostgresStorage struct {
+	pool *pgxpool.Pool
+	ctx  context.Context
+	now  func() time.Time
+}
+
+func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
+	return NewPostgresStorageWithClock(dsn, time.Now)
+}
+
+func NewPostgresStorageWithClock(dsn string, now func() time.Time) (*PostgresStorage, error) {
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create postgres schema: %w", err)
+	}
+
+	return &PostgresStorage{
+		pool: pool,
+		ctx:  ctx,
+		now:  now,
+	}, nil
+}
+
+func (p *PostgresStorage) Increment(key string, expiration time.Duration) (int64, error) {
+	blocked, err := p.IsBlocked(key)
+	if err != nil {
+		return 0, err
+	}
+	if blocked {
+		return -1, nil
+	}
+
+	now := p.now()
+	var expiresAt *time.Time
+	if expiration > 0 {
+		t := now.Add(expiration)
+		expiresAt = &t
+	}
+
+	var count int64
+	err = p.pool.QueryRow(p.ctx, postgresIncrement, key, now, expiresAt).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (p *PostgresStorage) Decrement(key string) error {
+	_, err := p.pool.Exec(p.ctx, `
+		UPDATE rate_limit_counters SET value = value - 1
+		WHERE key = $1 AND value > 0 AND (expires_at IS NULL OR expires_at > $2)`,
+		key, p.now())
+	return err
+}
+
+func (p *PostgresStorage) SetBlock(key string, duration time.Duration) error {
+	_, err := p.pool.Exec(p.ctx, `
+		INSERT INTO rate_limit_blocks (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at`,
+		key, p.now().Add(duration))
+	return err
+}
+
+func (p *PostgresStorage) IsBlocked(key string) (bool, error) {
+	ttl, err := p.BlockTTL(key)
+	if err != nil {
+		return false, err
+	}
+	return ttl > 0, nil
+}
+
+func (p *PostgresStorage) BlockTTL(key string) (time.Duration, error) {
+	var expiresAt time.Time
+	err := p.pool.QueryRow(p.ctx, `SELECT expires_at FROM rate_limit_blocks WHERE key = $1`, key).Scan(&expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := expiresAt.Sub(p.now())
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (p *PostgresStorage) Counter(key string) (int64, time.Duration, error) {
+	var (
+		value     int64
+		expiresAt *time.Time
+	)
+	err := p.pool.QueryRow(p.ctx, `SELECT value, expires_at FROM rate_limit_counters WHERE key = $1`, key).Scan(&value, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if expiresAt == nil {
+		return value, 0, nil
+	}
+
+	ttl := expiresAt.Sub(p.now())
+	if ttl <= 0 {
+		return 0, 0, nil
+	}
+	return value, ttl, nil
+}
+
+func (p *PostgresStorage) Reset(key string) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM rate_limit_counters WHERE key = $1`, key)
+	batch.Queue(`DELETE FROM rate_limit_blocks WHERE key = $1`, key)
+	return p.pool.SendBatch(p.ctx, batch).Close()
+}
+
+func (p *PostgresStorage) DeleteExpired() (int64, error) {
+	now := p.now()
+
+	counters, err := p.pool.Exec(p.ctx,
+		`DELETE FROM rate_limit_counters WHERE expires_at IS NOT NULL AND expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	blocks, err := p.pool.Exec(p.ctx, `DELETE FROM rate_limit_blocks WHERE expires_at <= $1`, now)
+	if err != nil {
+		return counters.RowsAffected(), err
+	}
+
+	return counters.RowsAffected() + blocks.RowsAffected(), nil
+}
+
+func (p *PostgresStorage) GetTokenLimit(token string) (int, error) {
+	var limit int
+	err := p.pool.QueryRow(p.ctx, `SELECT limit_value FROM rate_limit_token_limits WHERE token = $1`, token).Scan(&limit)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return limit, nil
+}
+
+func (p *PostgresStorage) SetTokenLimit(token string, limit int) error {
+	_, err := p.pool.Exec(p.ctx, `
+		INSERT INTO rate_limit_token_limits (token, limit_value) VALUES ($1, $2)
+		ON CONFLICT (token) DO UPDATE SET limit_value = EXCLUDED.limit_value`,
+		token, limit)
+	return err
+}
+
+func (p *PostgresStorage) DeleteTokenLimit(token string) error {
+	_, err := p.pool.Exec(p.ctx, `DELETE FROM rate_limit_token_limits WHERE token = $1`, token)
+	return err
+}
+
+func (p *PostgresStorage) TokenLimits() (map[string]int, error) {
+	rows, err := p.pool.Query(p.ctx, `SELECT token, limit_value FROM rate_limit_token_limits`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int)
+	for rows.Next() {
+		var (
+			token string
+			limit int
+		)
+		if err := rows.Scan(&token, &limit); err != nil {
+			return nil, err
+		}
+		limits[token] = limit
+	}
+	return limits, rows.Err()
+}
+
+func (p *PostgresStorage) Counters() (map[string]int64, error) {
+	rows, err := p.pool.Query(p.ctx, `
+		SELECT key, value FROM rate_limit_counters
+		WHERE expires_at IS NULL OR expires_at > $1`, p.now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counters := make(map[string]int64)
+	for rows.Next() {
+		var (
+			key   string
+			value int64
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		counters[key] = value
+	}
+	return counters, rows.Err()
+}
+
+func (p *PostgresStorage) Blocks() (map[string]time.Duration, error) {
+	now := p.now()
+	rows, err := p.pool.Query(p.ctx, `SELECT key, expires_at FROM rate_limit_blocks WHERE expires_at > $1`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := make(map[string]time.Duration)
+	for rows.Next() {
+		var (
+			key       string
+			expiresAt time.Time
+		)
+		if err := rows.Scan(&key, &expiresAt); err != nil {
+			return nil, err
+		}
+		blocks[key] = expiresAt.Sub(now)
+	}
+	return blocks, rows.Err()
+}
+
+func (p *PostgresStorage) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+func (p *PostgresStorage) Close() error {
+	p.pool.Close()
+	return nil
+}
@@ -4,17 +4,53 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// incrementScript define a expiração apenas no primeiro incremento, mantendo
+// a janela fixa mesmo com tráfego contínuo.
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if tonumber(ARGV[1]) > 0 and (count == 1 or redis.call('PTTL', KEYS[1]) == -1) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// decrementScript só decrementa contadores ainda vivos: um DECR em chave
+// expirada criaria um contador negativo e sem expiração.
+var decrementScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+	redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// DefaultCounterPatterns são os padrões das chaves de contador gravadas pelo
+// limiter e pelo client: Counters só varre essas chaves, para não percorrer
+// o banco inteiro quando ele é compartilhado com outras aplicações.
+var DefaultCounterPatterns = []string{"global", "ip:*", "token:*", "tenant:*", "conn:*", "msg:*", "client:*"}
+
 type RedisStorage struct {
-	client *redis.Client
-	ctx    context.Context
+	client          *redis.Client
+	ctx             context.Context
+	counterPatterns []string
+}
+
+type RedisOption func(*RedisStorage)
+
+// WithCounterPatterns substitui os padrões de chave (sintaxe do SCAN MATCH)
+// considerados contadores por Counters.
+func WithCounterPatterns(patterns ...string) RedisOption {
+	return func(r *RedisStorage) {
+		r.counterPatterns = patterns
+	}
 }
 
-func NewRedisStorage(host string, port string, password string, db int) (*RedisStorage, error) {
+func NewRedisStorage(host string, port string, password string, db int, opts ...RedisOption) (*RedisStorage, error) {
 	addr := fmt.Sprintf("%s:%s", host, port)
 
 	client := redis.NewClient(&redis.Options{
@@ -23,16 +59,25 @@ func NewRedisStorage(host string, port string, password string, db int) (*RedisS
 		DB:       db,
 	})
 
+	return NewRedisStorageFromClient(client, opts...)
+}
+
+func NewRedisStorageFromClient(client *redis.Client, opts ...RedisOption) (*RedisStorage, error) {
 	ctx := context.Background()
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &RedisStorage{
-		client: client,
-		ctx:    ctx,
-	}, nil
+	r := &RedisStorage{
+		client:          client,
+		ctx:             ctx,
+		counterPatterns: DefaultCounterPatterns,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
 func (r *RedisStorage) Increment(key string, expiration time.Duration) (int64, error) {
@@ -44,15 +89,11 @@ func (r *RedisStorage) Increment(key string, expiration time.Duration) (int64, e
 		return -1, nil
 	}
 
-	pipe := r.client.Pipeline()
-	incr := pipe.Incr(r.ctx, key)
-	pipe.Expire(r.ctx, key, expiration)
-	_, err = pipe.Exec(r.ctx)
-	if err != nil {
-		return 0, err
-	}
+	return incrementScript.Run(r.ctx, r.client, []string{key}, expiration.Milliseconds()).Int64()
+}
 
-	return incr.Val(), nil
+func (r *RedisStorage) Decrement(key string) error {
+	return decrementScript.Run(r.ctx, r.client, []string{key}).Err()
 }
 
 func (r *RedisStorage) SetBlock(key string, duration time.Duration) error {
@@ -69,6 +110,42 @@ func (r *RedisStorage) IsBlocked(key string) (bool, error) {
 	return val > 0, nil
 }
 
+func (r *RedisStorage) BlockTTL(key string) (time.Duration, error) {
+	blockKey := fmt.Sprintf("block:%s", key)
+	ttl, err := r.client.PTTL(r.ctx, blockKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *RedisStorage) Counter(key string) (int64, time.Duration, error) {
+	pipe := r.client.Pipeline()
+	get := pipe.Get(r.ctx, key)
+	pttl := pipe.PTTL(r.ctx, key)
+	_, err := pipe.Exec(r.ctx)
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value, err := get.Int64()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0
+	}
+	return value, ttl, nil
+}
+
 func (r *RedisStorage) Reset(key string) error {
 	pipe := r.client.Pipeline()
 	pipe.Del(r.ctx, key)
@@ -100,6 +177,125 @@ func (r *RedisStorage) SetTokenLimit(token string, limit int) error {
 	return r.client.Set(r.ctx, key, limit, 0).Err()
 }
 
+func (r *RedisStorage) DeleteTokenLimit(token string) error {
+	key := fmt.Sprintf("token_limit:%s", token)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+func (r *RedisStorage) TokenLimits() (map[string]int, error) {
+	values, err := r.scanValues("token_limit:*")
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]int, len(values))
+	for key, value := range values {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token limit in %s: %w", key, err)
+		}
+		limits[strings.TrimPrefix(key, "token_limit:")] = limit
+	}
+	return limits, nil
+}
+
+// Counters lista as chaves de counterPatterns. Chaves de outro tipo (por
+// exemplo, um hash de outra aplicação no mesmo banco) são ignoradas.
+func (r *RedisStorage) Counters() (map[string]int64, error) {
+	counters := make(map[string]int64)
+	for _, pattern := range r.counterPatterns {
+		values, err := r.scanValues(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range values {
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			counters[key] = count
+		}
+	}
+	return counters, nil
+}
+
+func (r *RedisStorage) Blocks() (map[string]time.Duration, error) {
+	keys, err := r.scanKeys("block:*")
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := r.client.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		ttls[i] = pipe.PTTL(r.ctx, key)
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	blocks := make(map[string]time.Duration, len(keys))
+	for i, key := range keys {
+		if ttl := ttls[i].Val(); ttl > 0 {
+			blocks[strings.TrimPrefix(key, "block:")] = ttl
+		}
+	}
+	return blocks, nil
+}
+
+func (r *RedisStorage) scanKeys(pattern string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(r.ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(r.ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// scanValues lê os valores das chaves de pattern. Chaves que expiram entre o
+// SCAN e o GET, ou que não são strings, são ignoradas.
+func (r *RedisStorage) scanValues(pattern string) (map[string]string, error) {
+	keys, err := r.scanKeys(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := r.client.Pipeline()
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		gets[i] = pipe.Get(r.ctx, key)
+	}
+	if len(keys) > 0 {
+		// Exec retorna o erro do primeiro comando que falhou; os erros são
+		// avaliados por chave abaixo.
+		pipe.Exec(r.ctx)
+	}
+
+	values := make(map[string]string, len(keys))
+	for i, key := range keys {
+		value, err := gets[i].Result()
+		switch {
+		case err == nil:
+			values[key] = value
+		case err == redis.Nil || isWrongType(err):
+		default:
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+func (r *RedisStorage) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisStorage) Close() error {
 	return r.client.Close()
 }
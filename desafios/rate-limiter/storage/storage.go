@@ -1,6 +1,9 @@
 package storage
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type TokenLimiter interface {
 	GetTokenLimit(token string) (int, error)
@@ -12,3 +15,45 @@ type Storage interface {
 	IsBlocked(key string) (bool, error)
 	Reset(key string) error
 }
+
+// Decrementer desfaz um Increment dentro da mesma janela. Não faz nada se a
+// janela já expirou.
+type Decrementer interface {
+	Decrement(key string) error
+}
+
+type BlockInspector interface {
+	BlockTTL(key string) (time.Duration, error)
+}
+
+// CounterInspector lê o contador atual de uma chave sem incrementá-lo,
+// junto com o tempo restante até o fim da janela.
+type CounterInspector interface {
+	Counter(key string) (int64, time.Duration, error)
+}
+
+// TokenLimitStore administra os limites customizados de token.
+type TokenLimitStore interface {
+	TokenLimiter
+	SetTokenLimit(token string, limit int) error
+	DeleteTokenLimit(token string) error
+	TokenLimits() (map[string]int, error)
+}
+
+// KeyLister enumera as chaves ativas: contadores dentro da janela e bloqueios
+// ainda não expirados.
+type KeyLister interface {
+	Counters() (map[string]int64, error)
+	Blocks() (map[string]time.Duration, error)
+}
+
+// Sweeper remove contadores e bloqueios expirados. Backends sem expiração
+// nativa (PostgreSQL, BoltDB, memória) só descartam uma chave quando ela é
+// usada de novo; sem a limpeza periódica, crescem indefinidamente.
+type Sweeper interface {
+	DeleteExpired() (int64, error)
+}
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
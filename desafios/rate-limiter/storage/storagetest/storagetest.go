@@ -0,0 +1,467 @@
+// Package storagetest contém uma suíte de conformidade que qualquer
+// implementação de storage.Storage deve satisfazer.
+package storagetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"rate-limiter/storage"
+)
+
+// Backend é a instância sob teste. Advance deve avançar o relógio do backend
+// (relógio falso, FastForward do miniredis, etc.) em d.
+type Backend struct {
+	Storage storage.Storage
+	Advance func(d time.Duration)
+}
+
+// Factory cria um backend vazio e isolado para cada subteste.
+type Factory func(t *testing.T) Backend
+
+type tokenLimitSetter interface {
+	SetTokenLimit(token string, limit int) error
+}
+
+func Run(t *testing.T, newBackend Factory) {
+	t.Run("IncrementCounts", func(t *testing.T) { testIncrementCounts(t, newBackend(t)) })
+	t.Run("KeysAreIndependent", func(t *testing.T) { testKeysAreIndependent(t, newBackend(t)) })
+	t.Run("WindowExpires", func(t *testing.T) { testWindowExpires(t, newBackend(t)) })
+	t.Run("WindowIsFixed", func(t *testing.T) { testWindowIsFixed(t, newBackend(t)) })
+	t.Run("Block", func(t *testing.T) { testBlock(t, newBackend(t)) })
+	t.Run("BlockExpires", func(t *testing.T) { testBlockExpires(t, newBackend(t)) })
+	t.Run("BlockTTL", func(t *testing.T) { testBlockTTL(t, newBackend(t)) })
+	t.Run("Counter", func(t *testing.T) { testCounter(t, newBackend(t)) })
+	t.Run("Reset", func(t *testing.T) { testReset(t, newBackend(t)) })
+	t.Run("TokenLimits", func(t *testing.T) { testTokenLimits(t, newBackend(t)) })
+	t.Run("TokenLimitStore", func(t *testing.T) { testTokenLimitStore(t, newBackend(t)) })
+	t.Run("KeyLister", func(t *testing.T) { testKeyLister(t, newBackend(t)) })
+	t.Run("Decrementer", func(t *testing.T) { testDecrementer(t, newBackend(t)) })
+	t.Run("Sweeper", func(t *testing.T) { testSweeper(t, newBackend(t)) })
+	t.Run("ConcurrentIncrements", func(t *testing.T) { testConcurrentIncrements(t, newBackend(t)) })
+}
+
+func increment(t *testing.T, s storage.Storage, key string, window time.Duration) int64 {
+	t.Helper()
+
+	count, err := s.Increment(key, window)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return count
+}
+
+func isBlocked(t *testing.T, s storage.Storage, key string) bool {
+	t.Helper()
+
+	blocked, err := s.IsBlocked(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return blocked
+}
+
+func testIncrementCounts(t *testing.T, b Backend) {
+	for i := int64(1); i <= 3; i++ {
+		if count := increment(t, b.Storage, "conformance:count", time.Second); count != i {
+			t.Errorf("Expected count %d, got %d", i, count)
+		}
+	}
+}
+
+func testKeysAreIndependent(t *testing.T, b Backend) {
+	increment(t, b.Storage, "conformance:a", time.Second)
+	increment(t, b.Storage, "conformance:a", time.Second)
+
+	if count := increment(t, b.Storage, "conformance:b", time.Second); count != 1 {
+		t.Errorf("Expected count 1 for a different key, got %d", count)
+	}
+}
+
+func testWindowExpires(t *testing.T, b Backend) {
+	key := "conformance:window"
+	for i := 0; i < 3; i++ {
+		increment(t, b.Storage, key, time.Second)
+	}
+
+	b.Advance(1500 * time.Millisecond)
+
+	if count := increment(t, b.Storage, key, time.Second); count != 1 {
+		t.Errorf("Expected count 1 after window expired, got %d", count)
+	}
+}
+
+func testWindowIsFixed(t *testing.T, b Backend) {
+	key := "conformance:fixed"
+	increment(t, b.Storage, key, time.Second)
+
+	b.Advance(600 * time.Millisecond)
+	if count := increment(t, b.Storage, key, time.Second); count != 2 {
+		t.Errorf("Expected count 2 inside the window, got %d", count)
+	}
+
+	// A segunda requisição não pode estender a janela iniciada pela primeira.
+	b.Advance(600 * time.Millisecond)
+	if count := increment(t, b.Storage, key, time.Second); count != 1 {
+		t.Errorf("Expected count 1 in the next window, got %d", count)
+	}
+}
+
+func testBlock(t *testing.T, b Backend) {
+	key := "conformance:block"
+
+	if isBlocked(t, b.Storage, key) {
+		t.Error("Key should not start blocked")
+	}
+
+	if err := b.Storage.SetBlock(key, 5*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !isBlocked(t, b.Storage, key) {
+		t.Error("Key should be blocked")
+	}
+	if count := increment(t, b.Storage, key, time.Second); count != -1 {
+		t.Errorf("Expected -1 (blocked), got %d", count)
+	}
+	if isBlocked(t, b.Storage, "conformance:other") {
+		t.Error("Block should not affect other keys")
+	}
+}
+
+func testBlockExpires(t *testing.T, b Backend) {
+	key := "conformance:block-expires"
+
+	if err := b.Storage.SetBlock(key, 2*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b.Advance(3 * time.Second)
+
+	if isBlocked(t, b.Storage, key) {
+		t.Error("Block should have expired")
+	}
+	if count := increment(t, b.Storage, key, time.Second); count != 1 {
+		t.Errorf("Expected count 1 after block expired, got %d", count)
+	}
+}
+
+func testBlockTTL(t *testing.T, b Backend) {
+	inspector, ok := b.Storage.(storage.BlockInspector)
+	if !ok {
+		t.Skip("backend does not implement storage.BlockInspector")
+	}
+
+	key := "conformance:block-ttl"
+
+	ttl, err := inspector.BlockTTL(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ttl != 0 {
+		t.Errorf("Expected TTL 0 for an unblocked key, got %v", ttl)
+	}
+
+	if err := b.Storage.SetBlock(key, 10*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b.Advance(4 * time.Second)
+
+	ttl, err = inspector.BlockTTL(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ttl <= 5*time.Second || ttl > 6*time.Second {
+		t.Errorf("Expected TTL of about 6s, got %v", ttl)
+	}
+}
+
+func testCounter(t *testing.T, b Backend) {
+	inspector, ok := b.Storage.(storage.CounterInspector)
+	if !ok {
+		t.Skip("backend does not implement storage.CounterInspector")
+	}
+
+	key := "conformance:counter"
+
+	value, ttl, err := inspector.Counter(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 0 || ttl != 0 {
+		t.Errorf("Expected empty counter, got %d with TTL %v", value, ttl)
+	}
+
+	increment(t, b.Storage, key, 10*time.Second)
+	increment(t, b.Storage, key, 10*time.Second)
+	b.Advance(4 * time.Second)
+
+	value, ttl, err = inspector.Counter(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 2 {
+		t.Errorf("Expected counter 2, got %d", value)
+	}
+	if ttl <= 5*time.Second || ttl > 6*time.Second {
+		t.Errorf("Expected TTL of about 6s, got %v", ttl)
+	}
+
+	if count := increment(t, b.Storage, key, 10*time.Second); count != 3 {
+		t.Errorf("Reading the counter should not increment it, got %d", count)
+	}
+
+	b.Advance(7 * time.Second)
+
+	value, _, err = inspector.Counter(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 0 {
+		t.Errorf("Expected counter 0 after window expired, got %d", value)
+	}
+}
+
+func testReset(t *testing.T, b Backend) {
+	key := "conformance:reset"
+
+	increment(t, b.Storage, key, time.Second)
+	increment(t, b.Storage, key, time.Second)
+	if err := b.Storage.SetBlock(key, time.Minute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := b.Storage.Reset(key); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if isBlocked(t, b.Storage, key) {
+		t.Error("Key should not be blocked after reset")
+	}
+	if count := increment(t, b.Storage, key, time.Second); count != 1 {
+		t.Errorf("Expected count 1 after reset, got %d", count)
+	}
+}
+
+func testTokenLimits(t *testing.T, b Backend) {
+	limiter, ok := b.Storage.(storage.TokenLimiter)
+	if !ok {
+		t.Skip("backend does not implement storage.TokenLimiter")
+	}
+	setter, ok := b.Storage.(tokenLimitSetter)
+	if !ok {
+		t.Skip("backend does not support SetTokenLimit")
+	}
+
+	if err := setter.SetTokenLimit("conformance-token", 42); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	limit, err := limiter.GetTokenLimit("conformance-token")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if limit != 42 {
+		t.Errorf("Expected limit 42, got %d", limit)
+	}
+
+	limit, err = limiter.GetTokenLimit("conformance-unknown")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if limit != 0 {
+		t.Errorf("Expected limit 0 for unknown token, got %d", limit)
+	}
+}
+
+func testTokenLimitStore(t *testing.T, b Backend) {
+	store, ok := b.Storage.(storage.TokenLimitStore)
+	if !ok {
+		t.Skip("backend does not implement storage.TokenLimitStore")
+	}
+
+	for token, limit := range map[string]int{"conformance-a": 10, "conformance-b": 20} {
+		if err := store.SetTokenLimit(token, limit); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := store.DeleteTokenLimit("conformance-a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	limits, err := store.TokenLimits()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(limits) != 1 || limits["conformance-b"] != 20 {
+		t.Errorf("Expected only conformance-b=20, got %v", limits)
+	}
+
+	limit, err := store.GetTokenLimit("conformance-a")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if limit != 0 {
+		t.Errorf("Expected limit 0 after delete, got %d", limit)
+	}
+
+	if err := store.DeleteTokenLimit("conformance-unknown"); err != nil {
+		t.Errorf("Deleting an unknown token should not fail: %v", err)
+	}
+}
+
+func testKeyLister(t *testing.T, b Backend) {
+	lister, ok := b.Storage.(storage.KeyLister)
+	if !ok {
+		t.Skip("backend does not implement storage.KeyLister")
+	}
+
+	increment(t, b.Storage, "conformance:short", time.Second)
+	for i := 0; i < 3; i++ {
+		increment(t, b.Storage, "conformance:long", time.Minute)
+	}
+	if err := b.Storage.SetBlock("conformance:blocked", 10*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Storage.SetBlock("conformance:expired", time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b.Advance(2 * time.Second)
+
+	counters, err := lister.Counters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(counters) != 1 || counters["conformance:long"] != 3 {
+		t.Errorf("Expected only conformance:long=3, got %v", counters)
+	}
+
+	blocks, err := lister.Blocks()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("Expected only conformance:blocked, got %v", blocks)
+	}
+	if ttl := blocks["conformance:blocked"]; ttl <= 7*time.Second || ttl > 8*time.Second {
+		t.Errorf("Expected TTL of about 8s, got %v", ttl)
+	}
+}
+
+func testDecrementer(t *testing.T, b Backend) {
+	decrementer, ok := b.Storage.(storage.Decrementer)
+	if !ok {
+		t.Skip("backend does not implement storage.Decrementer")
+	}
+
+	key := "conformance:decrement"
+	increment(t, b.Storage, key, time.Second)
+	increment(t, b.Storage, key, time.Second)
+
+	if err := decrementer.Decrement(key); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count := increment(t, b.Storage, key, time.Second); count != 2 {
+		t.Errorf("Expected count 2 after decrement, got %d", count)
+	}
+
+	// A janela continua a mesma: o decremento não a estende.
+	b.Advance(1500 * time.Millisecond)
+	if err := decrementer.Decrement(key); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count := increment(t, b.Storage, key, time.Second); count != 1 {
+		t.Errorf("Expected count 1 in the next window, got %d", count)
+	}
+
+	if err := decrementer.Decrement("conformance:unknown"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count := increment(t, b.Storage, "conformance:unknown", time.Second); count != 1 {
+		t.Errorf("Decrementing an unknown key should not create a counter, got count %d", count)
+	}
+}
+
+func testSweeper(t *testing.T, b Backend) {
+	sweeper, ok := b.Storage.(storage.Sweeper)
+	if !ok {
+		t.Skip("backend does not implement storage.Sweeper")
+	}
+
+	increment(t, b.Storage, "conformance:short", time.Second)
+	increment(t, b.Storage, "conformance:long", time.Minute)
+	if err := b.Storage.SetBlock("conformance:expired", time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Storage.SetBlock("conformance:blocked", time.Minute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b.Advance(2 * time.Second)
+
+	deleted, err := sweeper.DeleteExpired()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 expired keys deleted, got %d", deleted)
+	}
+
+	if deleted, err := sweeper.DeleteExpired(); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing left to delete, got %d (err %v)", deleted, err)
+	}
+
+	if count := increment(t, b.Storage, "conformance:long", time.Minute); count != 2 {
+		t.Errorf("Live counter should survive the sweep, expected count 2, got %d", count)
+	}
+	if !isBlocked(t, b.Storage, "conformance:blocked") {
+		t.Error("Live block should survive the sweep")
+	}
+}
+
+func testConcurrentIncrements(t *testing.T, b Backend) {
+	const (
+		workers    = 20
+		increments = 25
+	)
+	key := "conformance:concurrent"
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[int64]bool)
+		errs = make(chan error, workers*increments)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				count, err := b.Storage.Increment(key, time.Minute)
+				if err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				seen[count] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(seen) != workers*increments {
+		t.Errorf("Expected %d distinct counter values, got %d", workers*increments, len(seen))
+	}
+	if count := increment(t, b.Storage, key, time.Minute); count != workers*increments+1 {
+		t.Errorf("Expected count %d, got %d", workers*increments+1, count)
+	}
+}
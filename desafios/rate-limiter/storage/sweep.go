@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunSweeper remove as chaves expiradas de s a cada interval, até o contexto
+// ser cancelado.
+func RunSweeper(ctx context.Context, s Sweeper, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteExpired(); err != nil {
+				log.Printf("failed to delete expired keys: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package stream aplica o rate limiter às mensagens de conexões WebSocket:
+// cada mensagem recebida conta no limite da conexão e, ao exceder, a conexão
+// é encerrada com um close frame em vez de ser derrubada.
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"rate-limiter/limiter"
+)
+
+const CloseReason = "message rate limit exceeded"
+
+var ErrMessageRateExceeded = errors.New(CloseReason)
+
+// closeTimeout limita a escrita do close frame para um cliente que não lê.
+const closeTimeout = time.Second
+
+// Conn envolve uma *websocket.Conn limitando as mensagens lidas com
+// Limiter.CheckMessage.
+type Conn struct {
+	*websocket.Conn
+	limiter *limiter.Limiter
+	id      string
+}
+
+// NewConn cria a conexão limitada com um identificador aleatório, usado na
+// chave msg:<id> do storage.
+func NewConn(conn *websocket.Conn, l *limiter.Limiter) *Conn {
+	return NewConnWithID(conn, l, newConnectionID())
+}
+
+func NewConnWithID(conn *websocket.Conn, l *limiter.Limiter, id string) *Conn {
+	return &Conn{
+		Conn:    conn,
+		limiter: l,
+		id:      id,
+	}
+}
+
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// ReadMessage lê a próxima mensagem e a conta no limite da conexão. Ao
+// exceder, envia um close frame 1008 (policy violation), fecha a conexão e
+// retorna ErrMessageRateExceeded; a mensagem excedente é descartada.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType, data, err := c.Conn.ReadMessage()
+	if err != nil {
+		return messageType, data, err
+	}
+
+	result, err := c.limiter.CheckMessage(c.id)
+	if err != nil {
+		c.CloseWithReason(websocket.CloseInternalServerErr, "internal server error")
+		return 0, nil, err
+	}
+	if !result.Allowed {
+		c.CloseWithReason(websocket.ClosePolicyViolation, CloseReason)
+		return 0, nil, ErrMessageRateExceeded
+	}
+
+	return messageType, data, nil
+}
+
+// CloseWithReason envia o close frame e fecha a conexão.
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	message := websocket.FormatCloseMessage(code, reason)
+	err := c.Conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeTimeout))
+	if closeErr := c.Conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func newConnectionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
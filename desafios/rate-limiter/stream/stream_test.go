@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"rate-limiter/config"
+	"rate-limiter/limiter"
+	"rate-limiter/storage"
+)
+
+func newEchoServer(t *testing.T, messages int, serverErr chan<- error) string {
+	t.Helper()
+
+	rateLimiter := limiter.NewLimiter(storage.NewMemoryStorage(), &config.Config{RateLimitMessages: messages})
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			return
+		}
+		conn := NewConn(ws, rateLimiter)
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				serverErr <- err
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestConn_ClosesWhenMessageRateExceeded(t *testing.T) {
+	serverErr := make(chan error, 1)
+	url := newEchoServer(t, 3, serverErr)
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := client.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, data, err := client.ReadMessage(); err != nil || string(data) != "ping" {
+			t.Fatalf("Message %d should be echoed, got %q (%v)", i+1, data, err)
+		}
+	}
+
+	if err := client.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, _, err = client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected a close frame, got %v", err)
+	}
+	if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != CloseReason {
+		t.Errorf("Expected close 1008 %q, got %d %q", CloseReason, closeErr.Code, closeErr.Text)
+	}
+
+	if err := <-serverErr; !errors.Is(err, ErrMessageRateExceeded) {
+		t.Errorf("Server should see ErrMessageRateExceeded, got %v", err)
+	}
+}
+
+func TestConn_LimitsAreIndependentPerConnection(t *testing.T) {
+	serverErr := make(chan error, 2)
+	url := newEchoServer(t, 2, serverErr)
+
+	for c := 0; c < 2; c++ {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			client.WriteMessage(websocket.TextMessage, []byte("ping"))
+			if _, _, err := client.ReadMessage(); err != nil {
+				t.Fatalf("Connection %d, message %d should be echoed: %v", c+1, i+1, err)
+			}
+		}
+		client.Close()
+	}
+}